@@ -1,11 +1,14 @@
 package accumulator
 
 import (
-	"fmt"
 	"math/big"
-	"time"
+	"runtime"
+	"sync"
+	"sync/atomic"
 
 	"github.com/jiajunxin/multiexp"
+	"github.com/jiajunxin/rsa_accumulator/group"
+	"github.com/jiajunxin/rsa_accumulator/trace"
 	"github.com/remyoudompheng/bigfft"
 )
 
@@ -30,47 +33,188 @@ func TrustedSetup() *Setup {
 	return ret
 }
 
-// GenRepresentatives generates different representatives that can be inputted into RSA accumulator
+// GenRepresentatives generates different representatives that can be inputted into RSA accumulator,
+// it panics on an unknown EncodeType, which older versions encoded with HashToPrimeFromSha256, or an element
+// the encoder cannot handle. GenRepresentativesWithError returns these errors instead.
 func GenRepresentatives(set []string, encodeType EncodeType) []*big.Int {
-	switch encodeType {
-	case HashToPrimeFromSha256:
-		return genRepWithHashToPrimeFromSHA256(set)
-	case DIHashFromPoseidon:
-		return genRepWithDIHashFromPoseidon(set)
-	default:
-		return genRepWithHashToPrimeFromSHA256(set)
-	}
-}
-
-// AccAndProve generates the accumulator with all the memberships precomputed
-func AccAndProve(set []string, encodeType EncodeType, setup *Setup) (*big.Int, []*big.Int) {
-	startingTime := time.Now().UTC()
-	rep := GenRepresentatives(set, encodeType)
-	endingTime := time.Now().UTC()
-	var duration = endingTime.Sub(startingTime)
-	fmt.Printf("Running GenRepresentatives Takes [%.3f] Seconds \n",
-		duration.Seconds())
-
-	proofs := ProveMembership(setup.G, setup.N, rep)
-	// we generate the accumulator by anyone of the membership proof raised to its power to save some calculation
-	acc := AccumulateNew(proofs[0], rep[0], setup.N)
+	ret, err := GenRepresentativesWithError(set, encodeType)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// GenRepresentativesWithError is GenRepresentatives returning ErrUnknownEncodeType or the error of the encoder
+// instead of panicking
+func GenRepresentativesWithError(set []string, encodeType EncodeType) ([]*big.Int, error) {
+	encoder, err := lookupEncoder(encodeType)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*big.Int, len(set))
+	for i, v := range set {
+		if ret[i], err = encoder.Encode(v); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// repChunkSize is the number of elements a worker of GenRepresentativesParallel takes at once
+const repChunkSize = 256
+
+// GenRepresentativesParallel is GenRepresentatives on numWorkers goroutines, all CPUs if numWorkers <= 0.
+// The representatives are in the order of the set. It panics like GenRepresentatives.
+func GenRepresentativesParallel(set []string, encodeType EncodeType, numWorkers int) []*big.Int {
+	ret, err := GenRepresentativesParallelWithError(set, encodeType, numWorkers)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// GenRepresentativesParallelWithError is GenRepresentativesParallel returning the first error instead of panicking.
+// The workers take chunks of the set, so that elements with a longer prime search do not hold the others back,
+// and encoders implementing ClonableEncoder are cloned once per worker.
+func GenRepresentativesParallelWithError(set []string, encodeType EncodeType, numWorkers int) ([]*big.Int, error) {
+	encoder, err := lookupEncoder(encodeType)
+	if err != nil {
+		return nil, err
+	}
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+	if maxWorkers := (len(set) + repChunkSize - 1) / repChunkSize; numWorkers > maxWorkers {
+		numWorkers = maxWorkers
+	}
+	ret := make([]*big.Int, len(set))
+	var (
+		next     int64
+		failed   int32
+		firstErr error
+		errOnce  sync.Once
+		wg       sync.WaitGroup
+	)
+	for w := 0; w < numWorkers; w++ {
+		workerEncoder := encoder
+		if c, ok := encoder.(ClonableEncoder); ok {
+			workerEncoder = c.Clone()
+		}
+		wg.Add(1)
+		go func(encoder Encoder) {
+			defer wg.Done()
+			for atomic.LoadInt32(&failed) == 0 {
+				start := int(atomic.AddInt64(&next, repChunkSize)) - repChunkSize
+				if start >= len(set) {
+					return
+				}
+				end := start + repChunkSize
+				if end > len(set) {
+					end = len(set)
+				}
+				for i := start; i < end; i++ {
+					rep, err := encoder.Encode(set[i])
+					if err != nil {
+						errOnce.Do(func() { firstErr = err })
+						atomic.StoreInt32(&failed, 1)
+						return
+					}
+					ret[i] = rep
+				}
+			}
+		}(workerEncoder)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return ret, nil
+}
 
+// AccAndProve generates the accumulator with all the memberships precomputed, it panics on an empty set,
+// an unknown EncodeType, an element the encoder cannot handle or a trapdoor of another modulus
+func AccAndProve(set []string, encodeType EncodeType, setup *Setup, opts ...trace.Option) (*big.Int, []*big.Int) {
+	acc, proofs, err := AccAndProveWithError(set, encodeType, setup, opts...)
+	if err != nil {
+		panic(err)
+	}
 	return acc, proofs
 }
 
-// AccAndProveIter iteratively generates the accumulator with all the memberships precomputed
+// AccAndProveWithError is AccAndProve returning ErrInvalidInput for an empty set, ErrUnknownEncodeType,
+// the error of the encoder or ErrInvalidTrapdoor instead of panicking. WithTrapdoor makes it TrapdoorSetup.AccAndProve.
+func AccAndProveWithError(set []string, encodeType EncodeType, setup *Setup, opts ...trace.Option) (*big.Int, []*big.Int, error) {
+	ts, err := trapdoorOf(setup.N, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return accAndProve(set, encodeType, setup, ts, opts...)
+}
+
+// accAndProve is AccAndProve with the exponentiations done with the trapdoor if it is not nil
+func accAndProve(set []string, encodeType EncodeType, setup *Setup, ts *TrapdoorSetup,
+	opts ...trace.Option) (*big.Int, []*big.Int, error) {
+	if len(set) == 0 {
+		return nil, nil, ErrInvalidInput
+	}
+	tracer := trace.NewOptions(opts...).Tracer
+	tracer.StartPhase(trace.PhaseGenRepresentatives)
+	rep, err := GenRepresentativesWithError(set, encodeType)
+	tracer.EndPhase(trace.PhaseGenRepresentatives)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tracer.StartPhase(trace.PhasePrecompute)
+	var proofs []*big.Int
+	if ts != nil {
+		proofs = ts.ProveMembership(setup.G, rep)
+	} else {
+		proofs = ProveMembership(setup.G, setup.N, rep)
+	}
+	tracer.EndPhase(trace.PhasePrecompute)
+	// we generate the accumulator by anyone of the membership proof raised to its power to save some calculation
+	acc := proverExp(setup, ts, proofs[0], rep[0])
+
+	return acc, proofs, nil
+}
+
+// AccAndProveIter iteratively generates the accumulator with all the memberships precomputed,
+// it panics on an empty set, an unknown EncodeType or an element the encoder cannot handle
 func AccAndProveIter(set []string, encodeType EncodeType, setup *Setup) (*big.Int, []*big.Int) {
-	rep := GenRepresentatives(set, encodeType)
+	acc, proofs, err := AccAndProveIterWithError(set, encodeType, setup)
+	if err != nil {
+		panic(err)
+	}
+	return acc, proofs
+}
+
+// AccAndProveIterWithError is AccAndProveIter returning ErrInvalidInput for an empty set, ErrUnknownEncodeType or
+// the error of the encoder instead of panicking
+func AccAndProveIterWithError(set []string, encodeType EncodeType, setup *Setup) (*big.Int, []*big.Int, error) {
+	if len(set) == 0 {
+		return nil, nil, ErrInvalidInput
+	}
+	rep, err := GenRepresentativesWithError(set, encodeType)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	proofs := ProveMembershipIter(*setup.G, setup.N, rep)
 	// we generate the accumulator by anyone of the membership proof raised to its power to save some calculation
 	acc := AccumulateNew(proofs[0], rep[0], setup.N)
 
-	return acc, proofs
+	return acc, proofs, nil
 }
 
 // ProveMembershipSingleThreadWithRandomizer uses divide-and-conquer method to pre-compute the all membership proofs in time O(nlog(n))
-func ProveMembershipSingleThreadWithRandomizer(base, randomizer, N *big.Int, set []*big.Int, table *multiexp.PreTable) []*big.Int {
+// WithTrapdoor computes the proofs from base^randomizer with CRT instead, the table is not needed then.
+// A trapdoor of another modulus is ignored.
+func ProveMembershipSingleThreadWithRandomizer(base, randomizer, N *big.Int, set []*big.Int, table *multiexp.PreTable,
+	opts ...trace.Option) []*big.Int {
+	if ts := trapdoorFor(N, opts); ts != nil {
+		return ts.proveWithRandomizer(base, randomizer, set, 1)
+	}
 	// the left part of proof need to accumulate the right part of the set, vice versa.
 	leftProd := SetProductRecursiveFast(set[len(set)/2:])
 	rightProd := SetProductRecursiveFast(set[0 : len(set)/2])
@@ -98,30 +242,9 @@ func ProveMembershipSingleThreadWithRandomizer(base, randomizer, N *big.Int, set
 
 // ProveMembership uses divide-and-conquer method to pre-compute the all membership proofs in time O(nlog(n))
 func ProveMembership(base, N *big.Int, set []*big.Int) []*big.Int {
-
-	if len(set) <= 4 {
-		return handleSmallSet(base, N, set)
-	}
-
-	leftProd := SetProductRecursiveFast(set[len(set)/2:])
-	rightProd := SetProductRecursiveFast(set[0 : len(set)/2])
-	leftleftProd := SetProductRecursiveFast(set[len(set)/4 : len(set)/2])
-	leftrightProd := SetProductRecursiveFast(set[0 : len(set)/4])
-	rightleftProd := SetProductRecursiveFast(set[len(set)*3/4:])
-	rightrightProd := SetProductRecursiveFast(set[len(set)/2 : len(set)*3/4])
-
-	var inputExp [4]*big.Int
-	inputExp[0] = bigfft.Mul(leftProd, leftleftProd)
-	inputExp[1] = bigfft.Mul(leftProd, leftrightProd)
-	inputExp[2] = bigfft.Mul(rightProd, rightleftProd)
-	inputExp[3] = bigfft.Mul(rightProd, rightrightProd)
-
-	bases := multiexp.FourfoldExp(base, N, inputExp)
-	proofs := ProveMembership(bases[0], N, set[0:len(set)/4])
-	proofs = append(proofs, ProveMembership(bases[1], N, set[len(set)/4:len(set)/2])...)
-	proofs = append(proofs, ProveMembership(bases[2], N, set[len(set)/2:len(set)*3/4])...)
-	proofs = append(proofs, ProveMembership(bases[3], N, set[len(set)*3/4:])...)
-	return proofs
+	// the proof of a single element is the base itself, copy it so that the caller can modify the proofs
+	base = new(big.Int).Set(base)
+	return toBigInts(ProveMembershipInGroup(&group.RSA{N: N}, base, set))
 }
 
 // ProofNode is the linked-list node for iterating proofs
@@ -184,48 +307,13 @@ func insertNewProofNode(iter *proofNode, N *big.Int, set []*big.Int) *proofNode
 }
 
 func handleSmallSet(base, N *big.Int, set []*big.Int) []*big.Int {
-	if len(set) == 4 {
-		// suppose the set is x0, x1, x2, x3, the membership for x0 is base^{x1x2x3}
-		x0x1Prod := bigfft.Mul(set[0], set[1])
-		x2x3Prod := bigfft.Mul(set[2], set[3])
-		x0Prod := bigfft.Mul(x2x3Prod, set[1])
-		x1Prod := bigfft.Mul(x2x3Prod, set[0])
-		x2Prod := bigfft.Mul(x0x1Prod, set[3])
-		x3Prod := bigfft.Mul(x0x1Prod, set[2])
-		fourfold := multiexp.FourfoldExp(base, N, [4]*big.Int{x0Prod, x1Prod, x2Prod, x3Prod})
-		return fourfold[:]
-	}
-	if len(set) == 3 {
-		// suppose the set is x0, x1, x2, the membership for x0 is base^{x1x2}
-		x0Prod := bigfft.Mul(set[1], set[2])
-		x1Prod := bigfft.Mul(set[0], set[2])
-		x2Prod := bigfft.Mul(set[0], set[1])
-		doubleExp := multiexp.DoubleExp(base, [2]*big.Int{x0Prod, x1Prod}, N)
-		ret := doubleExp[:]
-		ret = append(ret, AccumulateNew(base, x2Prod, N))
-		return ret
-	}
-	if len(set) == 2 {
-		doubleExp := multiexp.DoubleExp(base, [2]*big.Int{set[1], set[0]}, N)
-		return doubleExp[:]
-	}
-	if len(set) == 1 {
-		ret := make([]*big.Int, 1)
-		ret[0] = new(big.Int)
-		ret[0].Set(base)
-		return ret
-	}
-
-	// Should never reach here
-	fmt.Println("Error in handleSmallSet, set size =", len(set))
-	panic("Error in handleSmallSet, set size")
+	base = new(big.Int).Set(base)
+	return toBigInts(handleSmallSetInGroup(&group.RSA{N: N}, base, set))
 }
 
 // AccumulateNew calculates g^{power} mod N
 func AccumulateNew(g, power, N *big.Int) *big.Int {
-	ret := new(big.Int).Set(g)
-	ret.Exp(g, power, N)
-	return ret
+	return AccumulateInGroup(&group.RSA{N: N}, g, power).(*big.Int)
 }
 
 func accumulate(g, N *big.Int, set []*big.Int) *big.Int {
@@ -5,6 +5,8 @@ import (
 	"math/rand"
 	"strconv"
 	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/trace"
 )
 
 const testString = "2021HKUST"
@@ -86,6 +88,38 @@ func TestAccAndProve(t *testing.T) {
 	}
 }
 
+func TestAccAndProveTracer(t *testing.T) {
+	setup := TrustedSetup()
+	var phases []trace.Phase
+	tracer := trace.NewEventTracer(func(e trace.Event) {
+		phases = append(phases, e.Phase)
+	})
+	_, proofs := AccAndProve(GenTestSet(8), HashToPrimeFromSha256, setup, trace.WithTracer(tracer))
+	if len(proofs) != 8 {
+		t.Errorf("proofs have different size as the input set")
+	}
+	if len(phases) != 2 || phases[0] != trace.PhaseGenRepresentatives || phases[1] != trace.PhasePrecompute {
+		t.Errorf("got phases %v", phases)
+	}
+}
+
+func TestAccAndProveEmptySet(t *testing.T) {
+	setup := TrustedSetup()
+	for name, prove := range map[string]func([]string, EncodeType, *Setup, ...trace.Option) (*big.Int, []*big.Int, error){
+		"AccAndProveWithError":             AccAndProveWithError,
+		"AccAndProveParallelWithError":     AccAndProveParallelWithError,
+		"AccAndProveIterParallelWithError": AccAndProveIterParallelWithError,
+		"ZKAccumulateWithError":            ZKAccumulateWithError,
+	} {
+		if _, _, err := prove(nil, HashToPrimeFromSha256, setup); err != ErrInvalidInput {
+			t.Errorf("%s() of an empty set error = %v, want %v", name, err, ErrInvalidInput)
+		}
+	}
+	if _, _, err := AccAndProveIterWithError([]string{}, HashToPrimeFromSha256, setup); err != ErrInvalidInput {
+		t.Errorf("AccAndProveIterWithError() of an empty set error = %v, want %v", err, ErrInvalidInput)
+	}
+}
+
 func genAccts(set []string, setup *Setup, proofs []*big.Int, idx int) (acc1, acc2 *big.Int) {
 	rep := GenRepresentatives(set, HashToPrimeFromSha256)
 	acc1 = accumulateNew(setup.G, setup.N, rep)
@@ -0,0 +1,71 @@
+package accumulator
+
+import (
+	"errors"
+	"math/big"
+)
+
+// ErrNotSubset is returned when disaggregating a witness for representatives that are not in the batch
+var ErrNotSubset = errors.New("representatives are not a subset of the batch")
+
+// AggregateWitnesses combines the membership witnesses of two coprime representatives into one witness
+// for x1*x2 with Shamir's trick. With a*x1 + b*x2 = 1, the aggregated witness is w1^b * w2^a mod N.
+func AggregateWitnesses(setup *Setup, w1, x1, w2, x2 *big.Int) (*big.Int, error) {
+	var gcd, a, b big.Int
+	gcd.GCD(&a, &b, x1, x2)
+	if gcd.Cmp(big1) != 0 {
+		return nil, ErrNotCoprime
+	}
+	// a or b is negative, Exp then uses the inverse
+	ret := new(big.Int).Exp(w1, &b, setup.N)
+	if ret == nil {
+		return nil, ErrNotCoprime
+	}
+	pow := new(big.Int).Exp(w2, &a, setup.N)
+	if pow == nil {
+		return nil, ErrNotCoprime
+	}
+	ret.Mul(ret, pow)
+	ret.Mod(ret, setup.N)
+	return ret, nil
+}
+
+// AggregateWitnessList folds the membership witnesses of pairwise coprime representatives into one witness,
+// it returns the aggregated witness together with the product of the representatives.
+// It returns ErrInvalidInput if there are no witnesses or not one per representative.
+func AggregateWitnessList(setup *Setup, witnesses, reps []*big.Int) (*big.Int, *big.Int, error) {
+	if len(witnesses) == 0 || len(witnesses) != len(reps) {
+		return nil, nil, ErrInvalidInput
+	}
+	var err error
+	witness := new(big.Int).Set(witnesses[0])
+	prod := new(big.Int).Set(reps[0])
+	for i := 1; i < len(witnesses); i++ {
+		witness, err = AggregateWitnesses(setup, witness, prod, witnesses[i], reps[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		prod.Mul(prod, reps[i])
+	}
+	return witness, prod, nil
+}
+
+// DisaggregateWitness derives the witness for a subset of a batch from the witness of the whole batch,
+// the witness for the subset is witness^{prod(reps)/prod(subset)} mod N
+func DisaggregateWitness(setup *Setup, witness *big.Int, reps, subset []*big.Int) (*big.Int, error) {
+	var exp, rem big.Int
+	exp.DivMod(SetProductRecursiveFast(reps), SetProductRecursiveFast(subset), &rem)
+	if rem.Sign() != 0 {
+		return nil, ErrNotSubset
+	}
+	return AccumulateNew(witness, &exp, setup.N), nil
+}
+
+// DisaggregateWitnesses splits the witness of a batch into the membership witnesses of every representative
+// in the batch, using the divide-and-conquer method of ProveMembership
+func DisaggregateWitnesses(setup *Setup, witness *big.Int, reps []*big.Int) []*big.Int {
+	if len(reps) == 0 {
+		return nil
+	}
+	return ProveMembership(witness, setup.N, reps)
+}
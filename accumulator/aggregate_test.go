@@ -0,0 +1,57 @@
+package accumulator
+
+import (
+	"testing"
+)
+
+func TestAggregateWitnesses(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(20)
+	reps := GenRepresentatives(set, HashToPrimeFromSha256)
+	acc := AccumulateNew(setup.G, SetProductRecursiveFast(reps), setup.N)
+	witnesses := ProveMembership(setup.G, setup.N, reps)
+
+	witness, err := AggregateWitnesses(setup, witnesses[0], reps[0], witnesses[1], reps[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyBatchMembershipWithReps(setup, acc, reps[:2], HashToPrimeFromSha256, witness) {
+		t.Errorf("aggregated witness of two representatives rejected")
+	}
+	if _, err = AggregateWitnesses(setup, witnesses[0], reps[0], witnesses[0], reps[0]); err != ErrNotCoprime {
+		t.Errorf("expected ErrNotCoprime, got %v", err)
+	}
+
+	batch := reps[3:13]
+	witness, prod, err := AggregateWitnessList(setup, witnesses[3:13], batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prod.Cmp(SetProductRecursiveFast(batch)) != 0 {
+		t.Errorf("wrong product of the batch")
+	}
+	if !VerifyBatchMembershipWithReps(setup, acc, batch, HashToPrimeFromSha256, witness) {
+		t.Errorf("aggregated witness of the batch rejected")
+	}
+	if _, _, err = AggregateWitnessList(setup, witnesses[3:12], batch); err != ErrInvalidInput {
+		t.Errorf("AggregateWitnessList() with a missing witness = %v, want %v", err, ErrInvalidInput)
+	}
+
+	subWitness, err := DisaggregateWitness(setup, witness, batch, batch[2:5])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyBatchMembershipWithReps(setup, acc, batch[2:5], HashToPrimeFromSha256, subWitness) {
+		t.Errorf("disaggregated witness of the subset rejected")
+	}
+	if _, err = DisaggregateWitness(setup, witness, batch, reps[14:15]); err != ErrNotSubset {
+		t.Errorf("expected ErrNotSubset, got %v", err)
+	}
+
+	split := DisaggregateWitnesses(setup, witness, batch)
+	for i := range split {
+		if split[i].Cmp(witnesses[3+i]) != 0 {
+			t.Errorf("disaggregated witness %d does not match the membership witness", i)
+		}
+	}
+}
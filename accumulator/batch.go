@@ -0,0 +1,90 @@
+package accumulator
+
+import (
+	"math/big"
+
+	"github.com/jiajunxin/rsa_accumulator/proof"
+)
+
+// BatchMembershipProof is the aggregated membership proof of Boneh, Bünz and Fisch
+// "Batching Techniques for Accumulators with Applications to IOPs and Stateless Blockchains".
+// Witness is one membership witness for the product of the representatives, i.e. Witness^{x1*x2*...*xk} = acc,
+// and PoE proves the exponentiation so the verifier does not compute the large exponentiation itself.
+type BatchMembershipProof struct {
+	Witness *big.Int
+	PoE     *proof.PoEProof
+}
+
+// ProveBatchMembership generates the batch membership proof from a witness for the product of the representatives,
+// it returns ErrInvalidInput if there are no representatives
+func ProveBatchMembership(setup *Setup, acc, witness *big.Int, reps []*big.Int) (*BatchMembershipProof, error) {
+	if len(reps) == 0 {
+		return nil, ErrInvalidInput
+	}
+	poe, err := proof.PoEProve(witness, setup.N, acc, SetProductRecursiveFast(reps))
+	if err != nil {
+		return nil, err
+	}
+	return &BatchMembershipProof{
+		Witness: new(big.Int).Set(witness),
+		PoE:     poe,
+	}, nil
+}
+
+// VerifyBatchMembershipProof checks the batch membership proof of the elements
+func VerifyBatchMembershipProof(setup *Setup, acc *big.Int, elements []string, encodeType EncodeType, p *BatchMembershipProof) bool {
+	if len(elements) == 0 {
+		return false
+	}
+	reps, err := GenRepresentativesWithError(elements, encodeType)
+	if err != nil {
+		return false
+	}
+	return VerifyBatchMembershipProofWithReps(setup, acc, reps, encodeType, p)
+}
+
+// VerifyBatchMembershipProofWithReps checks the batch membership proof of representatives computed by the caller.
+// The verifier only multiplies the representatives and checks the PoE, which costs two exponentiations
+// with exponents of the size of the challenge.
+func VerifyBatchMembershipProofWithReps(setup *Setup, acc *big.Int, reps []*big.Int, encodeType EncodeType, p *BatchMembershipProof) bool {
+	if p == nil || len(reps) == 0 || p.CheckReduced(setup.N) != nil {
+		return false
+	}
+	for _, v := range reps {
+		if !IsValidRepresentative(v, encodeType) {
+			return false
+		}
+	}
+	if !IsInZNStar(acc, setup.N) || !IsInZNStar(p.Witness, setup.N) || !IsInZNStar(p.PoE.Q, setup.N) {
+		return false
+	}
+	return proof.PoEVerify(p.Witness, setup.N, acc, SetProductRecursiveFast(reps), p.PoE)
+}
+
+// ProveBatchMembership generates the batch membership proof for accumulated elements,
+// the witness is G to the power of the product of all the other representatives.
+// It returns ErrInvalidInput for no or repeated elements and ErrElementNotFound for an element not accumulated.
+func (acc *Accumulator) ProveBatchMembership(elements []string) (*BatchMembershipProof, error) {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	if len(elements) == 0 {
+		return nil, ErrInvalidInput
+	}
+	seen := make(map[string]bool, len(elements))
+	reps := make([]*big.Int, len(elements))
+	for i, v := range elements {
+		if seen[v] {
+			return nil, ErrInvalidInput
+		}
+		idx, ok := acc.index[v]
+		if !ok {
+			return nil, ErrElementNotFound
+		}
+		seen[v] = true
+		reps[i] = acc.reps[idx]
+	}
+	var exp big.Int
+	exp.Div(acc.prod, SetProductRecursiveFast(reps))
+	witness := AccumulateNew(acc.setup.G, &exp, acc.setup.N)
+	return ProveBatchMembership(acc.setup, acc.value, witness, reps)
+}
@@ -0,0 +1,81 @@
+package accumulator
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/codec"
+)
+
+func TestBatchMembershipProof(t *testing.T) {
+	setup := TrustedSetup()
+	acc := NewAccumulator(setup, HashToPrimeFromSha256)
+	set := GenBenchSet(16)
+	if err := acc.Update(set, nil); err != nil {
+		t.Fatal(err)
+	}
+	subset := []string{set[2], set[5], set[11]}
+	batchProof, err := acc.ProveBatchMembership(subset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyBatchMembershipProof(setup, acc.Value(), subset, HashToPrimeFromSha256, batchProof) {
+		t.Errorf("valid batch membership proof rejected")
+	}
+	if VerifyBatchMembershipProof(setup, acc.Value(), []string{set[2], set[5], set[12]}, HashToPrimeFromSha256, batchProof) {
+		t.Errorf("batch membership proof accepted for other elements")
+	}
+	if VerifyBatchMembershipProof(setup, acc.Value(), subset[:2], HashToPrimeFromSha256, batchProof) {
+		t.Errorf("batch membership proof accepted for a subset")
+	}
+	if _, err = acc.ProveBatchMembership([]string{set[0], "missing"}); err != ErrElementNotFound {
+		t.Errorf("expected ErrElementNotFound, got %v", err)
+	}
+	if _, err = acc.ProveBatchMembership([]string{set[0], set[0]}); err != ErrInvalidInput {
+		t.Errorf("repeated element error = %v, want %v", err, ErrInvalidInput)
+	}
+	if _, err = acc.ProveBatchMembership(nil); err != ErrInvalidInput {
+		t.Errorf("no elements error = %v, want %v", err, ErrInvalidInput)
+	}
+	if _, err = ProveBatchMembership(setup, acc.Value(), setup.G, nil); err != ErrInvalidInput {
+		t.Errorf("ProveBatchMembership() without representatives = %v, want %v", err, ErrInvalidInput)
+	}
+
+	data, err := batchProof.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded BatchMembershipProof
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyBatchMembershipProof(setup, acc.Value(), subset, HashToPrimeFromSha256, &decoded) {
+		t.Errorf("decoded batch membership proof rejected")
+	}
+	encoded, _ := decoded.MarshalBinary()
+	if !bytes.Equal(data, encoded) {
+		t.Errorf("encoding is not stable")
+	}
+	if err = decoded.UnmarshalBinary(data[:len(data)-1]); err != codec.ErrInvalidEncoding {
+		t.Errorf("expected ErrInvalidEncoding for truncated bytes, got %v", err)
+	}
+}
+
+func TestProveBatchMembershipFromWitness(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(8)
+	reps := GenRepresentatives(set, DIHashFromPoseidon)
+	witness := AccumulateNew(setup.G, SetProductRecursiveFast(reps[4:]), setup.N)
+	acc := AccumulateNew(witness, SetProductRecursiveFast(reps[:4]), setup.N)
+	batchProof, err := ProveBatchMembership(setup, acc, witness, reps[:4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyBatchMembershipProofWithReps(setup, acc, reps[:4], DIHashFromPoseidon, batchProof) {
+		t.Errorf("valid batch membership proof rejected")
+	}
+	if _, err = ProveBatchMembership(setup, new(big.Int).Add(acc, big1), witness, reps[:4]); err == nil {
+		t.Errorf("batch membership proof generated for a wrong accumulator")
+	}
+}
@@ -3,9 +3,11 @@ package accumulator
 import (
 	crand "crypto/rand"
 	"math/big"
+	"math/bits"
 	"testing"
 
 	"github.com/jiajunxin/multiexp"
+	"github.com/jiajunxin/rsa_accumulator/trace"
 )
 
 func BenchmarkHashToPrime(b *testing.B) {
@@ -224,3 +226,118 @@ func BenchmarkGCB(b *testing.B) {
 		_ = GCB(set[i-1], set[i])
 	}
 }
+
+// benchTrapdoorSetup generates a 2048-bit modulus with known factors
+func benchTrapdoorSetup(b *testing.B) *TrapdoorSetup {
+	p, err := crand.Prime(crand.Reader, RSABitLength/2)
+	if err != nil {
+		b.Fatal(err)
+	}
+	q, err := crand.Prime(crand.Reader, RSABitLength/2)
+	if err != nil {
+		b.Fatal(err)
+	}
+	n := new(big.Int).Mul(p, q)
+	setup := &Setup{N: n, G: new(big.Int).Exp(big.NewInt(65537), big2, n), H: big.NewInt(3)}
+	ts, err := NewTrapdoorSetup(setup, p, q)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return ts
+}
+
+func BenchmarkProveMembershipWithoutTrapdoor(b *testing.B) {
+	ts := benchTrapdoorSetup(b)
+	rep := GenRepresentatives(GenBenchSet(1000), DIHashFromPoseidon)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ProveMembership(ts.Setup.G, ts.Setup.N, rep)
+	}
+}
+
+func BenchmarkProveMembershipWithTrapdoor(b *testing.B) {
+	ts := benchTrapdoorSetup(b)
+	rep := GenRepresentatives(GenBenchSet(1000), DIHashFromPoseidon)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ts.ProveMembership(ts.Setup.G, rep)
+	}
+}
+
+func BenchmarkExpWithoutCRT(b *testing.B) {
+	ts := benchTrapdoorSetup(b)
+	x := GenRandomizer()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		AccumulateNew(ts.Setup.G, x, ts.Setup.N)
+	}
+}
+
+func BenchmarkExpWithCRT(b *testing.B) {
+	ts := benchTrapdoorSetup(b)
+	x := GenRandomizer()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ts.Exp(ts.Setup.G, x)
+	}
+}
+
+func BenchmarkZKAccumulateWithoutTrapdoor(b *testing.B) {
+	ts := benchTrapdoorSetup(b)
+	set := GenBenchSet(1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ZKAccumulate(set, DIHashFromPoseidon, ts.Setup)
+	}
+}
+
+func BenchmarkZKAccumulateWithTrapdoor(b *testing.B) {
+	ts := benchTrapdoorSetup(b)
+	set := GenBenchSet(1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = ts.ZKAccumulate(set, DIHashFromPoseidon)
+	}
+}
+
+func benchProveWithRandomizer(b *testing.B, withTrapdoor bool) {
+	ts := benchTrapdoorSetup(b)
+	rep := GenRepresentatives(GenBenchSet(1000), DIHashFromPoseidon)
+	r := GenRandomizer()
+	var table *multiexp.PreTable
+	var opts []trace.Option
+	if withTrapdoor {
+		opts = append(opts, WithTrapdoor(ts))
+	} else {
+		table = multiexp.NewPrecomputeTable(ts.Setup.G, ts.Setup.N, len(rep)*1025/bits.UintSize)
+	}
+	limit, _ := calNumWorkers()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ProveMembershipParallelWithTableWithRandomizer(ts.Setup.G, r, ts.Setup.N, rep, limit, table, opts...)
+	}
+}
+
+func BenchmarkProveMembershipParallelWithTableWithRandomizer(b *testing.B) {
+	benchProveWithRandomizer(b, false)
+}
+
+func BenchmarkProveMembershipParallelWithTableWithRandomizerWithTrapdoor(b *testing.B) {
+	benchProveWithRandomizer(b, true)
+}
+
+func BenchmarkGenRepresentatives(b *testing.B) {
+	set := GenBenchSet(4096)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		GenRepresentatives(set, HashToPrimeFromSha256)
+	}
+}
+
+func BenchmarkGenRepresentativesParallel(b *testing.B) {
+	set := GenBenchSet(4096)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		GenRepresentativesParallel(set, HashToPrimeFromSha256, 0)
+	}
+}
@@ -29,6 +29,16 @@ const (
 	HashToPrimeFromSha256 = iota
 	// DIHashFromPoseidon is a division intractable Hash output
 	DIHashFromPoseidon
+	// HashToPrimeWithNonce is a prime of DefaultHashToPrimeConfig, its nonce lets verifiers skip the prime search
+	HashToPrimeWithNonce
+	// HashToPrimeWithCertificate is a certified prime of DefaultHashToPrimeConfig, verified without probabilistic tests
+	HashToPrimeWithCertificate
+	// HashToPrimeFromSha3 is a 256-bit prime from the counter-mode hash-to-prime with SHA3-256
+	HashToPrimeFromSha3
+	// HashToPrimeFromBlake2b is a 256-bit prime from the counter-mode hash-to-prime with Blake2b-256
+	HashToPrimeFromBlake2b
+	// DIHashFromMiMC is a division intractable Hash output with MiMC over BN254, cheaper than Poseidon in some circuits
+	DIHashFromMiMC
 	// PString stores P, generated by RandomSetupForUniversalHash
 	PString = "90906479945022450706608444255860322124872501190254782434061962615363326054763"
 	// AString stores P, generated by RandomSetupForUniversalHash
@@ -72,10 +82,19 @@ type Setup struct {
 	H *big.Int //default generator in Z*_N
 }
 
+// proverExp calculates base^x mod N, with the trapdoor if it is not nil
+func proverExp(setup *Setup, ts *TrapdoorSetup, base, x *big.Int) *big.Int {
+	if ts != nil {
+		return ts.Exp(base, x)
+	}
+	return AccumulateNew(base, x, setup.N)
+}
+
 // Element should be able to be accumulated into RSA accumulator
 type Element []byte
 
-// EncodeType is the type of generating Element, should be consistent all the time
+// EncodeType is the type of generating Element, should be consistent all the time.
+// Every EncodeType has an Encoder, more can be added with RegisterEncoder.
 type EncodeType int
 
 // GenerateG generates a generator for a hidden order group randomly
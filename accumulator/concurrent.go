@@ -2,50 +2,105 @@ package accumulator
 
 import (
 	"context"
-	"fmt"
 	"math/big"
+	"math/bits"
 	"runtime"
-	"time"
 
 	"github.com/jiajunxin/multiexp"
+	"github.com/jiajunxin/rsa_accumulator/trace"
 	"github.com/remyoudompheng/bigfft"
 )
 
-// AccAndProveParallel recursively generates the accumulator with all the memberships precomputed in parallel
-func AccAndProveParallel(set []string, encodeType EncodeType, setup *Setup) (*big.Int, []*big.Int) {
-	startingTime := time.Now().UTC()
-	rep := GenRepresentatives(set, encodeType)
-	endingTime := time.Now().UTC()
-	var duration = endingTime.Sub(startingTime)
-	fmt.Printf("Running GenRepresentatives Takes [%.3f] Seconds \n",
-		duration.Seconds())
+// AccAndProveParallel recursively generates the accumulator with all the memberships precomputed in parallel,
+// the representatives are generated on trace.WithNumWorkers goroutines, all CPUs by default. It panics on
+// an empty set, an unknown EncodeType, an element the encoder cannot handle or a trapdoor of another modulus.
+func AccAndProveParallel(set []string, encodeType EncodeType, setup *Setup, opts ...trace.Option) (*big.Int, []*big.Int) {
+	acc, proofs, err := AccAndProveParallelWithError(set, encodeType, setup, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return acc, proofs
+}
+
+// AccAndProveParallelWithError is AccAndProveParallel returning ErrInvalidInput for an empty set,
+// ErrUnknownEncodeType, the error of the encoder or ErrInvalidTrapdoor instead of panicking.
+// WithTrapdoor makes it TrapdoorSetup.AccAndProveParallel.
+func AccAndProveParallelWithError(set []string, encodeType EncodeType, setup *Setup,
+	opts ...trace.Option) (*big.Int, []*big.Int, error) {
+	ts, err := trapdoorOf(setup.N, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return accAndProveParallel(set, encodeType, setup, ts, opts...)
+}
+
+// accAndProveParallel is AccAndProveParallel with the exponentiations done with the trapdoor if it is not nil
+func accAndProveParallel(set []string, encodeType EncodeType, setup *Setup, ts *TrapdoorSetup,
+	opts ...trace.Option) (*big.Int, []*big.Int, error) {
+	if len(set) == 0 {
+		return nil, nil, ErrInvalidInput
+	}
+	options := trace.NewOptions(opts...)
+	tracer := options.Tracer
+	tracer.StartPhase(trace.PhaseGenRepresentatives)
+	rep, err := GenRepresentativesParallelWithError(set, encodeType, options.NumWorkers)
+	tracer.EndPhase(trace.PhaseGenRepresentatives)
+	if err != nil {
+		return nil, nil, err
+	}
 	numWorkers, _ := calNumWorkers()
-	proofs := ProveMembershipParallel(setup.G, setup.N, rep, numWorkers)
+	tracer.StartPhase(trace.PhasePrecompute)
+	proofs := ProveMembershipParallel(setup.G, setup.N, rep, numWorkers, WithTrapdoor(ts))
+	tracer.EndPhase(trace.PhasePrecompute)
 	// we generate the accumulator by anyone of the membership proof raised to its power to save some calculation
-	acc := AccumulateNew(proofs[0], rep[0], setup.N)
+	acc := proverExp(setup, ts, proofs[0], rep[0])
 
-	return acc, proofs
+	return acc, proofs, nil
 }
 
-// AccAndProveIterParallel iteratively and concurrently generates the accumulator with all the memberships precomputed
+// AccAndProveIterParallel iteratively and concurrently generates the accumulator with all the memberships precomputed,
+// the representatives are generated on trace.WithNumWorkers goroutines, all CPUs by default. It panics on
+// an empty set, an unknown EncodeType or an element the encoder cannot handle.
 func AccAndProveIterParallel(set []string, encodeType EncodeType,
-	setup *Setup) (*big.Int, []*big.Int) {
-	startingTime := time.Now().UTC()
-	rep := GenRepresentatives(set, encodeType)
-	endingTime := time.Now().UTC()
-	var duration = endingTime.Sub(startingTime)
-	fmt.Printf("Running GenRepresentatives Takes [%.3f] Seconds \n",
-		duration.Seconds())
+	setup *Setup, opts ...trace.Option) (*big.Int, []*big.Int) {
+	acc, proofs, err := AccAndProveIterParallelWithError(set, encodeType, setup, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return acc, proofs
+}
+
+// AccAndProveIterParallelWithError is AccAndProveIterParallel returning ErrInvalidInput for an empty set,
+// ErrUnknownEncodeType or the error of the encoder instead of panicking
+func AccAndProveIterParallelWithError(set []string, encodeType EncodeType,
+	setup *Setup, opts ...trace.Option) (*big.Int, []*big.Int, error) {
+	if len(set) == 0 {
+		return nil, nil, ErrInvalidInput
+	}
+	options := trace.NewOptions(opts...)
+	tracer := options.Tracer
+	tracer.StartPhase(trace.PhaseGenRepresentatives)
+	rep, err := GenRepresentativesParallelWithError(set, encodeType, options.NumWorkers)
+	tracer.EndPhase(trace.PhaseGenRepresentatives)
+	if err != nil {
+		return nil, nil, err
+	}
+	tracer.StartPhase(trace.PhasePrecompute)
 	proofs := ProveMembershipIterParallel(*setup.G, setup.N, rep)
+	tracer.EndPhase(trace.PhasePrecompute)
 	// we generate the accumulator by anyone of the membership proof raised to its power to save some calculation
 	acc := AccumulateNew(proofs[0], rep[0], setup.N)
 
-	return acc, proofs
+	return acc, proofs, nil
 }
 
 // ProveMembershipParallel uses divide-and-conquer method to pre-compute the all membership proofs in time O(nlog(n))
-// It uses at most O(2^limit) Goroutines
-func ProveMembershipParallel(base, N *big.Int, set []*big.Int, limit int) []*big.Int {
+// It uses at most O(2^limit) Goroutines. WithTrapdoor switches to exponents modulo phi(N) with CRT,
+// a trapdoor of another modulus is ignored.
+func ProveMembershipParallel(base, N *big.Int, set []*big.Int, limit int, opts ...trace.Option) []*big.Int {
+	if ts := trapdoorFor(N, opts); ts != nil {
+		return ts.proveMembership(base, set, numWorkersOfLimit(limit))
+	}
 	if limit <= 0 {
 		return ProveMembership(base, N, set)
 	}
@@ -55,7 +110,6 @@ func ProveMembershipParallel(base, N *big.Int, set []*big.Int, limit int) []*big
 	limit -= 2
 
 	// the left part of proof need to accumulate the right part of the set, vice versa.
-	startingTime := time.Now().UTC()
 	leftProd := SetProductRecursiveFast(set[len(set)/2:])
 	rightProd := SetProductRecursiveFast(set[0 : len(set)/2])
 	leftleftProd := SetProductRecursiveFast(set[len(set)/4 : len(set)/2])
@@ -71,10 +125,6 @@ func ProveMembershipParallel(base, N *big.Int, set []*big.Int, limit int) []*big
 	inputExp[2] = bigfft.Mul(rightProd, rightleftProd)
 	inputExp[3] = bigfft.Mul(rightProd, rightrightProd)
 	bases := multiexp.FourfoldExp(base, N, inputExp)
-	endingTime := time.Now().UTC()
-	var duration = endingTime.Sub(startingTime)
-	fmt.Printf("Running ProveMembershipParallel for the first layer with 2 cores Takes [%.3f] Seconds \n",
-		duration.Seconds())
 	// leftBase := accumulateNew(base, N, set[len(set)/2:])
 	// rightBase := accumulateNew(base, N, set[0:len(set)/2])
 	c1 := make(chan []*big.Int)
@@ -98,8 +148,12 @@ func ProveMembershipParallel(base, N *big.Int, set []*big.Int, limit int) []*big
 }
 
 // ProveMembershipParallelWithTable uses divide-and-conquer method to pre-compute the all membership proofs in time O(nlog(n))
-// It uses at most O(2^limit) Goroutines
-func ProveMembershipParallelWithTable(base, N *big.Int, set []*big.Int, limit int, table *multiexp.PreTable) []*big.Int {
+// It uses at most O(2^limit) Goroutines. WithTrapdoor switches to exponents modulo phi(N) with CRT,
+// the table is not needed then. A trapdoor of another modulus is ignored.
+func ProveMembershipParallelWithTable(base, N *big.Int, set []*big.Int, limit int, table *multiexp.PreTable, opts ...trace.Option) []*big.Int {
+	if ts := trapdoorFor(N, opts); ts != nil {
+		return ts.proveMembership(base, set, numWorkersOfLimit(limit))
+	}
 	if limit <= 0 {
 		return ProveMembership(base, N, set)
 	}
@@ -109,7 +163,6 @@ func ProveMembershipParallelWithTable(base, N *big.Int, set []*big.Int, limit in
 	limit -= 2
 
 	// the left part of proof need to accumulate the right part of the set, vice versa.
-	startingTime := time.Now().UTC()
 	leftProd := SetProductRecursiveFast(set[len(set)/2:])
 	rightProd := SetProductRecursiveFast(set[0 : len(set)/2])
 	leftleftProd := SetProductRecursiveFast(set[len(set)/4 : len(set)/2])
@@ -125,10 +178,6 @@ func ProveMembershipParallelWithTable(base, N *big.Int, set []*big.Int, limit in
 	inputExp[2] = bigfft.Mul(rightProd, rightleftProd)
 	inputExp[3] = bigfft.Mul(rightProd, rightrightProd)
 	bases := multiexp.FourfoldExpPrecomputedParallel(base, N, inputExp, table)
-	endingTime := time.Now().UTC()
-	var duration = endingTime.Sub(startingTime)
-	fmt.Printf("Running ProveMembershipParallel for the first layer with 2 cores Takes [%.3f] Seconds \n",
-		duration.Seconds())
 	// leftBase := accumulateNew(base, N, set[len(set)/2:])
 	// rightBase := accumulateNew(base, N, set[0:len(set)/2])
 	c1 := make(chan []*big.Int)
@@ -172,8 +221,14 @@ func ProveMembershipParallelWithTable(base, N *big.Int, set []*big.Int, limit in
 
 // ProveMembershipParallelWithTableWithRandomizer uses divide-and-conquer method to pre-compute the all membership proofs in time O(nlog(n))
 // It uses at most O(2^limit) Goroutines
-// It uses the same table with different randomizers
-func ProveMembershipParallelWithTableWithRandomizer(base, randomizer, N *big.Int, set []*big.Int, limit int, table *multiexp.PreTable) []*big.Int {
+// It uses the same table with different randomizers.
+// WithTrapdoor computes the proofs from base^randomizer with CRT instead, the table is not needed then.
+// A trapdoor of another modulus is ignored.
+func ProveMembershipParallelWithTableWithRandomizer(base, randomizer, N *big.Int, set []*big.Int, limit int,
+	table *multiexp.PreTable, opts ...trace.Option) []*big.Int {
+	if ts := trapdoorFor(N, opts); ts != nil {
+		return ts.proveWithRandomizer(base, randomizer, set, numWorkersOfLimit(limit))
+	}
 	if limit <= 0 {
 		return ProveMembershipSingleThreadWithRandomizer(base, randomizer, N, set, table)
 	}
@@ -227,8 +282,16 @@ func ProveMembershipParallelWithTableWithRandomizer(base, randomizer, N *big.Int
 
 // ProveMembershipParallelWithTableWithRandomizerWithChan uses divide-and-conquer method to pre-compute the all membership proofs in time O(nlog(n))
 // It uses at most O(2^limit) Goroutines
-// It uses the same table with different randomizers
-func ProveMembershipParallelWithTableWithRandomizerWithChan(base, randomizer, N *big.Int, set []*big.Int, limit int, table *multiexp.PreTable, c chan []*big.Int) {
+// It uses the same table with different randomizers.
+// WithTrapdoor computes the proofs from base^randomizer with CRT instead, the table is not needed then.
+// A trapdoor of another modulus is ignored.
+func ProveMembershipParallelWithTableWithRandomizerWithChan(base, randomizer, N *big.Int, set []*big.Int, limit int,
+	table *multiexp.PreTable, c chan []*big.Int, opts ...trace.Option) {
+	if ts := trapdoorFor(N, opts); ts != nil {
+		c <- ts.proveWithRandomizer(base, randomizer, set, numWorkersOfLimit(limit))
+		close(c)
+		return
+	}
 	if limit <= 0 {
 		c <- ProveMembershipSingleThreadWithRandomizer(base, randomizer, N, set, table)
 		close(c)
@@ -365,6 +428,18 @@ func ProveMembershipIterParallel(base big.Int, N *big.Int, set []*big.Int) []*bi
 	return <-proofChan
 }
 
+// numWorkersOfLimit returns the number of goroutines of the trapdoor prover for the goroutine limit 2^limit,
+// a single one for limit <= 0
+func numWorkersOfLimit(limit int) int {
+	if limit <= 0 {
+		return 1
+	}
+	if limit >= bits.UintSize-2 || 1<<limit > runtime.NumCPU() {
+		return runtime.NumCPU()
+	}
+	return 1 << limit
+}
+
 func calNumWorkers() (int, int) {
 	numWorkersPowerOfTwo := 0
 	numWorkers := 1
@@ -373,7 +448,6 @@ func calNumWorkers() (int, int) {
 		numWorkersPowerOfTwo++
 		numWorkers *= 2
 	}
-	fmt.Printf("CPU Number: %d, Number of Workers: %d\n", numCPUs, numWorkers/2)
 	return numWorkers / 2, numWorkersPowerOfTwo - 1
 }
 
@@ -1,6 +1,11 @@
 package accumulator
 
-import "testing"
+import (
+	"math/big"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/trace"
+)
 
 func TestAccAndProveIterParallel(t *testing.T) {
 
@@ -65,3 +70,25 @@ func TestAccAndProveIterParallel(t *testing.T) {
 		})
 	}
 }
+
+func TestAccAndProveParallelNumWorkers(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(300)
+	want, wantProofs := AccAndProve(set, DIHashFromPoseidon, setup)
+	for _, numWorkers := range []int{1, 3} {
+		for name, prove := range map[string]func([]string, EncodeType, *Setup, ...trace.Option) (*big.Int, []*big.Int){
+			"AccAndProveParallel":     AccAndProveParallel,
+			"AccAndProveIterParallel": AccAndProveIterParallel,
+		} {
+			acc, proofs := prove(set, DIHashFromPoseidon, setup, trace.WithNumWorkers(numWorkers))
+			if acc.Cmp(want) != 0 || len(proofs) != len(wantProofs) {
+				t.Fatalf("%s() with %d workers differs from AccAndProve()", name, numWorkers)
+			}
+			for i := range proofs {
+				if proofs[i].Cmp(wantProofs[i]) != 0 {
+					t.Errorf("%s() with %d workers: proof %d differs from AccAndProve()", name, numWorkers, i)
+				}
+			}
+		}
+	}
+}
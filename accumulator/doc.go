@@ -0,0 +1,8 @@
+// Package accumulator implements RSA accumulators with precomputed membership proofs, their zero-knowledge
+// variant, batch and non-membership proofs, and the prover speed-ups of the trapdoor.
+//
+// The functions without an error result, such as GenRepresentatives, AccAndProve and ZKAccumulate, are kept
+// for the existing callers and panic on an input they cannot handle. An unknown EncodeType panics with
+// ErrUnknownEncodeType, it is no longer encoded with HashToPrimeFromSha256. Services should call the
+// WithError variants, e.g. GenRepresentativesWithError, which return the error instead.
+package accumulator
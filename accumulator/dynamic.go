@@ -0,0 +1,326 @@
+package accumulator
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+)
+
+var (
+	// ErrElementExists is returned when adding an element that is already accumulated
+	ErrElementExists = errors.New("element already in the accumulator")
+	// ErrElementNotFound is returned when deleting or proving an element that is not accumulated
+	ErrElementNotFound = errors.New("element not in the accumulator")
+)
+
+// Accumulator is a stateful RSA accumulator. It holds the current accumulator value,
+// the accumulated representatives together with their product, and keeps the membership
+// witnesses of all elements consistent across additions and deletions.
+type Accumulator struct {
+	mu         sync.Mutex
+	setup      *Setup
+	encodeType EncodeType
+	trapdoor   *TrapdoorSetup // nil if the factorization of N is unknown
+
+	value     *big.Int       // current accumulator value, G^{prod}
+	prod      *big.Int       // product of all representatives
+	elements  []string       // accumulated elements
+	reps      []*big.Int     // representatives of the elements, same order as elements
+	index     map[string]int // element -> position in elements
+	witnesses []*big.Int     // membership witnesses, same order as elements
+	stale     bool           // witnesses need to be recomputed
+}
+
+// NewAccumulator returns an empty accumulator over the setup, the value of an empty accumulator is G
+func NewAccumulator(setup *Setup, encodeType EncodeType) *Accumulator {
+	return &Accumulator{
+		setup:      setup,
+		encodeType: encodeType,
+		value:      new(big.Int).Set(setup.G),
+		prod:       big.NewInt(1),
+		index:      make(map[string]int),
+	}
+}
+
+// NewAccumulatorWithTrapdoor returns an empty accumulator for a manager who knows phi(N).
+// Deletions are then done with one modular inverse instead of the extended GCD for every witness.
+// ErrInvalidTrapdoor is returned if phi(N) does not factor N into two primes.
+func NewAccumulatorWithTrapdoor(setup *Setup, encodeType EncodeType, phiN *big.Int) (*Accumulator, error) {
+	ts, err := NewTrapdoorSetupFromPhi(setup, phiN)
+	if err != nil {
+		return nil, err
+	}
+	return NewAccumulatorFromTrapdoorSetup(ts, encodeType), nil
+}
+
+// NewAccumulatorFromTrapdoorSetup returns an empty accumulator for a manager who knows the factorization of N.
+// Deletions are done with one modular inverse and the witnesses are computed with exponents reduced modulo phi(N).
+func NewAccumulatorFromTrapdoorSetup(ts *TrapdoorSetup, encodeType EncodeType) *Accumulator {
+	acc := NewAccumulator(ts.Setup, encodeType)
+	acc.trapdoor = ts
+	return acc
+}
+
+// Setup returns the setup of the accumulator
+func (acc *Accumulator) Setup() *Setup {
+	return acc.setup
+}
+
+// Value returns a copy of the current accumulator value
+func (acc *Accumulator) Value() *big.Int {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	return new(big.Int).Set(acc.value)
+}
+
+// Product returns a copy of the product of all accumulated representatives
+func (acc *Accumulator) Product() *big.Int {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	return new(big.Int).Set(acc.prod)
+}
+
+// Size returns the number of accumulated elements
+func (acc *Accumulator) Size() int {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	return len(acc.elements)
+}
+
+// Contains returns true if the element is accumulated
+func (acc *Accumulator) Contains(element string) bool {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	_, ok := acc.index[element]
+	return ok
+}
+
+// Elements returns a copy of the accumulated elements
+func (acc *Accumulator) Elements() []string {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	ret := make([]string, len(acc.elements))
+	copy(ret, acc.elements)
+	return ret
+}
+
+// Representative returns the representative of an accumulated element
+func (acc *Accumulator) Representative(element string) (*big.Int, error) {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	idx, ok := acc.index[element]
+	if !ok {
+		return nil, ErrElementNotFound
+	}
+	return new(big.Int).Set(acc.reps[idx]), nil
+}
+
+// Add accumulates one element
+func (acc *Accumulator) Add(element string) error {
+	return acc.Update([]string{element}, nil)
+}
+
+// Delete removes one element from the accumulator
+func (acc *Accumulator) Delete(element string) error {
+	return acc.Update(nil, []string{element})
+}
+
+// Update deletes and adds elements in a batch. Either all changes are applied or none of them.
+// Deletions are applied before additions, so an element can be deleted and added back in one update.
+func (acc *Accumulator) Update(added, deleted []string) error {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+
+	if err := acc.checkUpdate(added, deleted); err != nil {
+		return err
+	}
+	newReps, err := GenRepresentativesWithError(added, acc.encodeType)
+	if err != nil {
+		return err
+	}
+	if len(deleted) > 0 {
+		acc.deleteElements(deleted)
+	}
+	if len(added) > 0 {
+		acc.addElements(added, newReps)
+	}
+	return nil
+}
+
+// Witness returns the membership witness of the element, i.e. w such that w^{rep} = value mod N
+func (acc *Accumulator) Witness(element string) (*big.Int, error) {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	idx, ok := acc.index[element]
+	if !ok {
+		return nil, ErrElementNotFound
+	}
+	acc.refreshWitnesses()
+	return new(big.Int).Set(acc.witnesses[idx]), nil
+}
+
+// Witnesses returns the membership witnesses of all elements, in the same order as Elements
+func (acc *Accumulator) Witnesses() []*big.Int {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	acc.refreshWitnesses()
+	ret := make([]*big.Int, len(acc.witnesses))
+	for i := range acc.witnesses {
+		ret[i] = new(big.Int).Set(acc.witnesses[i])
+	}
+	return ret
+}
+
+// checkUpdate makes sure an update can be fully applied before the state is touched
+func (acc *Accumulator) checkUpdate(added, deleted []string) error {
+	toDelete := make(map[string]bool, len(deleted))
+	for _, v := range deleted {
+		if _, ok := acc.index[v]; !ok || toDelete[v] {
+			return ErrElementNotFound
+		}
+		toDelete[v] = true
+	}
+	toAdd := make(map[string]bool, len(added))
+	for _, v := range added {
+		_, ok := acc.index[v]
+		if (ok && !toDelete[v]) || toAdd[v] {
+			return ErrElementExists
+		}
+		toAdd[v] = true
+	}
+	return nil
+}
+
+// addElements accumulates new elements. The old witnesses are raised to the product of the new
+// representatives, and the witnesses of the new elements are computed from the old accumulator value.
+func (acc *Accumulator) addElements(added []string, newReps []*big.Int) {
+	addProd := SetProductRecursiveFast(newReps)
+	exp := addProd
+	if acc.trapdoor != nil {
+		exp = new(big.Int).Mod(addProd, acc.trapdoor.PhiN)
+	}
+	if !acc.stale {
+		for i := range acc.witnesses {
+			acc.witnesses[i].Exp(acc.witnesses[i], exp, acc.setup.N)
+		}
+		acc.witnesses = append(acc.witnesses, acc.proveMembership(acc.value, newReps)...)
+	}
+	acc.value.Exp(acc.value, exp, acc.setup.N)
+	acc.prod.Mul(acc.prod, addProd)
+	for i, v := range added {
+		acc.index[v] = len(acc.elements)
+		acc.elements = append(acc.elements, v)
+		acc.reps = append(acc.reps, newReps[i])
+	}
+}
+
+// deleteElements removes elements from the accumulator, with the trapdoor if possible
+func (acc *Accumulator) deleteElements(deleted []string) {
+	delReps := make([]*big.Int, len(deleted))
+	var delWitnesses []*big.Int
+	if !acc.stale {
+		delWitnesses = make([]*big.Int, len(deleted))
+	}
+	for i, v := range deleted {
+		delReps[i] = acc.reps[acc.index[v]]
+		if !acc.stale {
+			delWitnesses[i] = acc.witnesses[acc.index[v]]
+		}
+	}
+	delProd := SetProductRecursiveFast(delReps)
+	for _, v := range deleted {
+		acc.removeAt(acc.index[v])
+	}
+	acc.prod.Div(acc.prod, delProd)
+
+	if acc.trapdoor != nil {
+		// x^{-1} mod phi(N) exists for all representatives coprime to phi(N)
+		inv := new(big.Int).ModInverse(delProd, acc.trapdoor.PhiN)
+		if inv != nil {
+			acc.value.Exp(acc.value, inv, acc.setup.N)
+			if !acc.stale {
+				for i := range acc.witnesses {
+					acc.witnesses[i].Exp(acc.witnesses[i], inv, acc.setup.N)
+				}
+			}
+			return
+		}
+	}
+	if !acc.stale && acc.deleteWithWitnesses(delReps, delWitnesses) {
+		return
+	}
+	if acc.trapdoor != nil {
+		acc.value = acc.trapdoor.Exp(acc.setup.G, acc.prod)
+	} else {
+		acc.value = AccumulateNew(acc.setup.G, acc.prod, acc.setup.N)
+	}
+	acc.stale = true
+}
+
+// deleteWithWitnesses removes the elements without the trapdoor. The witness of a deleted element
+// is exactly the accumulator without it, so the deleted elements are peeled off one by one and the
+// remaining witnesses are updated once for the whole batch.
+// It returns false if some representatives are not coprime, the witnesses then need to be recomputed.
+func (acc *Accumulator) deleteWithWitnesses(delReps, delWitnesses []*big.Int) bool {
+	var err error
+	value := acc.value
+	for i := range delReps {
+		value = delWitnesses[i]
+		for j := i + 1; j < len(delReps); j++ {
+			delWitnesses[j], err = UpdateWitnessOnDelete(acc.setup, delWitnesses[j], delReps[j], delReps[i:i+1], value)
+			if err != nil {
+				return false
+			}
+		}
+	}
+	witnesses := make([]*big.Int, len(acc.witnesses))
+	for i := range acc.witnesses {
+		witnesses[i], err = UpdateWitnessOnDelete(acc.setup, acc.witnesses[i], acc.reps[i], delReps, value)
+		if err != nil {
+			return false
+		}
+	}
+	acc.value = new(big.Int).Set(value)
+	acc.witnesses = witnesses
+	return true
+}
+
+// removeAt removes the element at position idx by moving the last element into its place
+func (acc *Accumulator) removeAt(idx int) {
+	last := len(acc.elements) - 1
+	delete(acc.index, acc.elements[idx])
+	if idx != last {
+		acc.elements[idx] = acc.elements[last]
+		acc.reps[idx] = acc.reps[last]
+		acc.index[acc.elements[idx]] = idx
+		if !acc.stale {
+			acc.witnesses[idx] = acc.witnesses[last]
+		}
+	}
+	acc.elements = acc.elements[:last]
+	acc.reps = acc.reps[:last]
+	if !acc.stale {
+		acc.witnesses = acc.witnesses[:last]
+	}
+}
+
+// refreshWitnesses recomputes all witnesses with the divide-and-conquer method if they are stale
+func (acc *Accumulator) refreshWitnesses() {
+	if !acc.stale {
+		return
+	}
+	if len(acc.reps) == 0 {
+		acc.witnesses = nil
+	} else {
+		acc.witnesses = acc.proveMembership(acc.setup.G, acc.reps)
+	}
+	acc.stale = false
+}
+
+// proveMembership computes the membership proofs from the base, with the trapdoor if possible
+func (acc *Accumulator) proveMembership(base *big.Int, reps []*big.Int) []*big.Int {
+	if acc.trapdoor != nil {
+		return acc.trapdoor.ProveMembership(base, reps)
+	}
+	return ProveMembership(base, acc.setup.N, reps)
+}
@@ -0,0 +1,137 @@
+package accumulator
+
+import (
+	crand "crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// genSmallTrapdoorSetup generates a 1024-bit setup together with phi(N), for test use only
+func genSmallTrapdoorSetup(t *testing.T) (*Setup, *big.Int) {
+	p, err := crand.Prime(crand.Reader, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := crand.Prime(crand.Reader, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setup := &Setup{
+		N: new(big.Int).Mul(p, q),
+		G: big.NewInt(65537),
+		H: big.NewInt(3),
+	}
+	setup.G.Exp(setup.G, big2, setup.N)
+	pMin1 := new(big.Int).Sub(p, big1)
+	qMin1 := new(big.Int).Sub(q, big1)
+	return setup, new(big.Int).Mul(pMin1, qMin1)
+}
+
+func checkAccumulatorState(t *testing.T, acc *Accumulator) {
+	t.Helper()
+	setup := acc.Setup()
+	elements := acc.Elements()
+	witnesses := acc.Witnesses()
+	value := acc.Value()
+	if len(elements) != len(witnesses) {
+		t.Fatalf("got %d witnesses for %d elements", len(witnesses), len(elements))
+	}
+	expected := AccumulateNew(setup.G, acc.Product(), setup.N)
+	if expected.Cmp(value) != 0 {
+		t.Fatalf("accumulator value is not consistent with the product of representatives")
+	}
+	for i, v := range elements {
+		rep, err := acc.Representative(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if AccumulateNew(witnesses[i], rep, setup.N).Cmp(value) != 0 {
+			t.Fatalf("witness of element %s is not valid", v)
+		}
+	}
+}
+
+func TestAccumulatorAddDelete(t *testing.T) {
+	setup := TrustedSetup()
+	acc := NewAccumulator(setup, HashToPrimeFromSha256)
+	if acc.Value().Cmp(setup.G) != 0 {
+		t.Errorf("empty accumulator should be G")
+	}
+	set := GenBenchSet(20)
+	for _, v := range set[:10] {
+		if err := acc.Add(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkAccumulatorState(t, acc)
+	if err := acc.Add(set[0]); err != ErrElementExists {
+		t.Errorf("adding an existing element should fail, got %v", err)
+	}
+
+	if err := acc.Delete(set[3]); err != nil {
+		t.Fatal(err)
+	}
+	if acc.Contains(set[3]) {
+		t.Errorf("deleted element is still in the accumulator")
+	}
+	if acc.stale {
+		t.Errorf("witnesses should be updated after the deletion")
+	}
+	checkAccumulatorState(t, acc)
+	if err := acc.Delete(set[3]); err != ErrElementNotFound {
+		t.Errorf("deleting a missing element should fail, got %v", err)
+	}
+
+	if err := acc.Update(set[10:], set[:2]); err != nil {
+		t.Fatal(err)
+	}
+	if acc.Size() != 17 {
+		t.Errorf("got size %d, want 17", acc.Size())
+	}
+	checkAccumulatorState(t, acc)
+
+	// the failed update must not change anything
+	value := acc.Value()
+	if err := acc.Update([]string{"new", set[12]}, nil); err != ErrElementExists {
+		t.Errorf("update with an existing element should fail, got %v", err)
+	}
+	if acc.Contains("new") || acc.Value().Cmp(value) != 0 {
+		t.Errorf("failed update changed the accumulator")
+	}
+
+	// delete and add back in the same update
+	if err := acc.Update([]string{set[15]}, []string{set[15]}); err != nil {
+		t.Fatal(err)
+	}
+	if acc.Value().Cmp(value) != 0 {
+		t.Errorf("deleting and adding back an element should not change the accumulator")
+	}
+	checkAccumulatorState(t, acc)
+}
+
+func TestAccumulatorWithTrapdoor(t *testing.T) {
+	setup, phiN := genSmallTrapdoorSetup(t)
+	if _, err := NewAccumulatorWithTrapdoor(setup, HashToPrimeFromSha256, new(big.Int).Sub(phiN, big2)); err != ErrInvalidTrapdoor {
+		t.Errorf("NewAccumulatorWithTrapdoor() with a wrong phi(N) error = %v, want %v", err, ErrInvalidTrapdoor)
+	}
+	acc, err := NewAccumulatorWithTrapdoor(setup, HashToPrimeFromSha256, phiN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := GenBenchSet(16)
+	if err := acc.Update(set, nil); err != nil {
+		t.Fatal(err)
+	}
+	checkAccumulatorState(t, acc)
+	if err := acc.Delete(set[7]); err != nil {
+		t.Fatal(err)
+	}
+	if acc.stale {
+		t.Errorf("witnesses should be updated with the trapdoor")
+	}
+	checkAccumulatorState(t, acc)
+	if err := acc.Update([]string{"a", "b"}, set[:4]); err != nil {
+		t.Fatal(err)
+	}
+	checkAccumulatorState(t, acc)
+}
@@ -0,0 +1,242 @@
+package accumulator
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/poseidon"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+// firstCustomEncodeType is the EncodeType given to the first encoder registered with RegisterEncoder
+const firstCustomEncodeType EncodeType = 64
+
+var (
+	// ErrUnknownEncodeType is returned for an EncodeType without a registered encoder
+	ErrUnknownEncodeType = errors.New("unknown encode type")
+	// ErrEncoderExists is returned when an encoder is registered twice with the same name
+	ErrEncoderExists = errors.New("encoder already registered")
+	// ErrTooManyEncoders is returned when no EncodeType is left for a new encoder
+	ErrTooManyEncoders = errors.New("too many encoders registered")
+)
+
+// Encoder generates the representatives of one EncodeType
+type Encoder interface {
+	// Encode returns the representative of the element
+	Encode(element string) (*big.Int, error)
+	// IsValid returns true if the representative could have been generated by the encoder
+	IsValid(rep *big.Int) bool
+}
+
+// ClonableEncoder is an Encoder with state that must not be shared between goroutines, such as a hasher.
+// GenRepresentativesParallel gives every worker its own clone, which reuses its hasher for all its elements.
+type ClonableEncoder interface {
+	Encoder
+	Clone() Encoder
+}
+
+type registeredEncoder struct {
+	name    string
+	encoder Encoder
+}
+
+var (
+	encodersMu     sync.RWMutex
+	encoders       = make(map[EncodeType]registeredEncoder)
+	encodersByName = make(map[string]EncodeType)
+	nextEncodeType = firstCustomEncodeType
+)
+
+func init() {
+	registerEncoder(HashToPrimeFromSha256, "sha256", sha256Encoder{})
+	registerEncoder(DIHashFromPoseidon, "poseidon-bn254-di", diHashEncoder{hash: poseidonBN254})
+	registerEncoder(HashToPrimeWithNonce, "sha256-nonce", primeEncoder{cfg: DefaultHashToPrimeConfig()})
+	registerEncoder(HashToPrimeWithCertificate, "sha256-certificate", primeEncoder{cfg: DefaultHashToPrimeConfig(), certified: true})
+	registerEncoder(HashToPrimeFromSha3, "sha3-256", primeEncoder{cfg: &HashToPrimeConfig{
+		Bits:     HashToPrime256,
+		Tag:      []byte("rsa_accumulator/HashToPrime/sha3-256/v1"),
+		MaxNonce: DefaultMaxNonce,
+		Hash:     sha3.New256,
+	}})
+	registerEncoder(HashToPrimeFromBlake2b, "blake2b-256", primeEncoder{cfg: &HashToPrimeConfig{
+		Bits:     HashToPrime256,
+		Tag:      []byte("rsa_accumulator/HashToPrime/blake2b-256/v1"),
+		MaxNonce: DefaultMaxNonce,
+		Hash:     newBlake2b256,
+	}})
+	registerEncoder(DIHashFromMiMC, "mimc-bn254-di", diHashEncoder{hash: mimcBN254, newHash: mimc.NewMiMC})
+}
+
+// registerEncoder adds a built-in encoder, it panics on a duplicate
+func registerEncoder(encodeType EncodeType, name string, encoder Encoder) {
+	if _, ok := encoders[encodeType]; ok {
+		panic(ErrEncoderExists)
+	}
+	if _, ok := encodersByName[name]; ok {
+		panic(ErrEncoderExists)
+	}
+	encoders[encodeType] = registeredEncoder{name: name, encoder: encoder}
+	encodersByName[name] = encodeType
+}
+
+// RegisterEncoder adds an encoder under a new EncodeType, e.g. Poseidon over BLS12-381, which is not part of the
+// gnark-crypto version used here. The EncodeType depends on the registration order, so programs sharing
+// encoded accumulators must register the same encoders in the same order, or look them up with EncodeTypeByName.
+func RegisterEncoder(name string, encoder Encoder) (EncodeType, error) {
+	if encoder == nil {
+		return 0, ErrInvalidInput
+	}
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	if _, ok := encodersByName[name]; ok {
+		return 0, ErrEncoderExists
+	}
+	// EncodeType is encoded as one byte by Accumulator.MarshalBinary
+	if nextEncodeType > 255 {
+		return 0, ErrTooManyEncoders
+	}
+	encodeType := nextEncodeType
+	nextEncodeType++
+	registerEncoder(encodeType, name, encoder)
+	return encodeType, nil
+}
+
+// EncodeTypeByName returns the EncodeType of the encoder registered under the name
+func EncodeTypeByName(name string) (EncodeType, error) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	encodeType, ok := encodersByName[name]
+	if !ok {
+		return 0, ErrUnknownEncodeType
+	}
+	return encodeType, nil
+}
+
+// String returns the name of the encoder of the EncodeType
+func (t EncodeType) String() string {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	if e, ok := encoders[t]; ok {
+		return e.name
+	}
+	return fmt.Sprintf("EncodeType(%d)", int(t))
+}
+
+// lookupEncoder returns the encoder of the EncodeType, ErrUnknownEncodeType if there is none
+func lookupEncoder(encodeType EncodeType) (Encoder, error) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	e, ok := encoders[encodeType]
+	if !ok {
+		return nil, ErrUnknownEncodeType
+	}
+	return e.encoder, nil
+}
+
+// sha256Encoder is HashToPrime, iterating SHA-256 until a prime is hit
+type sha256Encoder struct {
+	h hash.Hash // nil for the shared encoder of the registry
+}
+
+func (e sha256Encoder) Encode(element string) (*big.Int, error) {
+	if e.h == nil {
+		return HashToPrimeWithError([]byte(element))
+	}
+	return hashToPrimeWithHasher(e.h, []byte(element))
+}
+
+func (sha256Encoder) Clone() Encoder {
+	return sha256Encoder{h: sha256.New()}
+}
+
+func (sha256Encoder) IsValid(rep *big.Int) bool {
+	return rep.BitLen() <= 256 && rep.ProbablyPrime(securityParaHashToPrime)
+}
+
+// primeEncoder is the counter-mode hash-to-prime of the config, with or without certificate
+type primeEncoder struct {
+	cfg       *HashToPrimeConfig
+	certified bool
+	h         hash.Hash // nil for the shared encoder of the registry
+}
+
+func (e primeEncoder) Encode(element string) (*big.Int, error) {
+	h := e.h
+	if h == nil {
+		h = e.cfg.newHash()
+	}
+	if e.certified {
+		rep, _, err := e.cfg.certifiedHashToPrime(h, []byte(element))
+		return rep, err
+	}
+	rep, _, err := e.cfg.hashToPrime(h, []byte(element))
+	return rep, err
+}
+
+func (e primeEncoder) Clone() Encoder {
+	return primeEncoder{cfg: e.cfg, certified: e.certified, h: e.cfg.newHash()}
+}
+
+func (e primeEncoder) IsValid(rep *big.Int) bool {
+	return rep.BitLen() == e.cfg.Bits && rep.ProbablyPrime(securityParaHashToPrime)
+}
+
+func newBlake2b256() hash.Hash {
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		// only returned for a key longer than 64 bytes
+		panic(err)
+	}
+	return h
+}
+
+// diHashEncoder is a division intractable hash, the BN254 hash of the decimal element plus 2^1023
+type diHashEncoder struct {
+	hash    func(h hash.Hash, e *fr.Element) *big.Int
+	newHash func() hash.Hash // nil if hash needs no hasher
+	h       hash.Hash        // nil for the shared encoder of the registry
+}
+
+func (e diHashEncoder) Encode(element string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(element, 10)
+	if !ok {
+		return nil, ErrInvalidInput
+	}
+	h := e.h
+	if h == nil && e.newHash != nil {
+		h = e.newHash()
+	}
+	ret := e.hash(h, ElementFromBigInt(n))
+	return ret.Add(ret, Min1024), nil
+}
+
+func (e diHashEncoder) Clone() Encoder {
+	if e.newHash == nil {
+		return e
+	}
+	return diHashEncoder{hash: e.hash, newHash: e.newHash, h: e.newHash()}
+}
+
+func (diHashEncoder) IsValid(rep *big.Int) bool {
+	return rep.Cmp(Min1024) >= 0 && rep.Cmp(diUpperBound) < 0
+}
+
+func poseidonBN254(_ hash.Hash, e *fr.Element) *big.Int {
+	ret := new(big.Int)
+	poseidon.Poseidon(e).ToBigIntRegular(ret)
+	return ret
+}
+
+func mimcBN254(h hash.Hash, e *fr.Element) *big.Int {
+	h.Reset()
+	b := e.Bytes()
+	h.Write(b[:])
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
@@ -0,0 +1,160 @@
+package accumulator
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/trace"
+)
+
+func TestBuiltinEncoders(t *testing.T) {
+	set := GenBenchSet(4)
+	builtins := []EncodeType{HashToPrimeFromSha256, DIHashFromPoseidon, HashToPrimeWithNonce, HashToPrimeWithCertificate,
+		HashToPrimeFromSha3, HashToPrimeFromBlake2b, DIHashFromMiMC}
+	seen := make(map[string]EncodeType)
+	for _, encodeType := range builtins {
+		found, err := EncodeTypeByName(encodeType.String())
+		if err != nil || found != encodeType {
+			t.Errorf("EncodeTypeByName(%q) = %d, %v, want %d", encodeType, found, err, int(encodeType))
+		}
+		reps, err := GenRepresentativesWithError(set, encodeType)
+		if err != nil {
+			t.Fatalf("GenRepresentativesWithError() with %v error = %v", encodeType, err)
+		}
+		again := GenRepresentatives(set, encodeType)
+		for i := range reps {
+			if !IsValidRepresentative(reps[i], encodeType) {
+				t.Errorf("representative %d of %v rejected", i, encodeType)
+			}
+			if reps[i].Cmp(again[i]) != 0 {
+				t.Errorf("representative %d of %v is not deterministic", i, encodeType)
+			}
+		}
+		// all encoders are domain separated, apart from the nonce type which is the default config
+		if other, ok := seen[reps[0].String()]; ok && !(other == HashToPrimeWithNonce || encodeType == HashToPrimeWithNonce) {
+			t.Errorf("%v and %v give the same representative", other, encodeType)
+		}
+		seen[reps[0].String()] = encodeType
+	}
+	if _, err := GenRepresentativesWithError([]string{"a"}, DIHashFromMiMC); err != ErrInvalidInput {
+		t.Errorf("DIHashFromMiMC of a non-decimal element error = %v, want %v", err, ErrInvalidInput)
+	}
+}
+
+// oddEncoder is a test encoder, 2*element+1 for a decimal element
+type oddEncoder struct{}
+
+func (oddEncoder) Encode(element string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(element, 10)
+	if !ok {
+		return nil, ErrInvalidInput
+	}
+	return n.Lsh(n, 1).Add(n, big1), nil
+}
+
+func (oddEncoder) IsValid(rep *big.Int) bool {
+	return rep.Bit(0) == 1
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	// the registry is global, the encoder is already there when the test runs again with -count
+	encodeType, err := EncodeTypeByName("test-odd")
+	if err != nil {
+		if encodeType, err = RegisterEncoder("test-odd", oddEncoder{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if encodeType < firstCustomEncodeType || encodeType.String() != "test-odd" {
+		t.Errorf("RegisterEncoder() = %d named %q", int(encodeType), encodeType)
+	}
+	if _, err = RegisterEncoder("test-odd", oddEncoder{}); err != ErrEncoderExists {
+		t.Errorf("RegisterEncoder() twice error = %v, want %v", err, ErrEncoderExists)
+	}
+	if _, err = RegisterEncoder("sha256", oddEncoder{}); err != ErrEncoderExists {
+		t.Errorf("RegisterEncoder() with a built-in name error = %v, want %v", err, ErrEncoderExists)
+	}
+	reps := GenRepresentatives([]string{"1", "20"}, encodeType)
+	if reps[0].Int64() != 3 || reps[1].Int64() != 41 || !IsValidRepresentative(reps[1], encodeType) {
+		t.Errorf("GenRepresentatives() with the registered encoder = %v", reps)
+	}
+}
+
+func TestUnknownEncodeType(t *testing.T) {
+	unknown := EncodeType(200)
+	if unknown.String() != "EncodeType(200)" {
+		t.Errorf("String() = %q", unknown)
+	}
+	if _, err := EncodeTypeByName("poseidon-bls12-381"); err != ErrUnknownEncodeType {
+		t.Errorf("EncodeTypeByName() error = %v, want %v", err, ErrUnknownEncodeType)
+	}
+	if _, err := GenRepresentativesWithError([]string{"1"}, unknown); err != ErrUnknownEncodeType {
+		t.Errorf("GenRepresentativesWithError() error = %v, want %v", err, ErrUnknownEncodeType)
+	}
+	if IsValidRepresentative(big.NewInt(3), unknown) {
+		t.Error("representative of an unknown encode type accepted")
+	}
+	setup := TrustedSetup()
+	if VerifyMembership(setup, setup.G, "1", unknown, setup.G) {
+		t.Error("membership with an unknown encode type accepted")
+	}
+	acc := NewAccumulator(setup, unknown)
+	if err := acc.Add("1"); err != ErrUnknownEncodeType {
+		t.Errorf("Add() error = %v, want %v", err, ErrUnknownEncodeType)
+	}
+	if VerifyBatchMembershipProof(setup, setup.G, []string{"1"}, unknown, &BatchMembershipProof{}) {
+		t.Error("batch membership with an unknown encode type accepted")
+	}
+	if _, err := ProveNonMembership(setup, []string{"1"}, "2", unknown); err != ErrUnknownEncodeType {
+		t.Errorf("ProveNonMembership() error = %v, want %v", err, ErrUnknownEncodeType)
+	}
+	if _, err := acc.NonMembershipWitness("2"); err != ErrUnknownEncodeType {
+		t.Errorf("NonMembershipWitness() error = %v, want %v", err, ErrUnknownEncodeType)
+	}
+	for name, prove := range map[string]func([]string, EncodeType, *Setup, ...trace.Option) (*big.Int, []*big.Int, error){
+		"AccAndProveWithError":             AccAndProveWithError,
+		"AccAndProveParallelWithError":     AccAndProveParallelWithError,
+		"AccAndProveIterParallelWithError": AccAndProveIterParallelWithError,
+		"ZKAccumulateWithError":            ZKAccumulateWithError,
+	} {
+		if _, _, err := prove([]string{"1"}, unknown, setup); err != ErrUnknownEncodeType {
+			t.Errorf("%s() error = %v, want %v", name, err, ErrUnknownEncodeType)
+		}
+	}
+	if _, _, err := AccAndProveIterWithError([]string{"1"}, unknown, setup); err != ErrUnknownEncodeType {
+		t.Errorf("AccAndProveIterWithError() error = %v, want %v", err, ErrUnknownEncodeType)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("GenRepresentatives() with an unknown encode type should panic")
+		}
+	}()
+	GenRepresentatives([]string{"1"}, unknown)
+}
+
+func TestGenRepresentativesParallel(t *testing.T) {
+	set := GenBenchSet(600)
+	for _, encodeType := range []EncodeType{HashToPrimeFromSha256, DIHashFromPoseidon, HashToPrimeFromBlake2b, DIHashFromMiMC} {
+		want := GenRepresentatives(set, encodeType)
+		for _, numWorkers := range []int{0, 1, 3, 16} {
+			got := GenRepresentativesParallel(set, encodeType, numWorkers)
+			if len(got) != len(want) {
+				t.Fatalf("GenRepresentativesParallel() with %v and %d workers returned %d representatives", encodeType, numWorkers, len(got))
+			}
+			for i := range want {
+				if got[i].Cmp(want[i]) != 0 {
+					t.Fatalf("GenRepresentativesParallel() with %v and %d workers differs at %d", encodeType, numWorkers, i)
+				}
+			}
+		}
+	}
+	if reps := GenRepresentativesParallel(nil, DIHashFromPoseidon, 4); len(reps) != 0 {
+		t.Errorf("GenRepresentativesParallel() of an empty set = %v", reps)
+	}
+	bad := append(GenBenchSet(700), "not a number")
+	if _, err := GenRepresentativesParallelWithError(bad, DIHashFromPoseidon, 3); err != ErrInvalidInput {
+		t.Errorf("GenRepresentativesParallelWithError() error = %v, want %v", err, ErrInvalidInput)
+	}
+	if _, err := GenRepresentativesParallelWithError(set, EncodeType(200), 3); err != ErrUnknownEncodeType {
+		t.Errorf("GenRepresentativesParallelWithError() error = %v, want %v", err, ErrUnknownEncodeType)
+	}
+}
@@ -0,0 +1,215 @@
+package accumulator
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/jiajunxin/rsa_accumulator/codec"
+	"github.com/jiajunxin/rsa_accumulator/proof"
+)
+
+// MarshalBinary encodes the setup in the versioned binary format of package codec
+func (setup *Setup) MarshalBinary() ([]byte, error) {
+	if setup.N == nil || setup.G == nil || setup.H == nil {
+		return nil, codec.ErrInvalidEncoding
+	}
+	enc := codec.NewEncoder(codec.TypeSetup)
+	enc.PutBigInt(setup.N)
+	enc.PutBigInt(setup.G)
+	enc.PutBigInt(setup.H)
+	return enc.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the setup encoded by MarshalBinary, G and H must be in Z*_N
+func (setup *Setup) UnmarshalBinary(data []byte) error {
+	dec := codec.NewDecoder(data, codec.TypeSetup)
+	n := dec.Nat()
+	if dec.Err() == nil && n.Cmp(big2) <= 0 {
+		dec.Fail(codec.ErrInvalidEncoding)
+	}
+	g := dec.Element(n)
+	h := dec.Element(n)
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	if !IsInZNStar(g, n) || !IsInZNStar(h, n) {
+		return codec.ErrNotReduced
+	}
+	setup.N, setup.G, setup.H = n, g, h
+	return nil
+}
+
+// MarshalBinary encodes the accumulator with its setup, encoding type, elements and value.
+// The witnesses are recomputed after decoding and the trapdoor is never encoded.
+func (acc *Accumulator) MarshalBinary() ([]byte, error) {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	setupBytes, err := acc.setup.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	enc := codec.NewEncoder(codec.TypeAccumulator)
+	enc.PutBytes(setupBytes)
+	enc.PutUint8(uint8(acc.encodeType))
+	enc.PutUint32(uint32(len(acc.elements)))
+	for _, v := range acc.elements {
+		enc.PutString(v)
+	}
+	enc.PutBigInt(acc.value)
+	return enc.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the accumulator encoded by MarshalBinary, the representatives are generated again
+// from the elements and the value must be G to the power of their product
+func (acc *Accumulator) UnmarshalBinary(data []byte) error {
+	dec := codec.NewDecoder(data, codec.TypeAccumulator)
+	setupBytes := dec.Bytes()
+	encodeType := EncodeType(dec.Uint8())
+	count := dec.Uint32()
+	var elements []string
+	for i := uint32(0); i < count && dec.Err() == nil; i++ {
+		elements = append(elements, dec.String())
+	}
+	if err := dec.Err(); err != nil {
+		return err
+	}
+	setup := new(Setup)
+	if err := setup.UnmarshalBinary(setupBytes); err != nil {
+		return err
+	}
+	value := dec.Element(setup.N)
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	if _, err := lookupEncoder(encodeType); err != nil {
+		return codec.ErrInvalidEncoding
+	}
+
+	index := make(map[string]int, len(elements))
+	for i, v := range elements {
+		if _, ok := index[v]; ok {
+			return codec.ErrInvalidEncoding
+		}
+		index[v] = i
+	}
+	var reps []*big.Int
+	prod := big.NewInt(1)
+	if len(elements) > 0 {
+		var err error
+		if reps, err = GenRepresentativesWithError(elements, encodeType); err != nil {
+			return codec.ErrInvalidEncoding
+		}
+		prod = SetProductRecursiveFast(reps)
+	}
+	if AccumulateNew(setup.G, prod, setup.N).Cmp(value) != 0 {
+		return codec.ErrInvalidEncoding
+	}
+
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	acc.setup, acc.encodeType, acc.trapdoor = setup, encodeType, nil
+	acc.value, acc.prod = value, prod
+	acc.elements, acc.reps, acc.index = elements, reps, index
+	acc.witnesses, acc.stale = nil, true
+	return nil
+}
+
+// MarshalBinary encodes the proof in the versioned binary format of package codec
+func (p *BatchMembershipProof) MarshalBinary() ([]byte, error) {
+	if p.Witness == nil || p.PoE == nil || p.PoE.Q == nil {
+		return nil, codec.ErrInvalidEncoding
+	}
+	enc := codec.NewEncoder(codec.TypeBatchMembershipProof)
+	enc.PutBigInt(p.Witness)
+	enc.PutBigInt(p.PoE.Q)
+	return enc.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the proof encoded by MarshalBinary
+func (p *BatchMembershipProof) UnmarshalBinary(data []byte) error {
+	dec := codec.NewDecoder(data, codec.TypeBatchMembershipProof)
+	witness := dec.Nat()
+	q := dec.Nat()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	p.Witness = witness
+	p.PoE = &proof.PoEProof{Q: q}
+	return nil
+}
+
+// CheckReduced checks that the group elements of the proof are reduced modulo n
+func (p *BatchMembershipProof) CheckReduced(n *big.Int) error {
+	if p.PoE == nil {
+		return codec.ErrNotReduced
+	}
+	return codec.CheckReduced(n, p.Witness, p.PoE.Q)
+}
+
+// MarshalBinary encodes the witness in the versioned binary format of package codec, A may be negative
+func (w *NonMembershipWitness) MarshalBinary() ([]byte, error) {
+	if w.A == nil || w.B == nil {
+		return nil, codec.ErrInvalidEncoding
+	}
+	enc := codec.NewEncoder(codec.TypeNonMembershipWitness)
+	enc.PutBigInt(w.A)
+	enc.PutBigInt(w.B)
+	return enc.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the witness encoded by MarshalBinary
+func (w *NonMembershipWitness) UnmarshalBinary(data []byte) error {
+	dec := codec.NewDecoder(data, codec.TypeNonMembershipWitness)
+	a := dec.BigInt()
+	b := dec.Nat()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	w.A, w.B = a, b
+	return nil
+}
+
+// CheckReduced checks that B is reduced modulo n
+func (w *NonMembershipWitness) CheckReduced(n *big.Int) error {
+	return codec.CheckReduced(n, w.B)
+}
+
+type setupJSON struct {
+	codec.Header
+	N string `json:"n"`
+	G string `json:"g"`
+	H string `json:"h"`
+}
+
+// MarshalJSON encodes the setup as JSON with hex integers
+func (setup *Setup) MarshalJSON() ([]byte, error) {
+	if setup.N == nil || setup.G == nil || setup.H == nil {
+		return nil, codec.ErrInvalidEncoding
+	}
+	return json.Marshal(setupJSON{
+		Header: codec.NewHeader("Setup"),
+		N:      codec.EncodeHex(setup.N),
+		G:      codec.EncodeHex(setup.G),
+		H:      codec.EncodeHex(setup.H),
+	})
+}
+
+// UnmarshalJSON decodes the setup encoded by MarshalJSON, G and H must be in Z*_N
+func (setup *Setup) UnmarshalJSON(data []byte) error {
+	var v setupJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.Check("Setup"); err != nil {
+		return err
+	}
+	values, err := codec.DecodeNatHexList(v.N, v.G, v.H)
+	if err != nil {
+		return err
+	}
+	if !IsInZNStar(values[1], values[0]) || !IsInZNStar(values[2], values[0]) {
+		return codec.ErrNotReduced
+	}
+	setup.N, setup.G, setup.H = values[0], values[1], values[2]
+	return nil
+}
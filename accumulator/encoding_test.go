@@ -0,0 +1,114 @@
+package accumulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/codec"
+	"github.com/jiajunxin/rsa_accumulator/internal/golden"
+)
+
+func TestSetupBinary(t *testing.T) {
+	setup := TrustedSetup()
+	data, err := setup.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Setup
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.N.Cmp(setup.N) != 0 || decoded.G.Cmp(setup.G) != 0 || decoded.H.Cmp(setup.H) != 0 {
+		t.Errorf("decoded setup does not match")
+	}
+
+	unreduced := &Setup{N: setup.N, G: new(big.Int).Add(setup.G, setup.N), H: setup.H}
+	data, err = unreduced.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = decoded.UnmarshalBinary(data); err != codec.ErrNotReduced {
+		t.Errorf("expected ErrNotReduced, got %v", err)
+	}
+}
+
+func TestAccumulatorBinary(t *testing.T) {
+	setup := TrustedSetup()
+	acc := NewAccumulator(setup, HashToPrimeFromSha256)
+	if err := acc.Update(GenBenchSet(10), nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := acc.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Accumulator
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Value().Cmp(acc.Value()) != 0 || decoded.Product().Cmp(acc.Product()) != 0 {
+		t.Errorf("decoded accumulator does not match")
+	}
+	checkAccumulatorState(t, &decoded)
+	encoded, err := decoded.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, encoded) {
+		t.Errorf("encoding is not stable")
+	}
+
+	// the value must be the accumulator of the elements
+	acc.value = new(big.Int).Exp(acc.value, big2, setup.N)
+	data, err = acc.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = decoded.UnmarshalBinary(data); err != codec.ErrInvalidEncoding {
+		t.Errorf("expected ErrInvalidEncoding for a value of other elements, got %v", err)
+	}
+}
+
+func TestNonMembershipWitnessBinary(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(6)
+	witness, err := ProveNonMembership(setup, set, "100", HashToPrimeFromSha256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := witness.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded NonMembershipWitness
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.A.Cmp(witness.A) != 0 || decoded.B.Cmp(witness.B) != 0 {
+		t.Errorf("decoded witness does not match")
+	}
+	if err = decoded.CheckReduced(setup.N); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestSetupJSON(t *testing.T) {
+	setup := TrustedSetup()
+	data := golden.JSON(t, "setup.json", setup)
+	var decoded Setup
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.N.Cmp(setup.N) != 0 || decoded.G.Cmp(setup.G) != 0 || decoded.H.Cmp(setup.H) != 0 {
+		t.Errorf("decoded setup does not match")
+	}
+	golden.CheckJSON(t, data, &decoded)
+	if err := json.Unmarshal(bytes.Replace(data, []byte(`"version":1`), []byte(`"version":2`), 1), &decoded); err != codec.ErrUnsupportedVersion {
+		t.Errorf("expected ErrUnsupportedVersion, got %v", err)
+	}
+	if err := json.Unmarshal(bytes.Replace(data, []byte(`"g":"0x`), []byte(`"g":"0x0`), 1), &decoded); err != codec.ErrInvalidHex {
+		t.Errorf("expected ErrInvalidHex for a leading zero, got %v", err)
+	}
+}
@@ -1,26 +1,58 @@
 package accumulator
 
 import (
+	"errors"
 	"math/big"
-
-	"github.com/consensys/gnark-crypto/ecc/bn254/fr/poseidon"
 )
 
-func genRepWithHashToPrimeFromSHA256(set []string) []*big.Int {
-	ret := make([]*big.Int, len(set))
+// ErrNoRepresentativeProof is returned for encode types whose representatives do not come with a proof
+var ErrNoRepresentativeProof = errors.New("encode type has no representative proof")
+
+// RepresentativeProof lets a verifier recompute a representative without searching for a prime,
+// the Nonce for HashToPrimeWithNonce and the Certificate for HashToPrimeWithCertificate
+type RepresentativeProof struct {
+	Nonce       uint32            `json:"nonce,omitempty"`
+	Certificate *PrimeCertificate `json:"certificate,omitempty"`
+}
+
+// GenRepresentativesWithProofs generates the representatives of HashToPrimeWithNonce or HashToPrimeWithCertificate
+// together with their proofs, which can be shipped with the elements
+func GenRepresentativesWithProofs(set []string, encodeType EncodeType) ([]*big.Int, []*RepresentativeProof, error) {
+	cfg := DefaultHashToPrimeConfig()
+	reps := make([]*big.Int, len(set))
+	proofs := make([]*RepresentativeProof, len(set))
 	for i, v := range set {
-		ret[i] = HashToPrime([]byte(v))
+		var err error
+		proofs[i] = new(RepresentativeProof)
+		switch encodeType {
+		case HashToPrimeWithNonce:
+			reps[i], proofs[i].Nonce, err = cfg.HashToPrime([]byte(v))
+		case HashToPrimeWithCertificate:
+			reps[i], proofs[i].Certificate, err = cfg.CertifiedHashToPrime([]byte(v))
+		default:
+			return nil, nil, ErrNoRepresentativeProof
+		}
+		if err != nil {
+			return nil, nil, err
+		}
 	}
-	return ret
+	return reps, proofs, nil
 }
 
-func genRepWithDIHashFromPoseidon(set []string) []*big.Int {
-	ret := make([]*big.Int, len(set))
-	for i := range set {
-		ret[i] = new(big.Int)
-		temp := poseidon.Poseidon(ElementFromString(set[i]))
-		temp.ToBigIntRegular(ret[i])
-		ret[i].Add(ret[i], Min1024)
+// RepresentativeFromProof recomputes the representative of the element from the nonce for HashToPrimeWithNonce,
+// or by checking the certificate for HashToPrimeWithCertificate. Only the nonces found by the prover are accepted,
+// so an element has a single representative.
+func RepresentativeFromProof(element string, encodeType EncodeType, proof *RepresentativeProof) (*big.Int, error) {
+	if proof == nil {
+		return nil, ErrInvalidInput
+	}
+	cfg := DefaultHashToPrimeConfig()
+	switch encodeType {
+	case HashToPrimeWithNonce:
+		return cfg.PrimeFromNonce([]byte(element), proof.Nonce)
+	case HashToPrimeWithCertificate:
+		return cfg.VerifyCertificate([]byte(element), proof.Certificate)
+	default:
+		return nil, ErrNoRepresentativeProof
 	}
-	return ret
 }
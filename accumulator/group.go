@@ -0,0 +1,110 @@
+package accumulator
+
+import (
+	"math/big"
+
+	"github.com/jiajunxin/rsa_accumulator/group"
+	"github.com/remyoudompheng/bigfft"
+)
+
+// The following functions work over any group of unknown order, e.g. a class group that needs no trusted setup.
+// Over Z*_N they compute the same values as the functions with a modulus.
+
+// Group returns Z*_N of the setup as a group
+func (setup *Setup) Group() *group.RSA {
+	return &group.RSA{N: setup.N}
+}
+
+// AccumulateInGroup calculates g^{power} in the group
+func AccumulateInGroup(grp group.Group, g group.Element, power *big.Int) group.Element {
+	return grp.Exp(g, power)
+}
+
+// AccumulateSetInGroup calculates g^{x1*x2*...*xk} in the group
+func AccumulateSetInGroup(grp group.Group, g group.Element, set []*big.Int) group.Element {
+	if len(set) == 0 {
+		return g
+	}
+	return grp.Exp(g, SetProductRecursiveFast(set))
+}
+
+// ProveMembershipInGroup uses divide-and-conquer method to pre-compute the all membership proofs in the group
+// in time O(nlog(n)). The set is split into quarters and the base of each quarter is base raised to the product
+// of the other three, the four exponentiations share their squarings if the group implements group.MultiPower.
+func ProveMembershipInGroup(grp group.Group, base group.Element, set []*big.Int) []group.Element {
+	if len(set) <= 4 {
+		return handleSmallSetInGroup(grp, base, set)
+	}
+
+	leftProd := SetProductRecursiveFast(set[len(set)/2:])
+	rightProd := SetProductRecursiveFast(set[0 : len(set)/2])
+	leftleftProd := SetProductRecursiveFast(set[len(set)/4 : len(set)/2])
+	leftrightProd := SetProductRecursiveFast(set[0 : len(set)/4])
+	rightleftProd := SetProductRecursiveFast(set[len(set)*3/4:])
+	rightrightProd := SetProductRecursiveFast(set[len(set)/2 : len(set)*3/4])
+
+	inputExp := []*big.Int{
+		bigfft.Mul(leftProd, leftleftProd),
+		bigfft.Mul(leftProd, leftrightProd),
+		bigfft.Mul(rightProd, rightleftProd),
+		bigfft.Mul(rightProd, rightrightProd),
+	}
+	bases := group.ExpMany(grp, base, inputExp)
+	proofs := ProveMembershipInGroup(grp, bases[0], set[0:len(set)/4])
+	proofs = append(proofs, ProveMembershipInGroup(grp, bases[1], set[len(set)/4:len(set)/2])...)
+	proofs = append(proofs, ProveMembershipInGroup(grp, bases[2], set[len(set)/2:len(set)*3/4])...)
+	proofs = append(proofs, ProveMembershipInGroup(grp, bases[3], set[len(set)*3/4:])...)
+	return proofs
+}
+
+// handleSmallSetInGroup computes the membership proofs of at most four elements
+func handleSmallSetInGroup(grp group.Group, base group.Element, set []*big.Int) []group.Element {
+	switch len(set) {
+	case 4:
+		// suppose the set is x0, x1, x2, x3, the membership for x0 is base^{x1x2x3}
+		x0x1Prod := bigfft.Mul(set[0], set[1])
+		x2x3Prod := bigfft.Mul(set[2], set[3])
+		return group.ExpMany(grp, base, []*big.Int{
+			bigfft.Mul(x2x3Prod, set[1]),
+			bigfft.Mul(x2x3Prod, set[0]),
+			bigfft.Mul(x0x1Prod, set[3]),
+			bigfft.Mul(x0x1Prod, set[2]),
+		})
+	case 3:
+		// suppose the set is x0, x1, x2, the membership for x0 is base^{x1x2}
+		ret := group.ExpMany(grp, base, []*big.Int{bigfft.Mul(set[1], set[2]), bigfft.Mul(set[0], set[2])})
+		return append(ret, grp.Exp(base, bigfft.Mul(set[0], set[1])))
+	case 2:
+		return group.ExpMany(grp, base, []*big.Int{set[1], set[0]})
+	case 1:
+		return []group.Element{base}
+	default:
+		return nil
+	}
+}
+
+// toBigInts converts elements of Z*_N back to big integers
+func toBigInts(elements []group.Element) []*big.Int {
+	ret := make([]*big.Int, len(elements))
+	for i := range elements {
+		ret[i] = elements[i].(*big.Int)
+	}
+	return ret
+}
+
+// VerifyMembershipInGroup checks that witness^{rep} = acc in the group, where rep is the representative of the element
+func VerifyMembershipInGroup(grp group.Group, acc group.Element, element string, encodeType EncodeType, witness group.Element) bool {
+	reps, err := GenRepresentativesWithError([]string{element}, encodeType)
+	if err != nil {
+		return false
+	}
+	return VerifyMembershipWithRepInGroup(grp, acc, reps[0], encodeType, witness)
+}
+
+// VerifyMembershipWithRepInGroup checks the membership witness of a representative computed by the caller
+func VerifyMembershipWithRepInGroup(grp group.Group, acc group.Element, rep *big.Int, encodeType EncodeType, witness group.Element) bool {
+	if !IsValidRepresentative(rep, encodeType) || !grp.IsElement(acc) || !grp.IsElement(witness) {
+		return false
+	}
+	return grp.Equal(grp.Exp(witness, rep), acc)
+}
@@ -0,0 +1,58 @@
+package accumulator
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/group"
+)
+
+// countingGroup counts the exponentiations in the wrapped group, it does not implement group.MultiPower
+type countingGroup struct {
+	group.Group
+	exps int
+}
+
+func (g *countingGroup) Exp(a group.Element, x *big.Int) group.Element {
+	g.exps++
+	return g.Group.Exp(a, x)
+}
+
+func TestProveMembershipInGroup(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(9)
+	reps := GenRepresentatives(set, HashToPrimeFromSha256)
+
+	// without the multiexp of Z*_N the proofs are the same as ProveMembership
+	counting := &countingGroup{Group: setup.Group()}
+	proofs := ProveMembershipInGroup(counting, setup.G, reps)
+	expected := ProveMembership(setup.G, setup.N, reps)
+	for i := range expected {
+		if !counting.Equal(proofs[i], expected[i]) {
+			t.Errorf("proof %d differs from ProveMembership", i)
+		}
+	}
+	// 4 exponentiations for the quarters of 2, 2, 2 and 3 elements, then one for each element
+	if counting.exps != 13 {
+		t.Errorf("ProveMembershipInGroup used %d exponentiations, want 13", counting.exps)
+	}
+
+	classGroup, err := group.NewClassGroupFromSeed([]byte("accumulator test"), 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := classGroup.Generator()
+	acc := AccumulateSetInGroup(classGroup, g, reps)
+	proofs = ProveMembershipInGroup(classGroup, g, reps)
+	for i := range set {
+		if !VerifyMembershipInGroup(classGroup, acc, set[i], HashToPrimeFromSha256, proofs[i]) {
+			t.Errorf("membership proof of %s is not valid", set[i])
+		}
+	}
+	if VerifyMembershipInGroup(classGroup, acc, set[0], HashToPrimeFromSha256, proofs[1]) {
+		t.Error("membership proof of another element accepted")
+	}
+	if VerifyMembershipInGroup(classGroup, acc, "not accumulated", HashToPrimeFromSha256, proofs[0]) {
+		t.Error("membership proof of a non-member accepted")
+	}
+}
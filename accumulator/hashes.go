@@ -2,12 +2,17 @@ package accumulator
 
 import (
 	"crypto/sha256"
+	"errors"
+	"hash"
 	"math/big"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/poseidon"
 )
 
+// ErrInvalidInput is returned when a function gets an input it cannot handle, e.g. the wrong number of inputs
+var ErrInvalidInput = errors.New("invalid input")
+
 // ElementFromBigInt returns an element in BN256 generated from BigInt
 func ElementFromBigInt(v *big.Int) *fr.Element {
 	var e fr.Element
@@ -33,13 +38,28 @@ func ElementFromUint32(v uint32) *fr.Element {
 	return &e
 }
 
-// HashToPrime takes the input into Sha256 and take the hash output to input repeatedly until we hit a prime number
+// HashToPrime takes the input into Sha256 and take the hash output to input repeatedly until we hit a prime number.
+// It has no domain separation and no bound on the iterations, HashToPrimeConfig should be preferred for new applications.
 func HashToPrime(input []byte) *big.Int {
+	ret, err := HashToPrimeWithError(input)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// HashToPrimeWithError is HashToPrime returning the hash error instead of panicking
+func HashToPrimeWithError(input []byte) (*big.Int, error) {
+	return hashToPrimeWithHasher(sha256.New(), input)
+}
+
+// hashToPrimeWithHasher is HashToPrimeWithError with the SHA-256 hasher h
+func hashToPrimeWithHasher(h hash.Hash, input []byte) (*big.Int, error) {
 	var ret big.Int
-	h := sha256.New()
+	h.Reset()
 	_, err := h.Write(input)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	hashTemp := h.Sum(nil)
 	ret.SetBytes(hashTemp)
@@ -50,13 +70,13 @@ func HashToPrime(input []byte) *big.Int {
 			h.Reset()
 			_, err := h.Write(hashTemp)
 			if err != nil {
-				panic(err)
+				return nil, err
 			}
 			hashTemp = h.Sum(nil)
 			ret.SetBytes(hashTemp)
 		}
 	}
-	return &ret
+	return &ret, nil
 }
 
 // SHA256ToInt calculates the input with Sha256 and change it to big.Int
@@ -74,13 +94,22 @@ func SHA256ToInt(input []byte) *big.Int {
 
 // PoseidonWith2Inputs inputs 2 big.Int and generate a Poseidon hash result.
 func PoseidonWith2Inputs(inputs []*big.Int) *big.Int {
-	if len(inputs) != 2 {
+	ret, err := PoseidonWith2InputsWithError(inputs)
+	if err != nil {
 		panic("PoseidonWith2Inputs requires 2 inputs")
 	}
+	return ret
+}
+
+// PoseidonWith2InputsWithError is PoseidonWith2Inputs returning ErrInvalidInput if there are not exactly 2 inputs
+func PoseidonWith2InputsWithError(inputs []*big.Int) (*big.Int, error) {
+	if len(inputs) != 2 {
+		return nil, ErrInvalidInput
+	}
 	fieldElement := poseidon.Poseidon(ElementFromBigInt(inputs[0]), (ElementFromBigInt(inputs[1])))
 	var ret big.Int
 	fieldElement.ToBigIntRegular(&ret)
-	return &ret
+	return &ret, nil
 }
 
 // UniversalHashToInt calculates output = input * A + B mod P
@@ -0,0 +1,166 @@
+package accumulator
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"math/big"
+)
+
+const (
+	// HashToPrime128 is the output length of 128-bit hash-to-prime
+	HashToPrime128 = 128
+	// HashToPrime256 is the output length of 256-bit hash-to-prime, the size of HashToPrime
+	HashToPrime256 = 256
+	// HashToPrime264 is the output length of 264-bit hash-to-prime, larger than any 256-bit hash output
+	HashToPrime264 = 264
+
+	// DefaultHashToPrimeTag is the domain separation tag of DefaultHashToPrimeConfig
+	DefaultHashToPrimeTag = "rsa_accumulator/HashToPrime/v1"
+	// DefaultMaxNonce bounds the candidates tried by DefaultHashToPrimeConfig. A random odd number of
+	// 256 bits is a prime with probability about 1/89, so no prime among 2^16 candidates happens with probability below 2^-1000.
+	DefaultMaxNonce = 1 << 16
+)
+
+var (
+	// ErrInvalidHashToPrimeConfig is returned when the output length is not supported or the nonce bound is zero
+	ErrInvalidHashToPrimeConfig = errors.New("invalid hash-to-prime config")
+	// ErrPrimeNotFound is returned when no candidate up to the maximum nonce is a prime
+	ErrPrimeNotFound = errors.New("no prime found up to the maximum nonce")
+	// ErrInvalidNonce is returned when the candidate of the nonce is not a prime
+	ErrInvalidNonce = errors.New("hash-to-prime candidate of the nonce is not a prime")
+)
+
+// HashToPrimeConfig configures the counter-mode hash-to-prime.
+// The candidate of nonce i is the counter-mode expansion of (Tag, input, i) with SHA-256, or Hash if set, truncated to Bits bits,
+// with the top bit set for the exact length and the lowest bit set to make it odd.
+// The prime of an input is the candidate of the smallest nonce that is a prime.
+type HashToPrimeConfig struct {
+	Bits     int    // output length, HashToPrime128, HashToPrime256 or HashToPrime264
+	Tag      []byte // domain separation tag, different applications must use different tags
+	MaxNonce uint32 // number of candidates tried before ErrPrimeNotFound
+
+	Hash func() hash.Hash // hash function of the expansion, SHA-256 if nil
+}
+
+// DefaultHashToPrimeConfig returns the 256-bit config with DefaultHashToPrimeTag
+func DefaultHashToPrimeConfig() *HashToPrimeConfig {
+	return &HashToPrimeConfig{
+		Bits:     HashToPrime256,
+		Tag:      []byte(DefaultHashToPrimeTag),
+		MaxNonce: DefaultMaxNonce,
+	}
+}
+
+// check returns ErrInvalidHashToPrimeConfig if the config cannot be used
+func (cfg *HashToPrimeConfig) check() error {
+	if cfg == nil || cfg.MaxNonce == 0 {
+		return ErrInvalidHashToPrimeConfig
+	}
+	switch cfg.Bits {
+	case HashToPrime128, HashToPrime256, HashToPrime264:
+		return nil
+	default:
+		return ErrInvalidHashToPrimeConfig
+	}
+}
+
+// Candidate returns the odd candidate of the input for the nonce, of exactly cfg.Bits bits.
+// The blocks are H(len(Tag) || Tag || len(input) || input || nonce || counter),
+// lengths are 8-byte and the nonce 4-byte big-endian, the counter is one byte.
+func (cfg *HashToPrimeConfig) Candidate(input []byte, nonce uint32) (*big.Int, error) {
+	if err := cfg.check(); err != nil {
+		return nil, err
+	}
+	return oddCandidate(cfg.newHash(), cfg.Tag, input, nonce, cfg.Bits), nil
+}
+
+// newHash returns a new hasher of the expansion
+func (cfg *HashToPrimeConfig) newHash() hash.Hash {
+	if cfg.Hash == nil {
+		return sha256.New()
+	}
+	return cfg.Hash()
+}
+
+// oddCandidate returns hashCandidate with the top bit and the lowest bit set
+func oddCandidate(h hash.Hash, tag, input []byte, nonce uint32, bits int) *big.Int {
+	ret := hashCandidate(h, tag, input, nonce, bits)
+	ret.SetBit(ret, bits-1, 1)
+	return ret.SetBit(ret, 0, 1)
+}
+
+// hashCandidate returns the first bits bits of the counter-mode expansion of (tag, input, nonce) with the hasher h
+func hashCandidate(h hash.Hash, tag, input []byte, nonce uint32, bits int) *big.Int {
+	var prefix []byte
+	prefix = binary.BigEndian.AppendUint64(prefix, uint64(len(tag)))
+	prefix = append(prefix, tag...)
+	prefix = binary.BigEndian.AppendUint64(prefix, uint64(len(input)))
+	prefix = append(prefix, input...)
+	prefix = binary.BigEndian.AppendUint32(prefix, nonce)
+
+	numBytes := (bits + 7) / 8
+	out := make([]byte, 0, numBytes+h.Size())
+	for counter := byte(0); len(out) < numBytes; counter++ {
+		h.Reset()
+		h.Write(prefix)
+		h.Write([]byte{counter})
+		out = h.Sum(out)
+	}
+	ret := new(big.Int).SetBytes(out[:numBytes])
+	return ret.Rsh(ret, uint(numBytes*8-bits))
+}
+
+// HashToPrime returns the prime of the input together with its nonce. The nonce can be shipped
+// with the element, so that VerifyHashToPrime checks one candidate instead of repeating the search.
+func (cfg *HashToPrimeConfig) HashToPrime(input []byte) (*big.Int, uint32, error) {
+	if err := cfg.check(); err != nil {
+		return nil, 0, err
+	}
+	return cfg.hashToPrime(cfg.newHash(), input)
+}
+
+// hashToPrime is HashToPrime with the hasher h, reused for all candidates
+func (cfg *HashToPrimeConfig) hashToPrime(h hash.Hash, input []byte) (*big.Int, uint32, error) {
+	for nonce := uint32(0); nonce < cfg.MaxNonce; nonce++ {
+		candidate := oddCandidate(h, cfg.Tag, input, nonce, cfg.Bits)
+		if candidate.ProbablyPrime(securityParaHashToPrime) {
+			return candidate, nonce, nil
+		}
+	}
+	return nil, 0, ErrPrimeNotFound
+}
+
+// PrimeFromNonce returns the candidate of the nonce, ErrInvalidNonce if it is not a prime or if it is not the nonce
+// found by HashToPrime. The candidates of the smaller nonces must be composite, so that every input has a single
+// prime. They are rejected by a cheap test, the nonce saves the primality tests with many rounds of the search.
+func (cfg *HashToPrimeConfig) PrimeFromNonce(input []byte, nonce uint32) (*big.Int, error) {
+	if err := cfg.check(); err != nil {
+		return nil, err
+	}
+	if nonce >= cfg.MaxNonce {
+		return nil, ErrInvalidNonce
+	}
+	h := cfg.newHash()
+	// a composite verdict is always right, a smaller nonce that passes may give the prime of the input
+	for i := uint32(0); i < nonce; i++ {
+		if oddCandidate(h, cfg.Tag, input, i, cfg.Bits).ProbablyPrime(0) {
+			return nil, ErrInvalidNonce
+		}
+	}
+	candidate := oddCandidate(h, cfg.Tag, input, nonce, cfg.Bits)
+	if !candidate.ProbablyPrime(securityParaHashToPrime) {
+		return nil, ErrInvalidNonce
+	}
+	return candidate, nil
+}
+
+// VerifyHashToPrime returns true if prime is the candidate of the input for the nonce and is a prime
+func (cfg *HashToPrimeConfig) VerifyHashToPrime(input []byte, nonce uint32, prime *big.Int) bool {
+	if prime == nil {
+		return false
+	}
+	candidate, err := cfg.PrimeFromNonce(input, nonce)
+	return err == nil && candidate.Cmp(prime) == 0
+}
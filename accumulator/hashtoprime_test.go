@@ -0,0 +1,138 @@
+package accumulator
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/internal/golden"
+)
+
+// hashToPrimeVector is one deterministic test vector of HashToPrimeConfig
+type hashToPrimeVector struct {
+	Bits  int    `json:"bits"`
+	Tag   string `json:"tag"`
+	Input string `json:"input"` // hex
+	Nonce uint32 `json:"nonce"`
+	Prime string `json:"prime"` // hex
+}
+
+func hashToPrimeVectorInputs() []hashToPrimeVector {
+	var ret []hashToPrimeVector
+	for _, bits := range []int{HashToPrime128, HashToPrime256, HashToPrime264} {
+		for _, tag := range []string{DefaultHashToPrimeTag, "notus/test"} {
+			for _, input := range []string{"", "00", "616263", "0123456789abcdef0123456789abcdef"} {
+				ret = append(ret, hashToPrimeVector{Bits: bits, Tag: tag, Input: input})
+			}
+		}
+	}
+	return ret
+}
+
+func TestHashToPrimeVectors(t *testing.T) {
+	var generated []byte
+	if golden.Update() {
+		vectors := hashToPrimeVectorInputs()
+		for i := range vectors {
+			cfg := &HashToPrimeConfig{Bits: vectors[i].Bits, Tag: []byte(vectors[i].Tag), MaxNonce: DefaultMaxNonce}
+			input, err := hex.DecodeString(vectors[i].Input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			prime, nonce, err := cfg.HashToPrime(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			vectors[i].Nonce, vectors[i].Prime = nonce, prime.Text(16)
+		}
+		var err error
+		if generated, err = json.MarshalIndent(vectors, "", "  "); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data := golden.File(t, "hashtoprime_vectors.json", generated)
+	var vectors []hashToPrimeVector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) != len(hashToPrimeVectorInputs()) {
+		t.Fatalf("got %d vectors, want %d", len(vectors), len(hashToPrimeVectorInputs()))
+	}
+	for _, v := range vectors {
+		cfg := &HashToPrimeConfig{Bits: v.Bits, Tag: []byte(v.Tag), MaxNonce: DefaultMaxNonce}
+		input, err := hex.DecodeString(v.Input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prime, nonce, err := cfg.HashToPrime(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nonce != v.Nonce || prime.Text(16) != v.Prime {
+			t.Errorf("HashToPrime(%q) with %d bits and tag %q = (%x, %d), want (%s, %d)", v.Input, v.Bits, v.Tag, prime, nonce, v.Prime, v.Nonce)
+		}
+		if prime.BitLen() != v.Bits || !cfg.VerifyHashToPrime(input, nonce, prime) {
+			t.Errorf("HashToPrime(%q) with %d bits and tag %q does not verify", v.Input, v.Bits, v.Tag)
+		}
+	}
+}
+
+func TestHashToPrimeConfig(t *testing.T) {
+	cfg := DefaultHashToPrimeConfig()
+	input := []byte("element")
+	prime, nonce, err := cfg.HashToPrime(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint32(0); i < nonce; i++ {
+		if _, err = cfg.PrimeFromNonce(input, i); err != ErrInvalidNonce {
+			t.Errorf("PrimeFromNonce() of a smaller nonce error = %v, want %v", err, ErrInvalidNonce)
+		}
+	}
+	// a larger nonce of another prime is rejected, the representative of the input is unique
+	for i := nonce + 1; ; i++ {
+		if c, _ := cfg.Candidate(input, i); c.ProbablyPrime(securityParaHashToPrime) {
+			if _, err = cfg.PrimeFromNonce(input, i); err != ErrInvalidNonce {
+				t.Errorf("PrimeFromNonce() of a larger nonce error = %v, want %v", err, ErrInvalidNonce)
+			}
+			if _, err = RepresentativeFromProof(string(input), HashToPrimeWithNonce, &RepresentativeProof{Nonce: i}); err != ErrInvalidNonce {
+				t.Errorf("RepresentativeFromProof() of a larger nonce error = %v, want %v", err, ErrInvalidNonce)
+			}
+			break
+		}
+	}
+	if cfg.VerifyHashToPrime(input, nonce, prime.Add(prime, big2)) || cfg.VerifyHashToPrime(input, nonce, nil) {
+		t.Error("VerifyHashToPrime() accepted a wrong prime")
+	}
+
+	other := DefaultHashToPrimeConfig()
+	other.Tag = []byte("other application")
+	c1, _ := cfg.Candidate(input, 0)
+	c2, _ := other.Candidate(input, 0)
+	if c1.Cmp(c2) == 0 {
+		t.Error("different tags give the same candidate")
+	}
+	// the lengths are encoded, so moving bytes between the tag and the input changes the candidate
+	c3, _ := (&HashToPrimeConfig{Bits: HashToPrime256, Tag: []byte("ab"), MaxNonce: 1}).Candidate([]byte("c"), 0)
+	c4, _ := (&HashToPrimeConfig{Bits: HashToPrime256, Tag: []byte("a"), MaxNonce: 1}).Candidate([]byte("bc"), 0)
+	if c3.Cmp(c4) == 0 {
+		t.Error("the tag and the input are not separated")
+	}
+
+	for _, bad := range []*HashToPrimeConfig{nil, {Bits: 100, MaxNonce: 1}, {Bits: HashToPrime256}} {
+		if _, _, err = bad.HashToPrime(input); err != ErrInvalidHashToPrimeConfig {
+			t.Errorf("HashToPrime() with %v error = %v, want %v", bad, err, ErrInvalidHashToPrimeConfig)
+		}
+	}
+	// with a single candidate per input, most inputs have no prime
+	short := &HashToPrimeConfig{Bits: HashToPrime128, Tag: []byte("short"), MaxNonce: 1}
+	for i := 0; ; i++ {
+		in := []byte{byte(i), byte(i >> 8)}
+		if _, _, err = short.HashToPrime(in); err == ErrPrimeNotFound {
+			break
+		}
+		if _, err = short.PrimeFromNonce(in, 1); err != ErrInvalidNonce {
+			t.Errorf("PrimeFromNonce() beyond MaxNonce error = %v, want %v", err, ErrInvalidNonce)
+		}
+	}
+}
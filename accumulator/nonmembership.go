@@ -0,0 +1,95 @@
+package accumulator
+
+import (
+	"errors"
+	"math/big"
+)
+
+// ErrNotCoprime is returned when a non-membership witness does not exist,
+// because the representative shares a factor with the accumulated product
+var ErrNotCoprime = errors.New("representative is not coprime to the accumulated product")
+
+// NonMembershipWitness is the universal accumulator witness for a value not in the set.
+// With a*prod + b*x = 1 and B = G^b, the witness satisfies acc^a * B^x = G mod N.
+type NonMembershipWitness struct {
+	A *big.Int
+	B *big.Int
+}
+
+// ProveNonMembership generates the non-membership witness of the element for the accumulator of the set
+func ProveNonMembership(setup *Setup, set []string, element string, encodeType EncodeType) (*NonMembershipWitness, error) {
+	reps, err := GenRepresentativesWithError(set, encodeType)
+	if err != nil {
+		return nil, err
+	}
+	rep, err := GenRepresentativesWithError([]string{element}, encodeType)
+	if err != nil {
+		return nil, err
+	}
+	return ProveNonMembershipWithProd(setup, SetProductRecursiveFast(reps), rep[0])
+}
+
+// ProveNonMembershipWithProd generates the non-membership witness of rep for the accumulator G^{prod}.
+// For zero-knowledge accumulators, the randomizer must be included in prod.
+// The Bezout coefficients come from the extended GCD, so |a| < rep and the witness stays small.
+// DIHashFromPoseidon representatives are not primes, so a witness only exists if rep happens to be coprime to prod,
+// use HashToPrimeFromSha256 for sets that need non-membership proofs.
+func ProveNonMembershipWithProd(setup *Setup, prod, rep *big.Int) (*NonMembershipWitness, error) {
+	var gcd, a, b big.Int
+	gcd.GCD(&a, &b, prod, rep)
+	if gcd.Cmp(big1) != 0 {
+		return nil, ErrNotCoprime
+	}
+	// b is negative in general, Exp then uses the inverse of G
+	B := new(big.Int).Exp(setup.G, &b, setup.N)
+	if B == nil {
+		return nil, ErrNotCoprime
+	}
+	return &NonMembershipWitness{
+		A: &a,
+		B: B,
+	}, nil
+}
+
+// VerifyNonMembership checks the non-membership witness of the element
+func VerifyNonMembership(setup *Setup, acc *big.Int, element string, encodeType EncodeType, witness *NonMembershipWitness) bool {
+	reps, err := GenRepresentativesWithError([]string{element}, encodeType)
+	if err != nil {
+		return false
+	}
+	return VerifyNonMembershipWithRep(setup, acc, reps[0], encodeType, witness)
+}
+
+// VerifyNonMembershipWithRep checks acc^a * B^{rep} = G mod N for a representative computed by the caller
+func VerifyNonMembershipWithRep(setup *Setup, acc, rep *big.Int, encodeType EncodeType, witness *NonMembershipWitness) bool {
+	if witness == nil || witness.A == nil || witness.CheckReduced(setup.N) != nil {
+		return false
+	}
+	if !IsValidRepresentative(rep, encodeType) {
+		return false
+	}
+	if !IsInZNStar(acc, setup.N) || !IsInZNStar(witness.B, setup.N) {
+		return false
+	}
+	lhs := new(big.Int).Exp(acc, witness.A, setup.N)
+	if lhs == nil {
+		return false
+	}
+	lhs.Mul(lhs, AccumulateNew(witness.B, rep, setup.N))
+	lhs.Mod(lhs, setup.N)
+	return lhs.Cmp(setup.G) == 0
+}
+
+// NonMembershipWitness returns the non-membership witness of an element that is not accumulated
+func (acc *Accumulator) NonMembershipWitness(element string) (*NonMembershipWitness, error) {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	if _, ok := acc.index[element]; ok {
+		return nil, ErrElementExists
+	}
+	reps, err := GenRepresentativesWithError([]string{element}, acc.encodeType)
+	if err != nil {
+		return nil, err
+	}
+	return ProveNonMembershipWithProd(acc.setup, acc.prod, reps[0])
+}
@@ -0,0 +1,65 @@
+package accumulator
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestNonMembership(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(12)
+	reps := GenRepresentatives(set, HashToPrimeFromSha256)
+	acc := AccumulateNew(setup.G, SetProductRecursiveFast(reps), setup.N)
+	witness, err := ProveNonMembership(setup, set, "100", HashToPrimeFromSha256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyNonMembership(setup, acc, "100", HashToPrimeFromSha256, witness) {
+		t.Errorf("valid non-membership witness rejected")
+	}
+	if VerifyNonMembership(setup, acc, "101", HashToPrimeFromSha256, witness) {
+		t.Errorf("non-membership witness accepted for another element")
+	}
+	if VerifyNonMembership(setup, acc, set[0], HashToPrimeFromSha256, witness) {
+		t.Errorf("non-membership witness accepted for a member")
+	}
+
+	// a member has no non-membership witness
+	if _, err := ProveNonMembershipWithProd(setup, SetProductRecursiveFast(reps), reps[3]); err != ErrNotCoprime {
+		t.Errorf("expected ErrNotCoprime for a member, got %v", err)
+	}
+}
+
+func TestAccumulatorNonMembershipWitness(t *testing.T) {
+	setup := TrustedSetup()
+	acc := NewAccumulator(setup, HashToPrimeFromSha256)
+	witness, err := acc.NonMembershipWitness("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyNonMembership(setup, acc.Value(), "1", HashToPrimeFromSha256, witness) {
+		t.Errorf("non-membership witness rejected for the empty accumulator")
+	}
+	if err = acc.Update(GenBenchSet(8), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = acc.NonMembershipWitness("1"); err != ErrElementExists {
+		t.Errorf("expected ErrElementExists, got %v", err)
+	}
+	witness, err = acc.NonMembershipWitness("8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyNonMembership(setup, acc.Value(), "8", HashToPrimeFromSha256, witness) {
+		t.Errorf("valid non-membership witness rejected")
+	}
+	if witness.A.CmpAbs(HashToPrime([]byte("8"))) >= 0 {
+		t.Errorf("coefficient a should be smaller than the representative")
+	}
+	var negB big.Int
+	negB.Neg(witness.B)
+	if VerifyNonMembership(setup, acc.Value(), "8", HashToPrimeFromSha256,
+		&NonMembershipWitness{A: witness.A, B: &negB}) {
+		t.Errorf("non-membership witness out of Z*_N accepted")
+	}
+}
@@ -0,0 +1,191 @@
+package accumulator
+
+import (
+	"errors"
+	"hash"
+	"math/big"
+)
+
+// maxDeterministicBits is the size below which big.Int.ProbablyPrime is exact
+const maxDeterministicBits = 64
+
+// ErrInvalidCertificate is returned when a prime certificate does not prove the primality of the hash output
+var ErrInvalidCertificate = errors.New("invalid prime certificate")
+
+// PrimeCertificate proves that a certified hash-to-prime output is a prime without probabilistic tests.
+// The output is the last of a chain of primes p_0 < p_1 < ... of the sizes of certificateSizes.
+// Nonces[0] selects p_0, small enough for an exact primality test, and Nonces[i] selects k of p_i = 2*k*p_{i-1} + 1.
+// By Pocklington's theorem p_i is a prime since p_{i-1} > sqrt(p_i), a^{p_i - 1} = 1 mod p_i and
+// gcd(a^{2k} - 1, p_i) = 1 for the base a = Bases[i-1].
+type PrimeCertificate struct {
+	Nonces []uint32 `json:"nonces"`
+	Bases  []uint32 `json:"bases"`
+}
+
+// certificateSizes returns the bit lengths of the chain of primes ending with bits bits.
+// Each prime has more than half the size of the next one plus one bit, so it is larger than the square root.
+func certificateSizes(bits int) []int {
+	sizes := []int{bits}
+	for sizes[0] > maxDeterministicBits {
+		sizes = append([]int{sizes[0]/2 + 2}, sizes...)
+	}
+	return sizes
+}
+
+// levelTag separates the hashes of the levels of the chain from each other and from HashToPrime
+func (cfg *HashToPrimeConfig) levelTag(level int) []byte {
+	tag := make([]byte, 0, len(cfg.Tag)+len("/pocklington/")+1)
+	tag = append(tag, cfg.Tag...)
+	tag = append(tag, "/pocklington/"...)
+	return append(tag, byte(level))
+}
+
+// chainCandidate returns p = 2*k*q + 1 of exactly bits bits, k being derived from the hash of the level and the nonce
+func (cfg *HashToPrimeConfig) chainCandidate(h hash.Hash, input []byte, q *big.Int, level int, nonce uint32, bits int) *big.Int {
+	// k in [lo, hi] with lo = ceil((2^{bits-1} - 1) / 2q), hi = floor((2^bits - 2) / 2q)
+	twoQ := new(big.Int).Lsh(q, 1)
+	lo := new(big.Int).Lsh(big1, uint(bits-1))
+	lo.Sub(lo, big1)
+	lo.Add(lo, twoQ)
+	lo.Sub(lo, big1)
+	lo.Div(lo, twoQ)
+	hi := new(big.Int).Lsh(big1, uint(bits))
+	hi.Sub(hi, big2)
+	hi.Div(hi, twoQ)
+	hi.Sub(hi, lo)
+	hi.Add(hi, big1)
+
+	k := hashCandidate(h, cfg.levelTag(level), input, nonce, bits)
+	k.Mod(k, hi)
+	k.Add(k, lo)
+	k.Mul(k, twoQ)
+	return k.Add(k, big1)
+}
+
+// baseCandidate returns the odd candidate of the smallest prime of the chain
+func (cfg *HashToPrimeConfig) baseCandidate(h hash.Hash, input []byte, nonce uint32, bits int) *big.Int {
+	return oddCandidate(h, cfg.levelTag(0), input, nonce, bits)
+}
+
+// CertifiedHashToPrime returns a prime of cfg.Bits bits derived from the input together with its certificate.
+// The prime is not the one of HashToPrime, the candidates are derived with a separate tag.
+func (cfg *HashToPrimeConfig) CertifiedHashToPrime(input []byte) (*big.Int, *PrimeCertificate, error) {
+	if err := cfg.check(); err != nil {
+		return nil, nil, err
+	}
+	return cfg.certifiedHashToPrime(cfg.newHash(), input)
+}
+
+// certifiedHashToPrime is CertifiedHashToPrime with the hasher h, reused for all candidates
+func (cfg *HashToPrimeConfig) certifiedHashToPrime(h hash.Hash, input []byte) (*big.Int, *PrimeCertificate, error) {
+	sizes := certificateSizes(cfg.Bits)
+	cert := &PrimeCertificate{
+		Nonces: make([]uint32, len(sizes)),
+		Bases:  make([]uint32, len(sizes)-1),
+	}
+	var p *big.Int
+	for level, bits := range sizes {
+		found := false
+		for nonce := uint32(0); nonce < cfg.MaxNonce && !found; nonce++ {
+			if level == 0 {
+				candidate := cfg.baseCandidate(h, input, nonce, bits)
+				if candidate.ProbablyPrime(0) {
+					p, found = candidate, true
+				}
+			} else {
+				candidate := cfg.chainCandidate(h, input, p, level, nonce, bits)
+				if !candidate.ProbablyPrime(securityParaHashToPrime) {
+					continue
+				}
+				base, ok := pocklingtonBase(candidate, p)
+				if ok {
+					cert.Bases[level-1] = base
+					p, found = candidate, true
+				}
+			}
+			if found {
+				cert.Nonces[level] = nonce
+			}
+		}
+		if !found {
+			return nil, nil, ErrPrimeNotFound
+		}
+	}
+	return p, cert, nil
+}
+
+// VerifyCertificate returns the prime of the input proven by the certificate, or ErrInvalidCertificate.
+// It runs one exact primality test on a small number and two exponentiations per level. The candidates of the
+// smaller nonces must be rejected as the prover does, so that a certificate with other nonces cannot prove another prime.
+func (cfg *HashToPrimeConfig) VerifyCertificate(input []byte, cert *PrimeCertificate) (*big.Int, error) {
+	if err := cfg.check(); err != nil {
+		return nil, err
+	}
+	sizes := certificateSizes(cfg.Bits)
+	if cert == nil || len(cert.Nonces) != len(sizes) || len(cert.Bases) != len(sizes)-1 {
+		return nil, ErrInvalidCertificate
+	}
+	for _, v := range cert.Nonces {
+		if v >= cfg.MaxNonce {
+			return nil, ErrInvalidCertificate
+		}
+	}
+	h := cfg.newHash()
+	// every level takes the first nonce the prover accepts, so that every input has a single prime
+	for i := uint32(0); i < cert.Nonces[0]; i++ {
+		if cfg.baseCandidate(h, input, i, sizes[0]).ProbablyPrime(0) {
+			return nil, ErrInvalidCertificate
+		}
+	}
+	p := cfg.baseCandidate(h, input, cert.Nonces[0], sizes[0])
+	if !p.ProbablyPrime(0) {
+		return nil, ErrInvalidCertificate
+	}
+	for level := 1; level < len(sizes); level++ {
+		for i := uint32(0); i < cert.Nonces[level]; i++ {
+			skipped := cfg.chainCandidate(h, input, p, level, i, sizes[level])
+			if !skipped.ProbablyPrime(0) {
+				continue
+			}
+			if _, ok := pocklingtonBase(skipped, p); ok {
+				return nil, ErrInvalidCertificate
+			}
+		}
+		candidate := cfg.chainCandidate(h, input, p, level, cert.Nonces[level], sizes[level])
+		if !pocklingtonCheck(candidate, p, big.NewInt(int64(cert.Bases[level-1]))) {
+			return nil, ErrInvalidCertificate
+		}
+		p = candidate
+	}
+	return p, nil
+}
+
+// pocklingtonBase returns the smallest base proving the prime p with the factor q of p-1
+func pocklingtonBase(p, q *big.Int) (uint32, bool) {
+	for a := uint32(2); a < 1<<10; a++ {
+		if pocklingtonCheck(p, q, big.NewInt(int64(a))) {
+			return a, true
+		}
+	}
+	return 0, false
+}
+
+// pocklingtonCheck returns true if a^{p-1} = 1 mod p and gcd(a^{(p-1)/q} - 1, p) = 1.
+// The caller makes sure that q is a prime larger than sqrt(p) dividing p-1.
+func pocklingtonCheck(p, q, a *big.Int) bool {
+	if a.Cmp(big2) < 0 || a.Cmp(p) >= 0 {
+		return false
+	}
+	pMin1 := new(big.Int).Sub(p, big1)
+	if new(big.Int).Exp(a, pMin1, p).Cmp(big1) != 0 {
+		return false
+	}
+	var e, r big.Int
+	e.QuoRem(pMin1, q, &r)
+	if r.Sign() != 0 {
+		return false
+	}
+	e.Exp(a, &e, p)
+	e.Sub(&e, big1)
+	return e.GCD(nil, nil, &e, p).Cmp(big1) == 0
+}
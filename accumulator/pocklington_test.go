@@ -0,0 +1,86 @@
+package accumulator
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestCertifiedHashToPrime(t *testing.T) {
+	input := []byte("element")
+	for _, bits := range []int{HashToPrime128, HashToPrime256, HashToPrime264} {
+		cfg := &HashToPrimeConfig{Bits: bits, Tag: []byte(DefaultHashToPrimeTag), MaxNonce: DefaultMaxNonce}
+		prime, cert, err := cfg.CertifiedHashToPrime(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prime.BitLen() != bits || !prime.ProbablyPrime(securityParaHashToPrime) {
+			t.Fatalf("CertifiedHashToPrime() with %d bits = %v, not a prime of the size", bits, prime)
+		}
+		verified, err := cfg.VerifyCertificate(input, cert)
+		if err != nil || verified.Cmp(prime) != 0 {
+			t.Fatalf("VerifyCertificate() with %d bits = %v, %v", bits, verified, err)
+		}
+		if _, err = cfg.VerifyCertificate([]byte("other"), cert); err != ErrInvalidCertificate {
+			t.Errorf("VerifyCertificate() of another input error = %v, want %v", err, ErrInvalidCertificate)
+		}
+		for level := range cert.Nonces {
+			cert.Nonces[level]++
+			if _, err = cfg.VerifyCertificate(input, cert); err != ErrInvalidCertificate {
+				t.Errorf("VerifyCertificate() with nonce %d changed error = %v, want %v", level, err, ErrInvalidCertificate)
+			}
+			cert.Nonces[level]--
+		}
+		// another certifiable prime at the last level
+		sizes, h := certificateSizes(bits), cfg.newHash()
+		top := len(sizes) - 1
+		p := cfg.baseCandidate(h, input, cert.Nonces[0], sizes[0])
+		for level := 1; level < top; level++ {
+			p = cfg.chainCandidate(h, input, p, level, cert.Nonces[level], sizes[level])
+		}
+		other := &PrimeCertificate{Nonces: append([]uint32(nil), cert.Nonces...), Bases: append([]uint32(nil), cert.Bases...)}
+		for i := cert.Nonces[top] + 1; ; i++ {
+			candidate := cfg.chainCandidate(h, input, p, top, i, bits)
+			if base, ok := pocklingtonBase(candidate, p); ok {
+				other.Nonces[top], other.Bases[top-1] = i, base
+				break
+			}
+		}
+		if _, err = cfg.VerifyCertificate(input, other); err != ErrInvalidCertificate {
+			t.Errorf("VerifyCertificate() with a larger nonce error = %v, want %v", err, ErrInvalidCertificate)
+		}
+		cert.Bases = cert.Bases[1:]
+		if _, err = cfg.VerifyCertificate(input, cert); err != ErrInvalidCertificate {
+			t.Errorf("VerifyCertificate() with a missing base error = %v, want %v", err, ErrInvalidCertificate)
+		}
+	}
+}
+
+func TestPocklingtonCheck(t *testing.T) {
+	// q > sqrt(2kq+1) for these k, so a base exists if and only if 2kq+1 is a prime
+	q := big.NewInt(1000003)
+	for k := int64(1); k < 40; k++ {
+		p := new(big.Int).Mul(q, big.NewInt(2*k))
+		p.Add(p, big1)
+		if _, got := pocklingtonBase(p, q); got != p.ProbablyPrime(0) {
+			t.Errorf("pocklingtonBase(2*%d*q+1) = %v, want %v", k, got, !got)
+		}
+	}
+	if pocklingtonCheck(big.NewInt(2*1000003+1), big.NewInt(7), big2) {
+		t.Error("pocklingtonCheck() accepted q not dividing p-1")
+	}
+}
+
+func TestCertificateSizes(t *testing.T) {
+	for _, bits := range []int{HashToPrime128, HashToPrime256, HashToPrime264} {
+		sizes := certificateSizes(bits)
+		if sizes[0] > maxDeterministicBits || sizes[len(sizes)-1] != bits {
+			t.Errorf("certificateSizes(%d) = %v", bits, sizes)
+		}
+		for i := 1; i < len(sizes); i++ {
+			// p_{i-1} >= 2^{sizes[i-1]-1} must be larger than sqrt(p_i) < 2^{sizes[i]/2}
+			if 2*(sizes[i-1]-1) <= sizes[i] {
+				t.Errorf("certificateSizes(%d) = %v, level %d too small", bits, sizes, i-1)
+			}
+		}
+	}
+}
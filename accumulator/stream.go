@@ -0,0 +1,144 @@
+package accumulator
+
+import (
+	"bufio"
+	"io"
+	"math/big"
+
+	"github.com/jiajunxin/rsa_accumulator/trace"
+)
+
+const (
+	// DefaultStreamChunkSize is the number of elements a StreamAccumulator keeps before exponentiating,
+	// 2^14 DI representatives multiply to 2 MiB
+	DefaultStreamChunkSize = 1 << 14
+	// maxStreamLineSize bounds one element read by AccumulateFromReader
+	maxStreamLineSize = 1 << 20
+)
+
+// StreamAccumulator accumulates elements in chunks, acc = acc^{x1*x2*...*xk} for every chunk of k elements,
+// so that only one chunk of representatives and its product are in memory. The total cost is the one of
+// accumulating the whole set at once. Elements are not checked for duplicates.
+// WithTrapdoor makes the exponentiations use the factorization of N, with a trapdoor of another modulus
+// every method returning an error returns ErrInvalidTrapdoor.
+type StreamAccumulator struct {
+	setup      *Setup
+	trapdoor   *TrapdoorSetup // nil if the factorization of N is unknown
+	err        error          // ErrInvalidTrapdoor for a trapdoor of another modulus
+	encodeType EncodeType
+	chunkSize  int
+	numWorkers int
+
+	value   *big.Int
+	count   uint64
+	pending []string
+}
+
+// NewStreamAccumulator returns a stream accumulator starting from setup.G.
+// DefaultStreamChunkSize is used if chunkSize <= 0, the representatives of a chunk are generated on numWorkers
+// goroutines, all CPUs if numWorkers <= 0.
+func NewStreamAccumulator(setup *Setup, encodeType EncodeType, chunkSize, numWorkers int, opts ...trace.Option) *StreamAccumulator {
+	if chunkSize <= 0 {
+		chunkSize = DefaultStreamChunkSize
+	}
+	trapdoor, err := trapdoorOf(setup.N, opts)
+	return &StreamAccumulator{
+		setup:      setup,
+		trapdoor:   trapdoor,
+		err:        err,
+		encodeType: encodeType,
+		chunkSize:  chunkSize,
+		numWorkers: numWorkers,
+		value:      new(big.Int).Set(setup.G),
+		pending:    make([]string, 0, chunkSize),
+	}
+}
+
+// Add buffers the elements and accumulates every full chunk. If a chunk fails, e.g. with ErrUnknownEncodeType,
+// the chunk is dropped and the value is the one before the chunk.
+func (s *StreamAccumulator) Add(elements ...string) error {
+	if s.err != nil {
+		return s.err
+	}
+	for _, v := range elements {
+		s.pending = append(s.pending, v)
+		if len(s.pending) == s.chunkSize {
+			if err := s.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Flush accumulates the buffered elements
+func (s *StreamAccumulator) Flush() error {
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.pending) == 0 {
+		return nil
+	}
+	chunk := s.pending
+	s.pending = s.pending[:0]
+	reps, err := GenRepresentativesParallelWithError(chunk, s.encodeType, s.numWorkers)
+	if err != nil {
+		return err
+	}
+	var exp *big.Int
+	if s.trapdoor != nil {
+		exp = s.trapdoor.productModPhi(reps)
+	} else {
+		exp = SetProductRecursiveFast(reps)
+	}
+	s.value = proverExp(s.setup, s.trapdoor, s.value, exp)
+	s.count += uint64(len(chunk))
+	return nil
+}
+
+// Value flushes the buffered elements and returns a copy of the accumulator value
+func (s *StreamAccumulator) Value() (*big.Int, error) {
+	if err := s.Flush(); err != nil {
+		return nil, err
+	}
+	return new(big.Int).Set(s.value), nil
+}
+
+// Count returns the number of accumulated elements, without the buffered ones
+func (s *StreamAccumulator) Count() uint64 {
+	return s.count
+}
+
+// AccumulateFromReader accumulates the elements of r, one per line. Empty lines are skipped and
+// a line may not be longer than 1 MiB. It returns the accumulator value and the number of elements.
+func AccumulateFromReader(setup *Setup, encodeType EncodeType, r io.Reader, chunkSize int) (*big.Int, uint64, error) {
+	s := NewStreamAccumulator(setup, encodeType, chunkSize, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := s.Add(scanner.Text()); err != nil {
+			return nil, s.Count(), err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, s.Count(), err
+	}
+	value, err := s.Value()
+	return value, s.Count(), err
+}
+
+// AccumulateFromChannel accumulates the elements received from c until it is closed.
+// It returns the accumulator value and the number of elements, the rest of c is not drained on an error.
+func AccumulateFromChannel(setup *Setup, encodeType EncodeType, c <-chan string, chunkSize int) (*big.Int, uint64, error) {
+	s := NewStreamAccumulator(setup, encodeType, chunkSize, 0)
+	for v := range c {
+		if err := s.Add(v); err != nil {
+			return nil, s.Count(), err
+		}
+	}
+	value, err := s.Value()
+	return value, s.Count(), err
+}
@@ -0,0 +1,69 @@
+package accumulator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStreamAccumulator(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(100)
+	want := AccumulateNew(setup.G, SetProductRecursiveFast(GenRepresentatives(set, DIHashFromPoseidon)), setup.N)
+	for _, chunkSize := range []int{0, 1, 7, 100, 1000} {
+		s := NewStreamAccumulator(setup, DIHashFromPoseidon, chunkSize, 2)
+		if err := s.Add(set[:50]...); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Add(set[50:]...); err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Cmp(want) != 0 || s.Count() != uint64(len(set)) {
+			t.Errorf("chunk size %d: value or count %d differs from AccumulateNew()", chunkSize, s.Count())
+		}
+	}
+
+	got, count, err := AccumulateFromReader(setup, DIHashFromPoseidon, strings.NewReader(strings.Join(set, "\n")+"\n\n"), 16)
+	if err != nil || got.Cmp(want) != 0 || count != uint64(len(set)) {
+		t.Errorf("AccumulateFromReader() = %v, %d, %v", got, count, err)
+	}
+	c := make(chan string)
+	go func() {
+		for _, v := range set {
+			c <- v
+		}
+		close(c)
+	}()
+	got, count, err = AccumulateFromChannel(setup, DIHashFromPoseidon, c, 16)
+	if err != nil || got.Cmp(want) != 0 || count != uint64(len(set)) {
+		t.Errorf("AccumulateFromChannel() = %v, %d, %v", got, count, err)
+	}
+
+	bad := strings.Join(set[:20], "\n") + "\nnot a number\n"
+	if _, count, err = AccumulateFromReader(setup, DIHashFromPoseidon, strings.NewReader(bad), 16); err != ErrInvalidInput || count != 16 {
+		t.Errorf("AccumulateFromReader() with a bad element = %d, %v, want 16, %v", count, err, ErrInvalidInput)
+	}
+}
+
+func TestStreamAccumulatorWithTrapdoor(t *testing.T) {
+	setup, phiN := genSmallTrapdoorSetup(t)
+	ts, err := NewTrapdoorSetupFromPhi(setup, phiN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := GenBenchSet(40)
+	s := NewStreamAccumulator(setup, HashToPrimeFromSha256, 8, 0, WithTrapdoor(ts))
+	if err = s.Add(set...); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cmp(ts.Accumulate(GenRepresentatives(set, HashToPrimeFromSha256))) != 0 {
+		t.Error("value with the trapdoor differs from TrapdoorSetup.Accumulate()")
+	}
+}
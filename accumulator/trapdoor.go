@@ -0,0 +1,242 @@
+package accumulator
+
+import (
+	"errors"
+	"math/big"
+	"runtime"
+	"sync"
+
+	"github.com/jiajunxin/rsa_accumulator/group"
+	"github.com/jiajunxin/rsa_accumulator/trace"
+)
+
+// ErrInvalidTrapdoor is returned when p, q or phi(N) do not match the modulus of the setup
+var ErrInvalidTrapdoor = errors.New("trapdoor does not match the modulus")
+
+// TrapdoorSetup is the setup together with the factorization of N, only known to the manager of the accumulator.
+// Exponents are reduced modulo phi(N) before exponentiation, and representatives coprime to phi(N)
+// are removed with one modular inverse. It must never be given to users.
+type TrapdoorSetup struct {
+	Setup *Setup
+	P     *big.Int
+	Q     *big.Int
+	PhiN  *big.Int // (P-1)(Q-1)
+
+	crt *group.RSAWithFactors // nil if only phi(N) is known
+}
+
+// NewTrapdoorSetup returns the trapdoor setup for the factorization N = pq
+func NewTrapdoorSetup(setup *Setup, p, q *big.Int) (*TrapdoorSetup, error) {
+	if p == nil || q == nil || p.Cmp(big1) <= 0 || q.Cmp(big1) <= 0 || new(big.Int).Mul(p, q).Cmp(setup.N) != 0 {
+		return nil, ErrInvalidTrapdoor
+	}
+	crt, err := group.NewRSAWithFactors(p, q)
+	if err != nil {
+		return nil, ErrInvalidTrapdoor
+	}
+	phiN := new(big.Int).Mul(new(big.Int).Sub(p, big1), new(big.Int).Sub(q, big1))
+	return &TrapdoorSetup{
+		Setup: setup,
+		P:     new(big.Int).Set(p),
+		Q:     new(big.Int).Set(q),
+		PhiN:  phiN,
+		crt:   crt,
+	}, nil
+}
+
+// Group returns Z*_N with CRT exponentiation if the factors are known, otherwise the plain RSA group
+func (ts *TrapdoorSetup) Group() group.Group {
+	if ts.crt != nil {
+		return ts.crt
+	}
+	return ts.Setup.Group()
+}
+
+// AccAndProve is AccAndProveWithError over ts.Setup with the exponentiations done with the factorization of N
+func (ts *TrapdoorSetup) AccAndProve(set []string, encodeType EncodeType, opts ...trace.Option) (*big.Int, []*big.Int, error) {
+	return accAndProve(set, encodeType, ts.Setup, ts, opts...)
+}
+
+// AccAndProveParallel is AccAndProveParallelWithError over ts.Setup with the exponentiations done with
+// the factorization of N
+func (ts *TrapdoorSetup) AccAndProveParallel(set []string, encodeType EncodeType,
+	opts ...trace.Option) (*big.Int, []*big.Int, error) {
+	return accAndProveParallel(set, encodeType, ts.Setup, ts, opts...)
+}
+
+// ZKAccumulate is ZKAccumulateWithError over ts.Setup with the exponentiations done with the factorization of N
+func (ts *TrapdoorSetup) ZKAccumulate(set []string, encodeType EncodeType, opts ...trace.Option) (*big.Int, []*big.Int, error) {
+	return zkAccumulate(set, encodeType, ts.Setup, ts, opts...)
+}
+
+// NewTrapdoorSetupFromPhi returns the trapdoor setup for phi(N), N must be the product of two primes.
+// Since phi(N) = N - (p+q) + 1, p and q are the roots of x^2 - (N - phi(N) + 1)x + N.
+func NewTrapdoorSetupFromPhi(setup *Setup, phiN *big.Int) (*TrapdoorSetup, error) {
+	if phiN == nil || phiN.Sign() <= 0 || phiN.Cmp(setup.N) >= 0 {
+		return nil, ErrInvalidTrapdoor
+	}
+	var sum, disc, root big.Int
+	sum.Sub(setup.N, phiN)
+	sum.Add(&sum, big1)
+	disc.Mul(&sum, &sum)
+	disc.Sub(&disc, new(big.Int).Lsh(setup.N, 2))
+	if disc.Sign() < 0 {
+		return nil, ErrInvalidTrapdoor
+	}
+	root.Sqrt(&disc)
+	if new(big.Int).Mul(&root, &root).Cmp(&disc) != 0 {
+		return nil, ErrInvalidTrapdoor
+	}
+	p := new(big.Int).Add(&sum, &root)
+	p.Rsh(p, 1)
+	q := new(big.Int).Sub(&sum, &root)
+	q.Rsh(q, 1)
+	return NewTrapdoorSetup(setup, p, q)
+}
+
+// Exp calculates base^{x mod phi(N)} mod N, which equals base^x for base in Z*_N.
+// A negative x raises the inverse of base. With the factors known, it exponentiates modulo P and Q in parallel.
+func (ts *TrapdoorSetup) Exp(base, x *big.Int) *big.Int {
+	if ts.crt != nil {
+		return ts.crt.Exp(base, x).(*big.Int)
+	}
+	return ts.expModPhi(base, x)
+}
+
+// expSequential is Exp without splitting the CRT halves over goroutines
+func (ts *TrapdoorSetup) expSequential(base, x *big.Int) *big.Int {
+	if ts.crt != nil {
+		return ts.crt.ExpSequential(base, x).(*big.Int)
+	}
+	return ts.expModPhi(base, x)
+}
+
+func (ts *TrapdoorSetup) expModPhi(base, x *big.Int) *big.Int {
+	var e big.Int
+	e.Mod(x, ts.PhiN)
+	return new(big.Int).Exp(base, &e, ts.Setup.N)
+}
+
+// productModPhi returns the product of the representatives modulo phi(N)
+func (ts *TrapdoorSetup) productModPhi(reps []*big.Int) *big.Int {
+	prod := big.NewInt(1)
+	for _, v := range reps {
+		prod.Mul(prod, v)
+		prod.Mod(prod, ts.PhiN)
+	}
+	return prod
+}
+
+// Accumulate calculates G^{x1*x2*...*xk} mod N without computing the product of the representatives
+func (ts *TrapdoorSetup) Accumulate(reps []*big.Int) *big.Int {
+	return ts.Exp(ts.Setup.G, ts.productModPhi(reps))
+}
+
+// Delete removes the representatives from the accumulator with one modular inverse,
+// acc^{(x1*x2*...*xk)^{-1} mod phi(N)}. The same works for the membership witnesses of the remaining elements.
+// ErrNotCoprime is returned if the product of the representatives is not invertible modulo phi(N).
+func (ts *TrapdoorSetup) Delete(acc *big.Int, reps []*big.Int) (*big.Int, error) {
+	inv := new(big.Int).ModInverse(ts.productModPhi(reps), ts.PhiN)
+	if inv == nil {
+		return nil, ErrNotCoprime
+	}
+	return ts.Exp(acc, inv), nil
+}
+
+// ProveMembership computes all membership proofs with exponents reduced modulo phi(N).
+// The exponent of each proof, the product of all other representatives modulo phi(N), comes from the
+// prefix and suffix products, so the n exponentiations have exponents of the size of N instead of
+// the O(nlog(n)) large exponentiations of the divide-and-conquer method. They run on all CPUs.
+func (ts *TrapdoorSetup) ProveMembership(base *big.Int, set []*big.Int) []*big.Int {
+	return ts.proveMembership(base, set, runtime.NumCPU())
+}
+
+// proveMembership is ProveMembership with the exponentiations split over numWorkers goroutines
+func (ts *TrapdoorSetup) proveMembership(base *big.Int, set []*big.Int, numWorkers int) []*big.Int {
+	if len(set) == 0 {
+		return nil
+	}
+	if len(set) == 1 {
+		return []*big.Int{new(big.Int).Set(base)}
+	}
+	exps := ts.complementProducts(set)
+	proofs := make([]*big.Int, len(set))
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(set); i += numWorkers {
+				proofs[i] = ts.expSequential(base, exps[i])
+			}
+		}(w)
+	}
+	wg.Wait()
+	return proofs
+}
+
+// complementProducts returns the product of all representatives but set[i] modulo phi(N) for every i
+func (ts *TrapdoorSetup) complementProducts(set []*big.Int) []*big.Int {
+	exps := make([]*big.Int, len(set))
+	prefix := big.NewInt(1)
+	for i := range set {
+		exps[i] = new(big.Int).Set(prefix)
+		prefix.Mul(prefix, set[i])
+		prefix.Mod(prefix, ts.PhiN)
+	}
+	suffix := big.NewInt(1)
+	for i := len(set) - 1; i >= 0; i-- {
+		exps[i].Mul(exps[i], suffix)
+		exps[i].Mod(exps[i], ts.PhiN)
+		suffix.Mul(suffix, set[i])
+		suffix.Mod(suffix, ts.PhiN)
+	}
+	return exps
+}
+
+// Modulus returns the modulus of the setup, TrapdoorSetup is a trace.Trapdoor
+func (ts *TrapdoorSetup) Modulus() *big.Int {
+	return ts.Setup.N
+}
+
+// WithTrapdoor makes the prover use the factorization of N with CRT exponentiation, a nil trapdoor is no option.
+// The provers returning an error reject a trapdoor of another modulus with ErrInvalidTrapdoor,
+// the others ignore it and compute without the trapdoor.
+func WithTrapdoor(ts *TrapdoorSetup) trace.Option {
+	return func(o *trace.Options) {
+		if ts != nil {
+			o.Trapdoor = ts
+		}
+	}
+}
+
+// trapdoorFor returns the trapdoor of the options if it matches N, nil otherwise
+func trapdoorFor(N *big.Int, opts []trace.Option) *TrapdoorSetup {
+	ts, err := trapdoorOf(N, opts)
+	if err != nil {
+		return nil
+	}
+	return ts
+}
+
+// trapdoorOf returns the trapdoor of the options, nil if there is none,
+// and ErrInvalidTrapdoor if it does not belong to N
+func trapdoorOf(N *big.Int, opts []trace.Option) (*TrapdoorSetup, error) {
+	trapdoor := trace.NewOptions(opts...).Trapdoor
+	if trapdoor == nil {
+		return nil, nil
+	}
+	ts, ok := trapdoor.(*TrapdoorSetup)
+	if !ok || ts.Modulus().Cmp(N) != 0 {
+		return nil, ErrInvalidTrapdoor
+	}
+	return ts, nil
+}
+
+// proveWithRandomizer computes the membership proofs with base^randomizer, the base of the accumulator
+func (ts *TrapdoorSetup) proveWithRandomizer(base, randomizer *big.Int, set []*big.Int, numWorkers int) []*big.Int {
+	return ts.proveMembership(ts.Exp(base, randomizer), set, numWorkers)
+}
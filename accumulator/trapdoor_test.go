@@ -0,0 +1,178 @@
+package accumulator
+
+import (
+	"math/big"
+	"math/bits"
+	"testing"
+
+	"github.com/jiajunxin/multiexp"
+	"github.com/jiajunxin/rsa_accumulator/trace"
+)
+
+func TestNewTrapdoorSetup(t *testing.T) {
+	setup, phiN := genSmallTrapdoorSetup(t)
+	ts, err := NewTrapdoorSetupFromPhi(setup, phiN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if new(big.Int).Mul(ts.P, ts.Q).Cmp(setup.N) != 0 || ts.PhiN.Cmp(phiN) != 0 {
+		t.Fatal("factorization not recovered from phi(N)")
+	}
+	if _, err = NewTrapdoorSetup(setup, ts.P, ts.P); err != ErrInvalidTrapdoor {
+		t.Errorf("NewTrapdoorSetup() with wrong factors error = %v, want %v", err, ErrInvalidTrapdoor)
+	}
+	if _, err = NewTrapdoorSetupFromPhi(setup, new(big.Int).Sub(phiN, big2)); err != ErrInvalidTrapdoor {
+		t.Errorf("NewTrapdoorSetupFromPhi() with wrong phi error = %v, want %v", err, ErrInvalidTrapdoor)
+	}
+}
+
+func TestTrapdoorOperations(t *testing.T) {
+	setup, phiN := genSmallTrapdoorSetup(t)
+	ts, err := NewTrapdoorSetupFromPhi(setup, phiN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reps := GenRepresentatives(GenBenchSet(13), HashToPrimeFromSha256)
+
+	acc := ts.Accumulate(reps)
+	if acc.Cmp(AccumulateNew(setup.G, SetProductRecursiveFast(reps), setup.N)) != 0 {
+		t.Fatal("Accumulate() differs from AccumulateNew()")
+	}
+	proofs := ts.ProveMembership(setup.G, reps)
+	expected := ProveMembership(setup.G, setup.N, reps)
+	for i := range reps {
+		if proofs[i].Cmp(expected[i]) != 0 {
+			t.Errorf("proof %d differs from ProveMembership()", i)
+		}
+	}
+
+	deleted, err := ts.Delete(acc, reps[10:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted.Cmp(ts.Accumulate(reps[:10])) != 0 {
+		t.Error("Delete() differs from accumulating the remaining representatives")
+	}
+	if ts.Exp(setup.G, big.NewInt(-1)).Cmp(new(big.Int).ModInverse(setup.G, setup.N)) != 0 {
+		t.Error("Exp() with a negative exponent is not the inverse")
+	}
+	if _, err = ts.Delete(acc, []*big.Int{big2}); err != ErrNotCoprime {
+		t.Errorf("Delete() of a factor of phi(N) error = %v, want %v", err, ErrNotCoprime)
+	}
+}
+
+func TestAccumulatorFromTrapdoorSetup(t *testing.T) {
+	setup, phiN := genSmallTrapdoorSetup(t)
+	ts, err := NewTrapdoorSetupFromPhi(setup, phiN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc := NewAccumulatorFromTrapdoorSetup(ts, DIHashFromPoseidon)
+	set := GenBenchSet(12)
+	if err = acc.Update(set, nil); err != nil {
+		t.Fatal(err)
+	}
+	checkAccumulatorState(t, acc)
+	if err = acc.Update([]string{"123456789"}, set[3:6]); err != nil {
+		t.Fatal(err)
+	}
+	checkAccumulatorState(t, acc)
+	acc.stale = true
+	checkAccumulatorState(t, acc)
+}
+
+func TestProveWithTrapdoor(t *testing.T) {
+	setup, phiN := genSmallTrapdoorSetup(t)
+	ts, err := NewTrapdoorSetupFromPhi(setup, phiN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reps := GenRepresentatives(GenBenchSet(20), DIHashFromPoseidon)
+	randomizer := big.NewInt(123457)
+	base := AccumulateNew(setup.G, randomizer, setup.N)
+	expected := ProveMembership(base, setup.N, reps)
+	table := multiexp.NewPrecomputeTable(setup.G, setup.N, len(reps)*1100/bits.UintSize)
+
+	results := map[string][]*big.Int{
+		"ProveMembershipParallel":                        ProveMembershipParallel(base, setup.N, reps, 2, WithTrapdoor(ts)),
+		"ProveMembershipParallelWithTable":               ProveMembershipParallelWithTable(base, setup.N, reps, 2, nil, WithTrapdoor(ts)),
+		"ProveMembershipParallelWithTableWithRandomizer": ProveMembershipParallelWithTableWithRandomizer(setup.G, randomizer, setup.N, reps, 2, nil, WithTrapdoor(ts)),
+		"ProveMembershipSingleThreadWithRandomizer":      ProveMembershipSingleThreadWithRandomizer(setup.G, randomizer, setup.N, reps, nil, WithTrapdoor(ts)),
+		// a trapdoor of another modulus is ignored
+		"ProveMembershipSingleThreadWithRandomizer without trapdoor": ProveMembershipSingleThreadWithRandomizer(setup.G, randomizer, setup.N, reps, table,
+			WithTrapdoor(&TrapdoorSetup{Setup: &Setup{N: big.NewInt(15)}})),
+	}
+	c := make(chan []*big.Int, 1)
+	ProveMembershipParallelWithTableWithRandomizerWithChan(setup.G, randomizer, setup.N, reps, 0, nil, c, WithTrapdoor(ts))
+	results["ProveMembershipParallelWithTableWithRandomizerWithChan"] = <-c
+	for name, proofs := range results {
+		if len(proofs) != len(reps) {
+			t.Fatalf("%s returned %d proofs, want %d", name, len(proofs), len(reps))
+		}
+		for i := range reps {
+			if proofs[i].Cmp(expected[i]) != 0 {
+				t.Errorf("%s: proof %d differs from ProveMembership()", name, i)
+			}
+		}
+	}
+}
+
+func TestTrapdoorProver(t *testing.T) {
+	setup, phiN := genSmallTrapdoorSetup(t)
+	ts, err := NewTrapdoorSetupFromPhi(setup, phiN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := GenBenchSet(10)
+	acc, proofs, err := ts.ZKAccumulate(set, DIHashFromPoseidon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range set {
+		if !VerifyMembership(setup, acc, set[i], DIHashFromPoseidon, proofs[i]) {
+			t.Errorf("ZKAccumulate() proof %d does not verify", i)
+		}
+	}
+	acc2, proofs2 := AccAndProve(set, DIHashFromPoseidon, setup)
+	for name, prove := range map[string]func([]string, EncodeType, ...trace.Option) (*big.Int, []*big.Int, error){
+		"AccAndProve":         ts.AccAndProve,
+		"AccAndProveParallel": ts.AccAndProveParallel,
+		// the trapdoor passed as an option of the setup functions
+		"AccAndProveWithError": func(set []string, encodeType EncodeType, opts ...trace.Option) (*big.Int, []*big.Int, error) {
+			return AccAndProveWithError(set, encodeType, setup, append(opts, WithTrapdoor(ts))...)
+		},
+		"AccAndProveParallelWithError": func(set []string, encodeType EncodeType, opts ...trace.Option) (*big.Int, []*big.Int, error) {
+			return AccAndProveParallelWithError(set, encodeType, setup, append(opts, WithTrapdoor(ts))...)
+		},
+	} {
+		if acc, proofs, err = prove(set, DIHashFromPoseidon); err != nil {
+			t.Fatal(err)
+		}
+		if acc.Cmp(acc2) != 0 {
+			t.Fatalf("%s() with the trapdoor differs from AccAndProve()", name)
+		}
+		for i := range set {
+			if proofs[i].Cmp(proofs2[i]) != 0 {
+				t.Errorf("%s: proof %d differs from AccAndProve()", name, i)
+			}
+		}
+	}
+	// the provers returning an error reject a trapdoor of another modulus
+	other := WithTrapdoor(&TrapdoorSetup{Setup: &Setup{N: big.NewInt(15)}})
+	for name, prove := range map[string]func([]string, EncodeType, *Setup, ...trace.Option) (*big.Int, []*big.Int, error){
+		"AccAndProveWithError":         AccAndProveWithError,
+		"AccAndProveParallelWithError": AccAndProveParallelWithError,
+		"ZKAccumulateWithError":        ZKAccumulateWithError,
+	} {
+		if _, _, err = prove(set, DIHashFromPoseidon, setup, other); err != ErrInvalidTrapdoor {
+			t.Errorf("%s() with a trapdoor of another modulus error = %v, want %v", name, err, ErrInvalidTrapdoor)
+		}
+	}
+	s := NewStreamAccumulator(setup, DIHashFromPoseidon, 4, 0, other)
+	if err = s.Add(set...); err != ErrInvalidTrapdoor {
+		t.Errorf("StreamAccumulator.Add() with a trapdoor of another modulus error = %v, want %v", err, ErrInvalidTrapdoor)
+	}
+	if _, err = s.Value(); err != ErrInvalidTrapdoor {
+		t.Errorf("StreamAccumulator.Value() with a trapdoor of another modulus error = %v, want %v", err, ErrInvalidTrapdoor)
+	}
+}
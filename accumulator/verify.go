@@ -0,0 +1,100 @@
+package accumulator
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// diUpperBound is the exclusive upper bound of DI hash outputs, 2^1023 + the BN254 scalar field modulus
+var diUpperBound = new(big.Int).Add(new(big.Int).Lsh(big1, 1023), fr.Modulus())
+
+// IsInZNStar returns true if 0 < x < N and x is coprime to N
+func IsInZNStar(x, N *big.Int) bool {
+	if x == nil || x.Sign() <= 0 || x.Cmp(N) >= 0 {
+		return false
+	}
+	var gcd big.Int
+	gcd.GCD(nil, nil, x, N)
+	return gcd.Cmp(big1) == 0
+}
+
+// IsValidRepresentative returns true if the representative could have been generated with the encode type.
+// A HashToPrimeFromSha256 representative must be a prime of at most 256 bits, and
+// a DIHashFromPoseidon representative must lie in [2^1023, 2^1023 + r), r being the BN254 scalar field size.
+// The other primes must have exactly 256 bits and DIHashFromMiMC is in the range of DIHashFromPoseidon.
+// It returns false for an unknown EncodeType.
+func IsValidRepresentative(rep *big.Int, encodeType EncodeType) bool {
+	if rep == nil || rep.Sign() <= 0 {
+		return false
+	}
+	encoder, err := lookupEncoder(encodeType)
+	if err != nil {
+		return false
+	}
+	return encoder.IsValid(rep)
+}
+
+// VerifyMembership checks that witness^{rep} = acc mod N, where rep is the representative of the element
+func VerifyMembership(setup *Setup, acc *big.Int, element string, encodeType EncodeType, witness *big.Int) bool {
+	reps, err := GenRepresentativesWithError([]string{element}, encodeType)
+	if err != nil {
+		return false
+	}
+	rep := reps[0]
+	return VerifyMembershipWithRep(setup, acc, rep, encodeType, witness)
+}
+
+// VerifyMembershipWithRep checks the membership witness of a representative computed by the caller,
+// e.g. the DI hash of a Notus leaf. The representative is checked against the encode type.
+func VerifyMembershipWithRep(setup *Setup, acc, rep *big.Int, encodeType EncodeType, witness *big.Int) bool {
+	if !IsValidRepresentative(rep, encodeType) {
+		return false
+	}
+	return verifyExp(setup, acc, rep, witness)
+}
+
+// VerifyMembershipWithProof checks the membership witness of the element with the representative recomputed
+// from the proof of GenRepresentativesWithProofs, instead of searching for the prime again
+func VerifyMembershipWithProof(setup *Setup, acc *big.Int, element string, encodeType EncodeType,
+	proof *RepresentativeProof, witness *big.Int) bool {
+	rep, err := RepresentativeFromProof(element, encodeType, proof)
+	if err != nil {
+		return false
+	}
+	return verifyExp(setup, acc, rep, witness)
+}
+
+// VerifyBatchMembership checks one witness for all the elements, i.e. witness^{x1*x2*...*xk} = acc mod N
+func VerifyBatchMembership(setup *Setup, acc *big.Int, elements []string, encodeType EncodeType, witness *big.Int) bool {
+	if len(elements) == 0 {
+		return false
+	}
+	reps, err := GenRepresentativesWithError(elements, encodeType)
+	if err != nil {
+		return false
+	}
+	return VerifyBatchMembershipWithReps(setup, acc, reps, encodeType, witness)
+}
+
+// VerifyBatchMembershipWithReps checks one witness for all the representatives computed by the caller
+func VerifyBatchMembershipWithReps(setup *Setup, acc *big.Int, reps []*big.Int, encodeType EncodeType, witness *big.Int) bool {
+	if len(reps) == 0 {
+		return false
+	}
+	for _, v := range reps {
+		if !IsValidRepresentative(v, encodeType) {
+			return false
+		}
+	}
+	return verifyExp(setup, acc, SetProductRecursiveFast(reps), witness)
+}
+
+// verifyExp checks acc and witness are in Z*_N and witness^{exp} = acc mod N
+func verifyExp(setup *Setup, acc, exp, witness *big.Int) bool {
+	grp := setup.Group()
+	if !grp.IsElement(acc) || !grp.IsElement(witness) {
+		return false
+	}
+	return grp.Equal(grp.Exp(witness, exp), acc)
+}
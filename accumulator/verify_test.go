@@ -0,0 +1,111 @@
+package accumulator
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestVerifyMembership(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(16)
+	for _, encodeType := range []EncodeType{HashToPrimeFromSha256, DIHashFromPoseidon, HashToPrimeWithNonce, HashToPrimeWithCertificate} {
+		acc, proofs := AccAndProve(set, encodeType, setup)
+		for i := range set {
+			if !VerifyMembership(setup, acc, set[i], encodeType, proofs[i]) {
+				t.Errorf("valid membership witness %d rejected for encode type %d", i, encodeType)
+			}
+		}
+		if VerifyMembership(setup, acc, set[0], encodeType, proofs[1]) {
+			t.Errorf("witness of another element accepted")
+		}
+		if VerifyMembership(setup, acc, "16", encodeType, proofs[0]) {
+			t.Errorf("witness of a non-member accepted")
+		}
+		if VerifyMembership(setup, acc, set[0], encodeType, big.NewInt(0)) {
+			t.Errorf("witness 0 accepted")
+		}
+		var shifted big.Int
+		shifted.Add(proofs[0], setup.N)
+		if VerifyMembership(setup, acc, set[0], encodeType, &shifted) {
+			t.Errorf("witness not reduced mod N accepted")
+		}
+	}
+}
+
+func TestVerifyMembershipWithRep(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(8)
+	rep := GenRepresentatives(set, HashToPrimeFromSha256)
+	// a composite exponent 2x gives a valid equation with a witness of the form w^{2x} = acc
+	composite := new(big.Int).Lsh(rep[0], 1)
+	proofs := ProveMembership(setup.G, setup.N, rep)
+	acc := AccumulateNew(proofs[0], rep[0], setup.N)
+	if !VerifyMembershipWithRep(setup, acc, rep[0], HashToPrimeFromSha256, proofs[0]) {
+		t.Errorf("valid membership witness rejected")
+	}
+	accComposite := AccumulateNew(proofs[0], composite, setup.N)
+	if VerifyMembershipWithRep(setup, accComposite, composite, HashToPrimeFromSha256, proofs[0]) {
+		t.Errorf("composite representative accepted")
+	}
+
+	diRep := GenRepresentatives(set, DIHashFromPoseidon)
+	if !IsValidRepresentative(diRep[0], DIHashFromPoseidon) {
+		t.Errorf("DI hash representative rejected")
+	}
+	if IsValidRepresentative(new(big.Int).Sub(Min1024, big1), DIHashFromPoseidon) {
+		t.Errorf("DI hash representative below the range accepted")
+	}
+	if IsValidRepresentative(diUpperBound, DIHashFromPoseidon) {
+		t.Errorf("DI hash representative above the range accepted")
+	}
+}
+
+func TestVerifyBatchMembership(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(10)
+	for _, encodeType := range []EncodeType{HashToPrimeFromSha256, DIHashFromPoseidon, HashToPrimeWithNonce, HashToPrimeWithCertificate} {
+		rep := GenRepresentatives(set, encodeType)
+		acc := AccumulateNew(setup.G, SetProductRecursiveFast(rep), setup.N)
+		// witness for the first 4 elements is G to the power of the others
+		witness := AccumulateNew(setup.G, SetProductRecursiveFast(rep[4:]), setup.N)
+		if !VerifyBatchMembership(setup, acc, set[:4], encodeType, witness) {
+			t.Errorf("valid batch witness rejected for encode type %d", encodeType)
+		}
+		if VerifyBatchMembership(setup, acc, set[:3], encodeType, witness) {
+			t.Errorf("batch witness accepted for a different subset")
+		}
+		if VerifyBatchMembership(setup, acc, nil, encodeType, acc) {
+			t.Errorf("empty batch accepted")
+		}
+	}
+}
+
+func TestVerifyMembershipWithProof(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(8)
+	for _, encodeType := range []EncodeType{HashToPrimeWithNonce, HashToPrimeWithCertificate} {
+		reps, repProofs, err := GenRepresentativesWithProofs(set, encodeType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proofs := ProveMembership(setup.G, setup.N, reps)
+		acc := AccumulateNew(proofs[0], reps[0], setup.N)
+		for i := range set {
+			if !IsValidRepresentative(reps[i], encodeType) {
+				t.Errorf("representative %d rejected for encode type %d", i, encodeType)
+			}
+			if !VerifyMembershipWithProof(setup, acc, set[i], encodeType, repProofs[i], proofs[i]) {
+				t.Errorf("valid membership witness %d rejected for encode type %d", i, encodeType)
+			}
+		}
+		if VerifyMembershipWithProof(setup, acc, set[0], encodeType, repProofs[1], proofs[0]) {
+			t.Errorf("representative proof of another element accepted for encode type %d", encodeType)
+		}
+		if VerifyMembershipWithProof(setup, acc, set[0], encodeType, nil, proofs[0]) {
+			t.Errorf("missing representative proof accepted for encode type %d", encodeType)
+		}
+	}
+	if _, _, err := GenRepresentativesWithProofs(set, DIHashFromPoseidon); err != ErrNoRepresentativeProof {
+		t.Errorf("GenRepresentativesWithProofs() error = %v, want %v", err, ErrNoRepresentativeProof)
+	}
+}
@@ -0,0 +1,55 @@
+package accumulator
+
+import (
+	"math/big"
+)
+
+// The following functions implement the membership witness updates of
+// "Universal Accumulators with Efficient Nonmembership Proofs" (Li, Li and Xue).
+// A user only needs the own witness, the own representative, the representatives
+// added or deleted in an update and the new accumulator value.
+
+// UpdateWitnessOnAdd updates a membership witness after the representatives are added,
+// the new witness is witness^{x1*x2*...*xk} mod N
+func UpdateWitnessOnAdd(setup *Setup, witness *big.Int, added []*big.Int) *big.Int {
+	if len(added) == 0 {
+		return new(big.Int).Set(witness)
+	}
+	return AccumulateNew(witness, SetProductRecursiveFast(added), setup.N)
+}
+
+// UpdateWitnessOnDelete updates the membership witness of rep after the representatives are deleted.
+// With a*rep + b*prod(deleted) = 1, the new witness is witness^b * newAcc^a mod N.
+// ErrNotCoprime is returned if rep shares a factor with the deleted representatives, e.g. rep itself is deleted.
+func UpdateWitnessOnDelete(setup *Setup, witness, rep *big.Int, deleted []*big.Int, newAcc *big.Int) (*big.Int, error) {
+	return UpdateWitness(setup, witness, rep, nil, deleted, newAcc)
+}
+
+// UpdateWitness updates the membership witness of rep after one update that deletes and then adds representatives.
+// The accumulator between the deletion and the addition is not needed: with a*rep + b*prod(deleted) = 1,
+// the new witness is witness^{b*prod(added)} * newAcc^a mod N.
+func UpdateWitness(setup *Setup, witness, rep *big.Int, added, deleted []*big.Int, newAcc *big.Int) (*big.Int, error) {
+	if len(deleted) == 0 {
+		return UpdateWitnessOnAdd(setup, witness, added), nil
+	}
+	var gcd, a, b big.Int
+	gcd.GCD(&a, &b, rep, SetProductRecursiveFast(deleted))
+	if gcd.Cmp(big1) != 0 {
+		return nil, ErrNotCoprime
+	}
+	if len(added) > 0 {
+		b.Mul(&b, SetProductRecursiveFast(added))
+	}
+	// a or b is negative, Exp then uses the inverse
+	ret := new(big.Int).Exp(witness, &b, setup.N)
+	if ret == nil {
+		return nil, ErrNotCoprime
+	}
+	accPow := new(big.Int).Exp(newAcc, &a, setup.N)
+	if accPow == nil {
+		return nil, ErrNotCoprime
+	}
+	ret.Mul(ret, accPow)
+	ret.Mod(ret, setup.N)
+	return ret, nil
+}
@@ -0,0 +1,49 @@
+package accumulator
+
+import (
+	"testing"
+)
+
+func TestUpdateWitness(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(12)
+	reps := GenRepresentatives(set, HashToPrimeFromSha256)
+	// the old set is reps[:8], the user holds the witness of reps[0]
+	oldProofs := ProveMembership(setup.G, setup.N, reps[:8])
+	witness := oldProofs[0]
+
+	// add reps[8:10]
+	addedAcc := AccumulateNew(setup.G, SetProductRecursiveFast(reps[:10]), setup.N)
+	addedWitness := UpdateWitnessOnAdd(setup, witness, reps[8:10])
+	if !VerifyMembershipWithRep(setup, addedAcc, reps[0], HashToPrimeFromSha256, addedWitness) {
+		t.Errorf("witness updated after the addition is not valid")
+	}
+
+	// delete reps[5:8]
+	remaining := append(append(reps[:0:0], reps[:5]...), reps[8:10]...)
+	deletedAcc := AccumulateNew(setup.G, SetProductRecursiveFast(remaining), setup.N)
+	deletedWitness, err := UpdateWitnessOnDelete(setup, addedWitness, reps[0], reps[5:8], deletedAcc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyMembershipWithRep(setup, deletedAcc, reps[0], HashToPrimeFromSha256, deletedWitness) {
+		t.Errorf("witness updated after the deletion is not valid")
+	}
+
+	// delete reps[1:3] and add reps[10:] in one update, without the accumulator in between
+	remaining = append(append(reps[:0:0], reps[0]), reps[3:5]...)
+	remaining = append(remaining, reps[8:]...)
+	newAcc := AccumulateNew(setup.G, SetProductRecursiveFast(remaining), setup.N)
+	newWitness, err := UpdateWitness(setup, deletedWitness, reps[0], reps[10:], reps[1:3], newAcc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyMembershipWithRep(setup, newAcc, reps[0], HashToPrimeFromSha256, newWitness) {
+		t.Errorf("witness updated after the batch update is not valid")
+	}
+
+	// the witness of a deleted element cannot be updated
+	if _, err = UpdateWitnessOnDelete(setup, newWitness, reps[0], reps[0:1], newAcc); err != ErrNotCoprime {
+		t.Errorf("expected ErrNotCoprime when deleting the element itself, got %v", err)
+	}
+}
@@ -2,35 +2,76 @@ package accumulator
 
 import (
 	crand "crypto/rand"
-	"fmt"
 	"math/big"
-	"time"
+
+	"github.com/jiajunxin/rsa_accumulator/trace"
 )
 
 // GenRandomizer outputs random number uniformly between 0 to 2^2047
 func GenRandomizer() *big.Int {
-	ranNum, err := crand.Int(crand.Reader, Min2048)
+	ranNum, err := GenRandomizerWithError()
 	if err != nil {
 		panic(err)
 	}
 	return ranNum
 }
 
-// ZKAccumulate generates one accumulator which is zero-knowledge
-func ZKAccumulate(set []string, encodeType EncodeType, setup *Setup) (*big.Int, []*big.Int) {
-	startingTime := time.Now().UTC()
-	rep := GenRepresentatives(set, encodeType)
-	endingTime := time.Now().UTC()
-	var duration = endingTime.Sub(startingTime)
-	fmt.Printf("Running GenRepresentatives Takes [%.3f] Seconds \n",
-		duration.Seconds())
+// GenRandomizerWithError is GenRandomizer returning the error of the random source instead of panicking
+func GenRandomizerWithError() (*big.Int, error) {
+	return crand.Int(crand.Reader, Min2048)
+}
+
+// ZKAccumulate generates one accumulator which is zero-knowledge, it panics on an empty set,
+// an unknown EncodeType, an element the encoder cannot handle or a trapdoor of another modulus
+func ZKAccumulate(set []string, encodeType EncodeType, setup *Setup, opts ...trace.Option) (*big.Int, []*big.Int) {
+	acc, proofs, err := ZKAccumulateWithError(set, encodeType, setup, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return acc, proofs
+}
+
+// ZKAccumulateWithError is ZKAccumulate returning ErrInvalidInput for an empty set, ErrUnknownEncodeType,
+// the error of the encoder or of the random source, or ErrInvalidTrapdoor instead of panicking.
+// WithTrapdoor makes it TrapdoorSetup.ZKAccumulate.
+func ZKAccumulateWithError(set []string, encodeType EncodeType, setup *Setup, opts ...trace.Option) (*big.Int, []*big.Int, error) {
+	ts, err := trapdoorOf(setup.N, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return zkAccumulate(set, encodeType, setup, ts, opts...)
+}
+
+// zkAccumulate is ZKAccumulate with the exponentiations done with the trapdoor if it is not nil
+func zkAccumulate(set []string, encodeType EncodeType, setup *Setup, ts *TrapdoorSetup,
+	opts ...trace.Option) (*big.Int, []*big.Int, error) {
+	if len(set) == 0 {
+		return nil, nil, ErrInvalidInput
+	}
+	tracer := trace.NewOptions(opts...).Tracer
+	tracer.StartPhase(trace.PhaseGenRepresentatives)
+	rep, err := GenRepresentativesWithError(set, encodeType)
+	tracer.EndPhase(trace.PhaseGenRepresentatives)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	r := GenRandomizer()
-	base := AccumulateNew(setup.G, r, setup.N)
+	r, err := GenRandomizerWithError()
+	if err != nil {
+		return nil, nil, err
+	}
+	base := proverExp(setup, ts, setup.G, r)
 
-	proofs := ProveMembership(base, setup.N, rep)
+	tracer.StartPhase(trace.PhasePrecompute)
+	var proofs []*big.Int
+	if ts != nil {
+		proofs = ts.ProveMembership(base, rep)
+	} else {
+		proofs = ProveMembership(base, setup.N, rep)
+	}
+	tracer.EndPhase(trace.PhasePrecompute)
 	// we generate the accumulator by anyone of the membership proof raised to its power to save some calculation
-	acc := AccumulateNew(proofs[0], rep[0], setup.N)
+	acc := proverExp(setup, ts, proofs[0], rep[0])
 
-	return acc, proofs
+	return acc, proofs, nil
 }
@@ -0,0 +1,189 @@
+// Package ceremony generates RSA setups for the accumulator.
+// The modulus is the product of two safe primes, the generators G and H are derived
+// from public seeds so that anyone can check that nobody knows their discrete logarithms.
+// The setup is distributed as a signed file, the trapdoor phi(N) can be kept in a separate
+// secret file for operators who need fast deletions.
+package ceremony
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+)
+
+const (
+	// DefaultSeedG is the nothing-up-my-sleeve seed of the generator G
+	DefaultSeedG = "rsa_accumulator setup generator G"
+	// DefaultSeedH is the nothing-up-my-sleeve seed of the generator H
+	DefaultSeedH = "rsa_accumulator setup generator H"
+	// extra bits of the hash output before the reduction modulo N, making the result close to uniform
+	hashToGroupExtraBits = 128
+	// rounds of Miller-Rabin test for the safe primes, together with one Baillie-PSW test
+	primalityRounds = 20
+)
+
+var (
+	// ErrUnsupportedBits is returned for a modulus size other than 2048, 3072 or 4096 bits
+	ErrUnsupportedBits = errors.New("unsupported modulus size")
+	// ErrInvalidSeed is returned when the seeds of G and H are empty or equal
+	ErrInvalidSeed = errors.New("invalid generator seed")
+
+	big1 = big.NewInt(1)
+	big2 = big.NewInt(2)
+
+	// small odd primes for sieving the safe prime candidates
+	sievePrimes = []int64{3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47, 53, 59, 61, 67, 71, 73, 79, 83, 89, 97}
+)
+
+// Result is the output of a ceremony run
+type Result struct {
+	Setup *accumulator.Setup
+	// PhiN is the trapdoor, it must be destroyed or kept secret
+	PhiN  *big.Int
+	Bits  int
+	SeedG string
+	SeedH string
+}
+
+// Run generates a setup with a modulus of the given size, which must be 2048, 3072 or 4096 bits,
+// and the generators derived from the default seeds. Finding safe primes of 2048 bits can take hours.
+func Run(rand io.Reader, bits int) (*Result, error) {
+	return RunWithSeeds(rand, bits, DefaultSeedG, DefaultSeedH)
+}
+
+// RunWithSeeds is Run with the seeds of the generators chosen by the caller
+func RunWithSeeds(rand io.Reader, bits int, seedG, seedH string) (*Result, error) {
+	if bits != 2048 && bits != 3072 && bits != 4096 {
+		return nil, ErrUnsupportedBits
+	}
+	return run(rand, bits, seedG, seedH)
+}
+
+// run generates the setup without checking the modulus size
+func run(rand io.Reader, bits int, seedG, seedH string) (*Result, error) {
+	if seedG == "" || seedH == "" || seedG == seedH {
+		return nil, ErrInvalidSeed
+	}
+	var p, q, n *big.Int
+	for {
+		var err error
+		if p, err = SafePrime(rand, bits/2); err != nil {
+			return nil, err
+		}
+		if q, err = SafePrime(rand, bits-bits/2); err != nil {
+			return nil, err
+		}
+		n = new(big.Int).Mul(p, q)
+		if p.Cmp(q) != 0 && n.BitLen() == bits {
+			break
+		}
+	}
+	g, err := DeriveGenerator(n, seedG)
+	if err != nil {
+		return nil, err
+	}
+	h, err := DeriveGenerator(n, seedH)
+	if err != nil {
+		return nil, err
+	}
+	phiN := new(big.Int).Mul(new(big.Int).Sub(p, big1), new(big.Int).Sub(q, big1))
+	return &Result{
+		Setup: &accumulator.Setup{N: n, G: g, H: h},
+		PhiN:  phiN,
+		Bits:  bits,
+		SeedG: seedG,
+		SeedH: seedH,
+	}, nil
+}
+
+// SafePrime returns a prime p = 2p'+1 of the given bit length where p' is also a prime
+func SafePrime(rand io.Reader, bits int) (*big.Int, error) {
+	p := new(big.Int)
+	for {
+		pPrime, err := randPrime(rand, bits-1)
+		if err != nil {
+			return nil, err
+		}
+		if !safePrimeSieve(pPrime) {
+			continue
+		}
+		p.Lsh(pPrime, 1)
+		p.Add(p, big1)
+		if p.BitLen() == bits && p.ProbablyPrime(primalityRounds) {
+			return p, nil
+		}
+	}
+}
+
+// randPrime returns a random prime with the top bit set
+func randPrime(rand io.Reader, bits int) (*big.Int, error) {
+	if bits < 2 {
+		return nil, ErrUnsupportedBits
+	}
+	buf := make([]byte, (bits+7)/8)
+	p := new(big.Int)
+	for {
+		if _, err := io.ReadFull(rand, buf); err != nil {
+			return nil, err
+		}
+		p.SetBytes(buf)
+		// clear the bits above the length, then set the top bit and make p odd
+		for i := p.BitLen() - 1; i >= bits; i-- {
+			p.SetBit(p, i, 0)
+		}
+		p.SetBit(p, bits-1, 1)
+		p.SetBit(p, 0, 1)
+		if p.ProbablyPrime(primalityRounds) {
+			return p, nil
+		}
+	}
+}
+
+// safePrimeSieve returns false if 2p'+1 is divisible by a small prime r, i.e. p' = (r-1)/2 mod r.
+// It follows "Safe Prime Generation with a Combined Sieve".
+func safePrimeSieve(pPrime *big.Int) bool {
+	var r, rem big.Int
+	for _, v := range sievePrimes {
+		r.SetInt64(v)
+		rem.Mod(pPrime, &r)
+		if rem.Int64() == (v-1)/2 && pPrime.Cmp(&r) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// DeriveGenerator hashes the seed and the modulus into QR_N: the SHA256 counter-mode output
+// of length |N| + 128 bits is reduced modulo N and squared. Anyone can recompute the generator
+// from the public seed, so nobody knows its discrete logarithm to another generator.
+func DeriveGenerator(n *big.Int, seed string) (*big.Int, error) {
+	if seed == "" {
+		return nil, ErrInvalidSeed
+	}
+	outLen := (n.BitLen() + hashToGroupExtraBits + 7) / 8
+	var gcd big.Int
+	for attempt := uint32(0); ; attempt++ {
+		buf := make([]byte, 0, outLen+sha256.Size)
+		for counter := uint32(0); len(buf) < outLen; counter++ {
+			h := sha256.New()
+			var ctr [8]byte
+			binary.BigEndian.PutUint32(ctr[:4], attempt)
+			binary.BigEndian.PutUint32(ctr[4:], counter)
+			h.Write([]byte(seed))
+			h.Write(n.Bytes())
+			h.Write(ctr[:])
+			buf = h.Sum(buf)
+		}
+		ret := new(big.Int).SetBytes(buf[:outLen])
+		ret.Mod(ret, n)
+		ret.Exp(ret, big2, n)
+		gcd.GCD(nil, nil, ret, n)
+		if ret.Cmp(big1) > 0 && gcd.Cmp(big1) == 0 {
+			return ret, nil
+		}
+	}
+}
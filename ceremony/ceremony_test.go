@@ -0,0 +1,156 @@
+package ceremony
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+)
+
+const testBits = 256
+
+func testResult(t *testing.T) *Result {
+	t.Helper()
+	result, err := run(rand.Reader, testBits, DefaultSeedG, DefaultSeedH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func testKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestRun(t *testing.T) {
+	result := testResult(t)
+	n := result.Setup.N
+	if n.BitLen() != testBits {
+		t.Fatalf("modulus has %d bits, want %d", n.BitLen(), testBits)
+	}
+	// G and H are quadratic residues, so their order divides phi(N)/4
+	order := new(big.Int).Rsh(result.PhiN, 2)
+	for _, g := range []*big.Int{result.Setup.G, result.Setup.H} {
+		if new(big.Int).Exp(g, order, n).Cmp(big1) != 0 {
+			t.Errorf("generator %v is not in QR_N", g)
+		}
+	}
+	if result.Setup.G.Cmp(result.Setup.H) == 0 {
+		t.Error("G and H are equal")
+	}
+	if _, err := Run(rand.Reader, testBits); !errors.Is(err, ErrUnsupportedBits) {
+		t.Errorf("Run(%d) error = %v, want %v", testBits, err, ErrUnsupportedBits)
+	}
+	if _, err := run(rand.Reader, testBits, "seed", "seed"); !errors.Is(err, ErrInvalidSeed) {
+		t.Errorf("run with equal seeds error = %v, want %v", err, ErrInvalidSeed)
+	}
+}
+
+func TestSafePrime(t *testing.T) {
+	p, err := SafePrime(rand.Reader, 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pPrime := new(big.Int).Rsh(p, 1)
+	if p.BitLen() != 128 || !p.ProbablyPrime(20) || !pPrime.ProbablyPrime(20) {
+		t.Errorf("%v is not a 128-bit safe prime", p)
+	}
+}
+
+func TestDeriveGenerator(t *testing.T) {
+	n := testResult(t).Setup.N
+	g1, err := DeriveGenerator(n, DefaultSeedG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g2, err := DeriveGenerator(n, DefaultSeedG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g1.Cmp(g2) != 0 {
+		t.Error("DeriveGenerator is not deterministic")
+	}
+	if _, err = DeriveGenerator(n, ""); !errors.Is(err, ErrInvalidSeed) {
+		t.Errorf("DeriveGenerator with empty seed error = %v, want %v", err, ErrInvalidSeed)
+	}
+}
+
+func TestSetupFile(t *testing.T) {
+	result := testResult(t)
+	f, err := result.Sign(testKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "setup.json")
+	if err = WriteSetupFile(path, f); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadSetupFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Setup.N.Cmp(f.Setup.N) != 0 || got.Setup.G.Cmp(f.Setup.G) != 0 || got.Setup.H.Cmp(f.Setup.H) != 0 {
+		t.Error("setup file does not round trip")
+	}
+
+	tampered := *f
+	tampered.SeedG = "another seed"
+	if err = tampered.Verify(); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("tampered seed error = %v, want %v", err, ErrInvalidSignature)
+	}
+	// a valid signature over a G that is not derived from the seed
+	resigned := *result
+	resigned.SeedG = "another seed"
+	bad, err := resigned.Sign(testKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = bad.Verify(); !errors.Is(err, ErrGeneratorMismatch) {
+		t.Errorf("mismatched generator error = %v, want %v", err, ErrGeneratorMismatch)
+	}
+}
+
+func TestTrapdoorFile(t *testing.T) {
+	result := testResult(t)
+	path := filepath.Join(t.TempDir(), "trapdoor.json")
+	// an existing readable file is restricted to the owner
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTrapdoorFile(path, result.Setup, result.PhiN); err != nil {
+		t.Fatal(err)
+	}
+	phiN, err := ReadTrapdoorFile(path, result.Setup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if phiN.Cmp(result.PhiN) != 0 {
+		t.Errorf("ReadTrapdoorFile() = %v, want %v", phiN, result.PhiN)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("trapdoor file mode = %v, want %v", info.Mode().Perm(), os.FileMode(0600))
+	}
+	other := testResult(t)
+	if _, err = ReadTrapdoorFile(path, other.Setup); !errors.Is(err, ErrTrapdoorMismatch) {
+		t.Errorf("ReadTrapdoorFile() with another setup error = %v, want %v", err, ErrTrapdoorMismatch)
+	}
+	if err = WriteTrapdoorFile(path, result.Setup, new(big.Int).Sub(result.PhiN, big.NewInt(2))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ReadTrapdoorFile(path, result.Setup); !errors.Is(err, accumulator.ErrInvalidTrapdoor) {
+		t.Errorf("ReadTrapdoorFile() with a wrong phi(N) error = %v, want %v", err, accumulator.ErrInvalidTrapdoor)
+	}
+}
@@ -0,0 +1,233 @@
+package ceremony
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+	"github.com/jiajunxin/rsa_accumulator/codec"
+)
+
+var (
+	// ErrInvalidSignature is returned when the signature of a setup file does not verify
+	ErrInvalidSignature = errors.New("invalid setup signature")
+	// ErrGeneratorMismatch is returned when G or H is not derived from the seeds in the setup file
+	ErrGeneratorMismatch = errors.New("generator not derived from the seed")
+	// ErrTrapdoorMismatch is returned when the trapdoor file belongs to another modulus
+	ErrTrapdoorMismatch = errors.New("trapdoor does not match the modulus")
+)
+
+// SetupFile is the signed public output of a ceremony
+type SetupFile struct {
+	Setup     *accumulator.Setup
+	Bits      int
+	SeedG     string
+	SeedH     string
+	PublicKey ed25519.PublicKey
+	Signature []byte
+}
+
+type setupFileJSON struct {
+	codec.Header
+	Setup     *accumulator.Setup `json:"setup"`
+	Bits      int                `json:"bits"`
+	SeedG     string             `json:"seedG"`
+	SeedH     string             `json:"seedH"`
+	PublicKey string             `json:"publicKey"`
+	Signature string             `json:"signature"`
+}
+
+type trapdoorJSON struct {
+	codec.Header
+	N    string `json:"n"`
+	PhiN string `json:"phiN"`
+}
+
+// Sign signs the public part of the result with the key of the ceremony operator
+func (r *Result) Sign(key ed25519.PrivateKey) (*SetupFile, error) {
+	f := &SetupFile{
+		Setup:     r.Setup,
+		Bits:      r.Bits,
+		SeedG:     r.SeedG,
+		SeedH:     r.SeedH,
+		PublicKey: key.Public().(ed25519.PublicKey),
+	}
+	msg, err := f.message()
+	if err != nil {
+		return nil, err
+	}
+	f.Signature = ed25519.Sign(key, msg)
+	return f, nil
+}
+
+// message returns the signed bytes: the binary encodings of the setup followed by the bit length and the seeds
+func (f *SetupFile) message() ([]byte, error) {
+	setupBytes, err := f.Setup.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	enc := codec.NewEncoder(codec.TypeSetupFile)
+	enc.PutBytes(setupBytes)
+	enc.PutUint32(uint32(f.Bits))
+	enc.PutString(f.SeedG)
+	enc.PutString(f.SeedH)
+	return enc.Bytes(), nil
+}
+
+// Verify checks the signature, the size of the modulus and that G and H are derived from the seeds.
+// The caller still needs to check that PublicKey belongs to a trusted operator.
+func (f *SetupFile) Verify() error {
+	if f.Setup == nil || len(f.PublicKey) != ed25519.PublicKeySize {
+		return ErrInvalidSignature
+	}
+	msg, err := f.message()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(f.PublicKey, msg, f.Signature) {
+		return ErrInvalidSignature
+	}
+	if f.Setup.N.BitLen() != f.Bits {
+		return ErrUnsupportedBits
+	}
+	for _, v := range []struct {
+		generator *big.Int
+		seed      string
+	}{{f.Setup.G, f.SeedG}, {f.Setup.H, f.SeedH}} {
+		expected, err := DeriveGenerator(f.Setup.N, v.seed)
+		if err != nil {
+			return err
+		}
+		if expected.Cmp(v.generator) != 0 {
+			return ErrGeneratorMismatch
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the setup file as JSON with hex integers
+func (f *SetupFile) MarshalJSON() ([]byte, error) {
+	return json.Marshal(setupFileJSON{
+		Header:    codec.NewHeader("SetupFile"),
+		Setup:     f.Setup,
+		Bits:      f.Bits,
+		SeedG:     f.SeedG,
+		SeedH:     f.SeedH,
+		PublicKey: hex.EncodeToString(f.PublicKey),
+		Signature: hex.EncodeToString(f.Signature),
+	})
+}
+
+// UnmarshalJSON decodes the setup file encoded by MarshalJSON, the signature is not checked
+func (f *SetupFile) UnmarshalJSON(data []byte) error {
+	var v setupFileJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.Check("SetupFile"); err != nil {
+		return err
+	}
+	if v.Setup == nil {
+		return codec.ErrInvalidEncoding
+	}
+	publicKey, err := hex.DecodeString(v.PublicKey)
+	if err != nil {
+		return codec.ErrInvalidHex
+	}
+	signature, err := hex.DecodeString(v.Signature)
+	if err != nil {
+		return codec.ErrInvalidHex
+	}
+	*f = SetupFile{
+		Setup:     v.Setup,
+		Bits:      v.Bits,
+		SeedG:     v.SeedG,
+		SeedH:     v.SeedH,
+		PublicKey: publicKey,
+		Signature: signature,
+	}
+	return nil
+}
+
+// WriteSetupFile writes the signed setup as JSON
+func WriteSetupFile(path string, f *SetupFile) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// ReadSetupFile reads and verifies the signed setup
+func ReadSetupFile(path string) (*SetupFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f SetupFile
+	if err = json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	if err = f.Verify(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// WriteTrapdoorFile writes phi(N) into a file only readable by the owner, it must not be distributed with the setup.
+// An existing file is truncated and its permissions are restricted to the owner before phi(N) is written.
+func WriteTrapdoorFile(path string, setup *accumulator.Setup, phiN *big.Int) error {
+	data, err := json.MarshalIndent(trapdoorJSON{
+		Header: codec.NewHeader("Trapdoor"),
+		N:      codec.EncodeHex(setup.N),
+		PhiN:   codec.EncodeHex(phiN),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	// the mode of OpenFile only applies to a new file
+	if err = f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadTrapdoorFile reads phi(N) of the setup, ErrTrapdoorMismatch is returned if the file belongs to another modulus
+// and accumulator.ErrInvalidTrapdoor if phi(N) does not factor the modulus
+func ReadTrapdoorFile(path string, setup *accumulator.Setup) (*big.Int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var v trapdoorJSON
+	if err = json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	if err = v.Check("Trapdoor"); err != nil {
+		return nil, err
+	}
+	values, err := codec.DecodeNatHexList(v.N, v.PhiN)
+	if err != nil {
+		return nil, err
+	}
+	if values[0].Cmp(setup.N) != 0 || values[1].Sign() <= 0 || values[1].Cmp(setup.N) >= 0 {
+		return nil, ErrTrapdoorMismatch
+	}
+	if _, err = accumulator.NewTrapdoorSetupFromPhi(setup, values[1]); err != nil {
+		return nil, err
+	}
+	return values[1], nil
+}
@@ -0,0 +1,188 @@
+// Package client lets a user of Notus check that the balance the exchange owes them is included in the latest
+// accumulator. The exchange gives the user their leaf and its membership witness, and publishes the EpochReports.
+// The client recomputes the DI hash of the leaf, checks the witness against the latest accumulator and verifies
+// the reports from the epoch of the leaf on, so that the latest accumulator is reached by proven updates only.
+package client
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+	"github.com/jiajunxin/rsa_accumulator/notus"
+	"github.com/jiajunxin/rsa_accumulator/store"
+	"github.com/jiajunxin/rsa_accumulator/trace"
+	"github.com/jiajunxin/rsa_accumulator/zkmultiswap"
+)
+
+var (
+	// ErrUserMismatch is returned when the leaf belongs to another user
+	ErrUserMismatch = errors.New("leaf of another user")
+	// ErrBalanceMismatch is returned when the leaf has another balance than the one expected by the user
+	ErrBalanceMismatch = errors.New("leaf has another balance")
+	// ErrNotMember is returned when the witness does not prove the membership of the leaf in the latest accumulator
+	ErrNotMember = errors.New("leaf is not in the accumulator")
+	// ErrNoReports is returned when no report is given
+	ErrNoReports = errors.New("no epoch reports")
+	// ErrEpochNotCovered is returned when the reports start after the epoch of the leaf
+	ErrEpochNotCovered = errors.New("reports do not cover the epoch of the leaf")
+	// ErrBrokenChain is returned when a report does not continue the previous one
+	ErrBrokenChain = notus.ErrBrokenChain
+	// ErrUnknownUser is returned when the state has no leaf of the user
+	ErrUnknownUser = errors.New("unknown user")
+	// ErrNoInclusion is returned when no inclusion is given
+	ErrNoInclusion = errors.New("no inclusion")
+)
+
+// Check names the part of the verification that failed
+type Check string
+
+const (
+	// CheckLeaf compares the leaf with the user and the balance expected by the user
+	CheckLeaf Check = "leaf"
+	// CheckMembership verifies the witness of the leaf against the latest accumulator
+	CheckMembership Check = "membership"
+	// CheckChain checks that the reports are consecutive epochs, Acc_old of each being Acc_new of the previous one
+	CheckChain Check = "chain"
+	// CheckReport verifies the transcript, the PoKE proofs and the zkmultiswap proof of one report
+	CheckReport Check = "report"
+)
+
+// Failure is one failed check. Epoch is the epoch of the report for CheckChain and CheckReport,
+// the latest epoch otherwise, 0 if the reports are missing.
+type Failure struct {
+	Check Check
+	Epoch uint32
+	Err   error
+}
+
+// Error returns the failure as text
+func (f *Failure) Error() string {
+	return fmt.Sprintf("%s check of epoch %d: %v", f.Check, f.Epoch, f.Err)
+}
+
+// Unwrap returns the reason of the failure
+func (f *Failure) Unwrap() error {
+	return f.Err
+}
+
+// Verdict is the result of VerifyInclusion. All checks are run, Failures lists every one that failed.
+type Verdict struct {
+	UserID  uint32
+	Balance uint32
+	// Epoch is the latest epoch of the reports, the one the inclusion is checked for
+	Epoch    uint32
+	Failures []Failure
+}
+
+// OK returns true if all checks passed
+func (v *Verdict) OK() bool {
+	return len(v.Failures) == 0
+}
+
+// Err returns the first failure, nil if all checks passed
+func (v *Verdict) Err() error {
+	if v.OK() {
+		return nil
+	}
+	return &v.Failures[0]
+}
+
+func (v *Verdict) fail(check Check, epoch uint32, err error) {
+	v.Failures = append(v.Failures, Failure{Check: check, Epoch: epoch, Err: err})
+}
+
+// Inclusion is what the exchange gives a user to check their balance: the leaf and its witness in the latest accumulator
+type Inclusion struct {
+	Leaf    zkmultiswap.Leaf
+	Witness *big.Int
+}
+
+// InclusionFromState returns the inclusion of the user in the state, for the exchange to hand out
+func InclusionFromState(state *store.State, userID uint32) (*Inclusion, error) {
+	idx := state.Index(userID)
+	if idx < 0 {
+		return nil, ErrUnknownUser
+	}
+	leaf := &state.Leaves[idx]
+	ret := &Inclusion{
+		Leaf:    zkmultiswap.Leaf{UserID: leaf.UserID, Balance: leaf.Balance, Epoch: leaf.Epoch},
+		Witness: new(big.Int).Set(state.Witnesses[idx]),
+	}
+	ret.Leaf.PrevHash.Set(&leaf.PrevHash)
+	return ret, nil
+}
+
+// Verifier checks inclusions against published reports
+type Verifier struct {
+	setup *accumulator.Setup
+	opts  []trace.Option
+}
+
+// NewVerifier returns a verifier for the setup, the options are passed to the SNARK verifier.
+// The verifying keys of the zkmultiswap circuits are read from the working directory.
+func NewVerifier(setup *accumulator.Setup, opts ...trace.Option) *Verifier {
+	return &Verifier{setup: setup, opts: opts}
+}
+
+// VerifyInclusion checks that the user with the balance is included in the accumulator of the last report.
+// The reports are consecutive epochs as checked by notus.CheckLink, the first one no later than the epoch of the leaf.
+// Without reports, or with a nil one, the verdict has only that failure, and without an inclusion only the
+// failures of the reports.
+func (v *Verifier) VerifyInclusion(userID, balance uint32, inclusion *Inclusion, reports []*notus.EpochReport) *Verdict {
+	verdict := &Verdict{UserID: userID, Balance: balance}
+	if len(reports) == 0 {
+		verdict.fail(CheckChain, 0, ErrNoReports)
+		return verdict
+	}
+	for _, report := range reports {
+		if report == nil {
+			verdict.fail(CheckReport, 0, notus.ErrInvalidReport)
+			return verdict
+		}
+	}
+	latest := reports[len(reports)-1]
+	verdict.Epoch = latest.Epoch
+
+	if inclusion == nil {
+		verdict.fail(CheckLeaf, latest.Epoch, ErrNoInclusion)
+	} else {
+		v.checkLeaf(verdict, userID, balance, inclusion, reports)
+	}
+
+	for i, report := range reports {
+		var prev *notus.EpochReport
+		if i > 0 {
+			prev = reports[i-1]
+		}
+		if err := notus.CheckLink(prev, report); err != nil {
+			verdict.fail(CheckChain, report.Epoch, err)
+		}
+		if err := report.Verify(v.setup, v.opts...); err != nil {
+			verdict.fail(CheckReport, report.Epoch, err)
+		}
+	}
+	return verdict
+}
+
+// checkLeaf compares the leaf with the user and the balance, and checks its membership in the latest accumulator
+func (v *Verifier) checkLeaf(verdict *Verdict, userID, balance uint32, inclusion *Inclusion, reports []*notus.EpochReport) {
+	latest := reports[len(reports)-1]
+	leaf := &inclusion.Leaf
+	if leaf.UserID != userID {
+		verdict.fail(CheckLeaf, latest.Epoch, ErrUserMismatch)
+	}
+	if leaf.Balance != balance {
+		verdict.fail(CheckLeaf, latest.Epoch, ErrBalanceMismatch)
+	}
+	if leaf.Epoch > latest.Epoch || leaf.Epoch < reports[0].Epoch {
+		verdict.fail(CheckChain, latest.Epoch, ErrEpochNotCovered)
+	}
+
+	// the DI hash of the leaf and its membership in the latest accumulator
+	if !accumulator.VerifyMembershipWithRep(v.setup, latest.AccNew, leaf.Representative(), accumulator.DIHashFromPoseidon,
+		inclusion.Witness) {
+		verdict.fail(CheckMembership, latest.Epoch, ErrNotMember)
+	}
+}
@@ -0,0 +1,184 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+	"github.com/jiajunxin/rsa_accumulator/notus"
+	"github.com/jiajunxin/rsa_accumulator/store"
+	"github.com/jiajunxin/rsa_accumulator/zkmultiswap"
+)
+
+const testBatchSize = 2
+
+// TestMain generates the SNARK keys of testBatchSize in a temporary directory, the keys are read from the working directory
+func TestMain(m *testing.M) {
+	os.Exit(runInKeyDir(m))
+}
+
+func runInKeyDir(m *testing.M) int {
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	dir, err := os.MkdirTemp("", "client")
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+	if err = os.Chdir(dir); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	defer os.Chdir(wd)
+	if err = zkmultiswap.SetupZkMultiswapWithError(testBatchSize); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	return m.Run()
+}
+
+// genEpochs runs the genesis of the users 1 to 4 and two epochs, it returns the reports and the final state
+func genEpochs(t *testing.T, setup *accumulator.Setup) ([]*notus.EpochReport, *store.State) {
+	t.Helper()
+	s := store.NewMemoryStore(store.NewState(setup))
+	m, err := notus.NewEpochManager(&notus.Config{Setup: setup, Store: s, BatchSize: testBatchSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis, err := m.Genesis([]notus.Change{{UserID: 1, Balance: 10}, {UserID: 2, Balance: 20}, {UserID: 3, Balance: 30}, {UserID: 4, Balance: 40}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports := []*notus.EpochReport{genesis}
+	for _, changes := range [][]notus.Change{
+		{{UserID: 2, Balance: 25}, {UserID: 3, Balance: 35}},
+		{{UserID: 3, Balance: 5}, {UserID: 4, Balance: 45}},
+	} {
+		report, err := m.Advance(changes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, s.State()
+}
+
+// hasFailure returns true if the verdict has a failure of the check with the reason
+func hasFailure(v *Verdict, check Check, err error) bool {
+	for _, f := range v.Failures {
+		if f.Check == check && errors.Is(f.Err, err) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestVerifyInclusion(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	reports, state := genEpochs(t, setup)
+	v := NewVerifier(setup)
+
+	for _, tc := range []struct {
+		userID, balance uint32
+		first           int // index of the first report given to the user
+	}{
+		{1, 10, 0}, // leaf of the genesis epoch
+		{2, 25, 1},
+		{3, 5, 2},
+		{4, 45, 0},
+	} {
+		inclusion, err := InclusionFromState(state, tc.userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		verdict := v.VerifyInclusion(tc.userID, tc.balance, inclusion, reports[tc.first:])
+		if !verdict.OK() || verdict.Epoch != 3 {
+			t.Errorf("user %d: verdict of epoch %d: %v", tc.userID, verdict.Epoch, verdict.Err())
+		}
+	}
+	if _, err := InclusionFromState(state, 5); err != ErrUnknownUser {
+		t.Errorf("InclusionFromState() of an unknown user = %v, want %v", err, ErrUnknownUser)
+	}
+}
+
+func TestVerifyInclusionFailures(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	reports, state := genEpochs(t, setup)
+	v := NewVerifier(setup)
+	inclusion, err := InclusionFromState(state, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verdict := v.VerifyInclusion(3, 6, inclusion, reports[2:])
+	if verdict.OK() || !hasFailure(verdict, CheckLeaf, ErrBalanceMismatch) || len(verdict.Failures) != 1 {
+		t.Errorf("another balance: %v", verdict.Failures)
+	}
+	verdict = v.VerifyInclusion(4, 5, inclusion, reports[2:])
+	if !hasFailure(verdict, CheckLeaf, ErrUserMismatch) || len(verdict.Failures) != 1 {
+		t.Errorf("another user: %v", verdict.Failures)
+	}
+
+	// the leaf of user 3 of epoch 2 is no longer in the accumulator
+	stale := *inclusion
+	stale.Leaf.Balance, stale.Leaf.Epoch = 35, 2
+	verdict = v.VerifyInclusion(3, 35, &stale, reports[1:])
+	if !hasFailure(verdict, CheckMembership, ErrNotMember) {
+		t.Errorf("old leaf: %v", verdict.Failures)
+	}
+	wrong := *inclusion
+	wrong.Witness = new(big.Int).Add(inclusion.Witness, big.NewInt(1))
+	verdict = v.VerifyInclusion(3, 5, &wrong, reports[2:])
+	if !hasFailure(verdict, CheckMembership, ErrNotMember) {
+		t.Errorf("wrong witness: %v", verdict.Failures)
+	}
+
+	genesisLeaf, err := InclusionFromState(state, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verdict = v.VerifyInclusion(1, 10, genesisLeaf, reports[1:])
+	if len(verdict.Failures) != 1 || !hasFailure(verdict, CheckChain, ErrEpochNotCovered) {
+		t.Errorf("reports after the leaf: %v", verdict.Failures)
+	}
+	verdict = v.VerifyInclusion(3, 5, inclusion, []*notus.EpochReport{reports[0], reports[2]})
+	if !hasFailure(verdict, CheckChain, ErrBrokenChain) {
+		t.Errorf("missing epoch: %v", verdict.Failures)
+	}
+	verdict = v.VerifyInclusion(3, 5, inclusion, nil)
+	if !hasFailure(verdict, CheckChain, ErrNoReports) {
+		t.Errorf("no reports: %v", verdict.Failures)
+	}
+	verdict = v.VerifyInclusion(3, 5, inclusion, []*notus.EpochReport{reports[1], nil})
+	if len(verdict.Failures) != 1 || !hasFailure(verdict, CheckReport, notus.ErrInvalidReport) {
+		t.Errorf("nil report: %v", verdict.Failures)
+	}
+	verdict = v.VerifyInclusion(3, 5, nil, reports[2:])
+	if len(verdict.Failures) != 1 || !hasFailure(verdict, CheckLeaf, ErrNoInclusion) || verdict.Epoch != 3 {
+		t.Errorf("nil inclusion: %v", verdict.Failures)
+	}
+	// a genesis report of another epoch is rejected like by notus.ChainVerifier
+	genesis := *reports[0]
+	genesis.Epoch = 2
+	verdict = v.VerifyInclusion(3, 5, inclusion, []*notus.EpochReport{&genesis, reports[2]})
+	if !hasFailure(verdict, CheckChain, notus.ErrMissingGenesis) {
+		t.Errorf("genesis of epoch 2: %v", verdict.Failures)
+	}
+
+	tampered := *reports[2]
+	tampered.QNew = new(big.Int).Set(tampered.QOld)
+	verdict = v.VerifyInclusion(3, 5, inclusion, []*notus.EpochReport{reports[1], &tampered})
+	if len(verdict.Failures) != 1 || !hasFailure(verdict, CheckReport, notus.ErrInvalidPoKE) || verdict.Failures[0].Epoch != 3 {
+		t.Errorf("tampered report: %v", verdict.Failures)
+	}
+	if !errors.Is(verdict.Err(), notus.ErrInvalidPoKE) {
+		t.Errorf("Err() = %v, want %v", verdict.Err(), notus.ErrInvalidPoKE)
+	}
+}
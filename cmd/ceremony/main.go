@@ -0,0 +1,90 @@
+// Command ceremony generates a signed RSA setup for the accumulator.
+//
+//	ceremony -bits 2048 -key operator.key -out setup.json -trapdoor trapdoor.json
+//
+// The key file holds the ed25519 seed of the operator and is created if it does not exist.
+// The trapdoor file is only written if -trapdoor is set.
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/jiajunxin/rsa_accumulator/ceremony"
+)
+
+func main() {
+	bits := flag.Int("bits", 2048, "bit length of the modulus: 2048, 3072 or 4096")
+	out := flag.String("out", "setup.json", "path of the signed setup file")
+	trapdoor := flag.String("trapdoor", "", "path of the secret trapdoor file, phi(N) is discarded if empty")
+	keyPath := flag.String("key", "operator.key", "path of the ed25519 seed of the operator")
+	verify := flag.Bool("verify", false, "only verify the setup file given by -out")
+	flag.Parse()
+
+	if err := run(*bits, *out, *trapdoor, *keyPath, *verify); err != nil {
+		fmt.Fprintln(os.Stderr, "ceremony:", err)
+		os.Exit(1)
+	}
+}
+
+func run(bits int, out, trapdoor, keyPath string, verify bool) error {
+	if verify {
+		f, err := ceremony.ReadSetupFile(out)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s is a valid %d-bit setup signed by %x\n", out, f.Bits, []byte(f.PublicKey))
+		return nil
+	}
+	key, err := loadOrCreateKey(keyPath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Generating a %d-bit modulus, this can take a long time\n", bits)
+	result, err := ceremony.Run(rand.Reader, bits)
+	if err != nil {
+		return err
+	}
+	f, err := result.Sign(key)
+	if err != nil {
+		return err
+	}
+	if err = ceremony.WriteSetupFile(out, f); err != nil {
+		return err
+	}
+	fmt.Println("Setup written to", out)
+	if trapdoor != "" {
+		if err = ceremony.WriteTrapdoorFile(trapdoor, result.Setup, result.PhiN); err != nil {
+			return err
+		}
+		fmt.Println("Trapdoor written to", trapdoor)
+	}
+	result.PhiN.SetInt64(0)
+	return nil
+}
+
+// loadOrCreateKey reads the ed25519 seed of the operator, a new seed is generated if the file does not exist
+func loadOrCreateKey(path string) (ed25519.PrivateKey, error) {
+	seed, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		seed = make([]byte, ed25519.SeedSize)
+		if _, err = rand.Read(seed); err != nil {
+			return nil, err
+		}
+		if err = os.WriteFile(path, seed, 0600); err != nil {
+			return nil, err
+		}
+		fmt.Println("New operator key written to", path)
+	} else if err != nil {
+		return nil, err
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%s: invalid ed25519 seed length %d", path, len(seed))
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
@@ -0,0 +1,269 @@
+// Package codec implements the versioned binary encoding shared by the setups, accumulators and proofs.
+// Every encoding starts with a version byte and a type byte. Big integers are encoded as a sign byte,
+// a 4-byte big-endian length and the big-endian magnitude without leading zeros. Decoding is strict,
+// any non-canonical input is rejected so that every value has exactly one encoding.
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// Version is the current version of the binary encoding
+const Version byte = 1
+
+// Type tags of the encoded objects
+const (
+	TypeSetup byte = iota + 1
+	TypeAccumulator
+	TypeBatchMembershipProof
+	TypeNonMembershipWitness
+	TypePoKEStarProof
+	TypeZKPoKEProof
+	TypePoEProof
+	TypeRangeProof
+	TypeArgOfPositivity
+	TypeSetupFile
+	TypeStoreSnapshot
+	TypeStoreUpdate
+	TypeEpochReport
+)
+
+const (
+	signPositive byte = 0
+	signNegative byte = 1
+	lengthSize        = 4
+)
+
+var (
+	// ErrInvalidEncoding is returned when decoding malformed or non-canonical bytes
+	ErrInvalidEncoding = errors.New("invalid encoding")
+	// ErrUnsupportedVersion is returned when decoding bytes of an unknown encoding version
+	ErrUnsupportedVersion = errors.New("unsupported encoding version")
+	// ErrWrongType is returned when decoding bytes of another object type
+	ErrWrongType = errors.New("wrong encoded type")
+	// ErrNotReduced is returned when a group element is not reduced modulo N
+	ErrNotReduced = errors.New("value not reduced modulo N")
+)
+
+// Encoder appends values to a byte slice
+type Encoder struct {
+	buf []byte
+}
+
+// NewEncoder returns an encoder with the header of the type
+func NewEncoder(typ byte) *Encoder {
+	return &Encoder{buf: []byte{Version, typ}}
+}
+
+// Bytes returns the encoded bytes
+func (e *Encoder) Bytes() []byte {
+	return e.buf
+}
+
+// PutUint8 appends one byte
+func (e *Encoder) PutUint8(v uint8) {
+	e.buf = append(e.buf, v)
+}
+
+// PutUint32 appends a 4-byte big-endian integer
+func (e *Encoder) PutUint32(v uint32) {
+	var b [lengthSize]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	e.buf = append(e.buf, b[:]...)
+}
+
+// PutBytes appends the length-prefixed bytes
+func (e *Encoder) PutBytes(b []byte) {
+	e.PutUint32(uint32(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// PutString appends the length-prefixed string
+func (e *Encoder) PutString(s string) {
+	e.PutBytes([]byte(s))
+}
+
+// PutBigInt appends the sign and the length-prefixed magnitude of x, nil is not allowed
+func (e *Encoder) PutBigInt(x *big.Int) {
+	if x.Sign() < 0 {
+		e.PutUint8(signNegative)
+	} else {
+		e.PutUint8(signPositive)
+	}
+	e.PutBytes(x.Bytes())
+}
+
+// PutBigInts appends the number of integers followed by the integers
+func (e *Encoder) PutBigInts(xs []*big.Int) {
+	e.PutUint32(uint32(len(xs)))
+	for _, x := range xs {
+		e.PutBigInt(x)
+	}
+}
+
+// Decoder reads values from a byte slice. The first error is kept and all later reads return zero values,
+// so the caller only checks Finish.
+type Decoder struct {
+	data []byte
+	err  error
+}
+
+// NewDecoder checks the header of the data and returns a decoder for the rest
+func NewDecoder(data []byte, typ byte) *Decoder {
+	d := &Decoder{data: data}
+	if len(data) < 2 {
+		d.err = ErrInvalidEncoding
+		return d
+	}
+	if data[0] != Version {
+		d.err = ErrUnsupportedVersion
+		return d
+	}
+	if data[1] != typ {
+		d.err = ErrWrongType
+		return d
+	}
+	d.data = data[2:]
+	return d
+}
+
+// Err returns the first error during decoding
+func (d *Decoder) Err() error {
+	return d.err
+}
+
+// Fail records an error found by the caller, e.g. a failed range check
+func (d *Decoder) Fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+// Finish returns the first error during decoding, or ErrInvalidEncoding if there are trailing bytes
+func (d *Decoder) Finish() error {
+	if d.err == nil && len(d.data) != 0 {
+		d.err = ErrInvalidEncoding
+	}
+	return d.err
+}
+
+// next returns the next n bytes
+func (d *Decoder) next(n uint64) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if uint64(len(d.data)) < n {
+		d.err = ErrInvalidEncoding
+		return nil
+	}
+	ret := d.data[:n]
+	d.data = d.data[n:]
+	return ret
+}
+
+// Uint8 reads one byte
+func (d *Decoder) Uint8() uint8 {
+	b := d.next(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+// Uint32 reads a 4-byte big-endian integer
+func (d *Decoder) Uint32() uint32 {
+	b := d.next(lengthSize)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+// Bytes reads length-prefixed bytes, the returned slice is a copy
+func (d *Decoder) Bytes() []byte {
+	length := d.Uint32()
+	b := d.next(uint64(length))
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+// FixedBytes reads length-prefixed bytes and checks the length
+func (d *Decoder) FixedBytes(size int) []byte {
+	b := d.Bytes()
+	if d.err == nil && len(b) != size {
+		d.err = ErrInvalidEncoding
+	}
+	return b
+}
+
+// String reads a length-prefixed string
+func (d *Decoder) String() string {
+	return string(d.Bytes())
+}
+
+// BigInt reads a signed integer, rejecting leading zeros and negative zero
+func (d *Decoder) BigInt() *big.Int {
+	sign := d.Uint8()
+	mag := d.Bytes()
+	if d.err != nil {
+		return nil
+	}
+	if sign > signNegative || (len(mag) > 0 && mag[0] == 0) || (len(mag) == 0 && sign == signNegative) {
+		d.err = ErrInvalidEncoding
+		return nil
+	}
+	ret := new(big.Int).SetBytes(mag)
+	if sign == signNegative {
+		ret.Neg(ret)
+	}
+	return ret
+}
+
+// Nat reads a non-negative integer
+func (d *Decoder) Nat() *big.Int {
+	ret := d.BigInt()
+	if ret != nil && ret.Sign() < 0 {
+		d.err = ErrInvalidEncoding
+		return nil
+	}
+	return ret
+}
+
+// Element reads a non-negative integer reduced modulo n
+func (d *Decoder) Element(n *big.Int) *big.Int {
+	ret := d.Nat()
+	if ret != nil && ret.Cmp(n) >= 0 {
+		d.err = ErrNotReduced
+		return nil
+	}
+	return ret
+}
+
+// BigInts reads integers encoded by PutBigInts
+func (d *Decoder) BigInts() []*big.Int {
+	count := d.Uint32()
+	// every integer takes at least 5 bytes, do not trust the count for the allocation
+	if d.err != nil || uint64(count)*(lengthSize+1) > uint64(len(d.data)) {
+		d.Fail(ErrInvalidEncoding)
+		return nil
+	}
+	ret := make([]*big.Int, count)
+	for i := range ret {
+		ret[i] = d.BigInt()
+	}
+	return ret
+}
+
+// CheckReduced returns ErrNotReduced if any of the values is negative or not smaller than n
+func CheckReduced(n *big.Int, values ...*big.Int) error {
+	for _, v := range values {
+		if v == nil || v.Sign() < 0 || v.Cmp(n) >= 0 {
+			return ErrNotReduced
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package codec
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBigInt(t *testing.T) {
+	values := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(-1), new(big.Int).Lsh(big.NewInt(-3), 2000)}
+	enc := NewEncoder(TypePoEProof)
+	enc.PutBigInts(values)
+	dec := NewDecoder(enc.Bytes(), TypePoEProof)
+	decoded := dec.BigInts()
+	if err := dec.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	for i := range values {
+		if decoded[i].Cmp(values[i]) != 0 {
+			t.Errorf("got %s, want %s", decoded[i], values[i])
+		}
+	}
+}
+
+func TestStrictDecoding(t *testing.T) {
+	testCases := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", nil, ErrInvalidEncoding},
+		{"version", []byte{Version + 1, TypeSetup, 0, 0, 0, 0, 1, 1}, ErrUnsupportedVersion},
+		{"type", []byte{Version, TypePoEProof, 0, 0, 0, 0, 1, 1}, ErrWrongType},
+		{"leading zero", []byte{Version, TypeSetup, 0, 0, 0, 0, 2, 0, 1}, ErrInvalidEncoding},
+		{"negative zero", []byte{Version, TypeSetup, 1, 0, 0, 0, 0}, ErrInvalidEncoding},
+		{"sign", []byte{Version, TypeSetup, 2, 0, 0, 0, 1, 1}, ErrInvalidEncoding},
+		{"truncated", []byte{Version, TypeSetup, 0, 0, 0, 0, 2, 1}, ErrInvalidEncoding},
+		{"trailing", []byte{Version, TypeSetup, 0, 0, 0, 0, 1, 1, 0}, ErrInvalidEncoding},
+		{"not reduced", []byte{Version, TypeSetup, 0, 0, 0, 0, 1, 7}, ErrNotReduced},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dec := NewDecoder(tc.data, TypeSetup)
+			dec.Element(big.NewInt(7))
+			if err := dec.Finish(); err != tc.err {
+				t.Errorf("got %v, want %v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestHex(t *testing.T) {
+	for _, v := range []int64{0, 1, -1, 255, -4096} {
+		x := big.NewInt(v)
+		decoded, err := DecodeHex(EncodeHex(x))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Cmp(x) != 0 {
+			t.Errorf("got %s, want %s", decoded, x)
+		}
+	}
+	for _, s := range []string{"", "0x", "ff", "-ff", "0x0ff", "0xFF", "-0x0", "0x-1", "+0x1", "0xg"} {
+		if _, err := DecodeHex(s); err != ErrInvalidHex {
+			t.Errorf("%q: expected ErrInvalidHex, got %v", s, err)
+		}
+	}
+	if _, err := DecodeNatHex("-0x1"); err != ErrInvalidHex {
+		t.Errorf("expected ErrInvalidHex for a negative value, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package codec
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// ErrInvalidHex is returned when decoding a non-canonical hex integer
+var ErrInvalidHex = errors.New("invalid hex integer")
+
+// Header is embedded in every JSON encoding to identify the object type and the encoding version
+type Header struct {
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+}
+
+// NewHeader returns the header of the type with the current version
+func NewHeader(typ string) Header {
+	return Header{Type: typ, Version: int(Version)}
+}
+
+// Check returns an error if the header is not of the type or of an unknown version
+func (h Header) Check(typ string) error {
+	if h.Type != typ {
+		return ErrWrongType
+	}
+	if h.Version != int(Version) {
+		return ErrUnsupportedVersion
+	}
+	return nil
+}
+
+// EncodeHex encodes x as lower case hex with the 0x prefix, negative values start with -0x
+func EncodeHex(x *big.Int) string {
+	if x.Sign() < 0 {
+		return "-0x" + new(big.Int).Neg(x).Text(16)
+	}
+	return "0x" + x.Text(16)
+}
+
+// DecodeHex decodes the hex integer encoded by EncodeHex, rejecting leading zeros, upper case and negative zero
+func DecodeHex(s string) (*big.Int, error) {
+	negative := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(s, "-")
+	if !strings.HasPrefix(digits, "0x") {
+		return nil, ErrInvalidHex
+	}
+	digits = digits[2:]
+	if len(digits) == 0 || (len(digits) > 1 && digits[0] == '0') {
+		return nil, ErrInvalidHex
+	}
+	for _, c := range digits {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return nil, ErrInvalidHex
+		}
+	}
+	ret, ok := new(big.Int).SetString(digits, 16)
+	if !ok || (negative && ret.Sign() == 0) {
+		return nil, ErrInvalidHex
+	}
+	if negative {
+		ret.Neg(ret)
+	}
+	return ret, nil
+}
+
+// DecodeHexList decodes the hex integers, stopping at the first error
+func DecodeHexList(list ...string) ([]*big.Int, error) {
+	ret := make([]*big.Int, len(list))
+	var err error
+	for i, s := range list {
+		if ret[i], err = DecodeHex(s); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// DecodeNatHex decodes a non-negative hex integer
+func DecodeNatHex(s string) (*big.Int, error) {
+	ret, err := DecodeHex(s)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Sign() < 0 {
+		return nil, ErrInvalidHex
+	}
+	return ret, nil
+}
+
+// DecodeNatHexList decodes the non-negative hex integers, stopping at the first error
+func DecodeNatHexList(list ...string) ([]*big.Int, error) {
+	ret := make([]*big.Int, len(list))
+	var err error
+	for i, s := range list {
+		if ret[i], err = DecodeNatHex(s); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,109 @@
+package codec_test
+
+import (
+	"bytes"
+	"encoding"
+	"math/big"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+	"github.com/jiajunxin/rsa_accumulator/codec"
+	"github.com/jiajunxin/rsa_accumulator/proof"
+)
+
+type binaryValue interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+// checkRoundTrip decodes the encoding of src into dst and checks that dst encodes to the same bytes
+func checkRoundTrip(t *testing.T, src, dst binaryValue) {
+	t.Helper()
+	data, err := src.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = dst.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := dst.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, encoded) {
+		t.Errorf("encoding is not stable")
+	}
+	if err = dst.UnmarshalBinary(data[:len(data)-1]); err != codec.ErrInvalidEncoding {
+		t.Errorf("expected ErrInvalidEncoding for truncated bytes, got %v", err)
+	}
+}
+
+func TestProofRoundTrip(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	pp := proof.NewPublicParameters(setup.N, setup.G, setup.H)
+	x := big.NewInt(123456789)
+	C := new(big.Int).Exp(pp.G, x, pp.N)
+
+	poke, err := proof.PoKEStarProve(pp, C, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decodedPoKE proof.PoKEStarProof
+	checkRoundTrip(t, poke, &decodedPoKE)
+	if !proof.PoKEStarVerify(pp, C, &decodedPoKE) {
+		t.Errorf("decoded PoKE* proof rejected")
+	}
+
+	zkpoke, err := proof.ZKPoKEProve(pp, pp.G, x, C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decodedZKPoKE proof.ZKPoKEProof
+	checkRoundTrip(t, zkpoke, &decodedZKPoKE)
+	if !proof.ZKPoKEVerify(pp, pp.G, C, &decodedZKPoKE) {
+		t.Errorf("decoded ZKPoKE proof rejected")
+	}
+	if err = decodedZKPoKE.CheckReduced(pp.N); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	poe, err := proof.PoEProve(pp.G, pp.N, C, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decodedPoE proof.PoEProof
+	checkRoundTrip(t, poe, &decodedPoE)
+	if !proof.PoEVerify(pp.G, pp.N, C, x, &decodedPoE) {
+		t.Errorf("decoded PoE proof rejected")
+	}
+
+	r := big.NewInt(987654321)
+	rp, err := proof.NewRPProver(pp, r, big.NewInt(0), big.NewInt(1<<30)).Prove(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decodedRP proof.RangeProof
+	checkRoundTrip(t, rp, &decodedRP)
+	if !proof.NewRPVerifier(pp, big.NewInt(0), big.NewInt(1<<30)).Verify(&decodedRP) {
+		t.Errorf("decoded range proof rejected")
+	}
+	if err = decodedRP.CheckReduced(pp.N); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	aop, err := proof.NewZKAoPProver(pp, r).Prove(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decodedAoP proof.ArgOfPositivity
+	checkRoundTrip(t, aop, &decodedAoP)
+	c := new(big.Int).Exp(pp.G, x, pp.N)
+	c.Mul(c, new(big.Int).Exp(pp.H, r, pp.N))
+	c.Mod(c, pp.N)
+	if !proof.NewZKAoPVerifier(pp, c).Verify(&decodedAoP) {
+		t.Errorf("decoded argument of positivity rejected")
+	}
+	if err = decodedAoP.CheckReduced(pp.N); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"math/bits"
 	"os"
 	"runtime"
-	"strconv"
 	"time"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
@@ -17,21 +16,26 @@ import (
 
 	"github.com/jiajunxin/rsa_accumulator/accumulator"
 	"github.com/jiajunxin/rsa_accumulator/proof"
+	"github.com/jiajunxin/rsa_accumulator/trace"
 	"github.com/jiajunxin/rsa_accumulator/zkmultiswap"
 )
 
+// stdoutTracer prints the SNARK timings of the experiments
+var stdoutTracer = trace.WithTracer(trace.NewWriterTracer(os.Stdout))
+
 // TestMembershipVerify test the time to verify a membership proof
 func TestMembershipVerify() {
 	setup := *accumulator.TrustedSetup()
 	setSize := 10
 	set := accumulator.GenBenchSet(setSize)
 	rep := accumulator.GenRepresentatives(set, accumulator.DIHashFromPoseidon)
-	_, proofs := accumulator.AccAndProve(set, accumulator.DIHashFromPoseidon, &setup)
+	acc, proofs := accumulator.AccAndProve(set, accumulator.DIHashFromPoseidon, &setup)
 	startingTime := time.Now().UTC()
 	repeatNum := 100
 	for i := 0; i < repeatNum; i++ {
-		// each proof raise to the power of the representative is the process to verify the membership
-		_ = accumulator.AccumulateNew(proofs[0], rep[0], setup.N)
+		if !accumulator.VerifyMembershipWithRep(&setup, acc, rep[0], accumulator.DIHashFromPoseidon, proofs[0]) {
+			panic("membership verification failed")
+		}
 	}
 	duration := time.Now().UTC().Sub(startingTime)
 	fmt.Printf("Verifying membership proof for 100 rounds Takes [%.3f] Seconds \n", duration.Seconds())
@@ -460,7 +464,7 @@ func PoKE(base, exp, newAcc, N *big.Int) {
 
 // internal function for test purpose only
 func isCircuitExist(testSetSize uint32) bool {
-	fileName := zkmultiswap.KeyPathPrefix + "_" + strconv.FormatInt(int64(testSetSize), 10) + ".ccs.save"
+	fileName := zkmultiswap.KeyPath(testSetSize) + ".ccs.save"
 	_, err := os.Stat(fileName)
 	if err == nil {
 		return true
@@ -598,14 +602,14 @@ func TestNotusMultiSwap(setsize, updatedSetSize uint32) {
 	//--------------------------------------------finish generating accumulator--------------------------------
 	testSet := zkmultiswap.GenTestSet(updatedSetSize, accumulator.TrustedSetup())
 	publicInfo := testSet.PublicPart()
-	proof, err := zkmultiswap.Prove(testSet)
+	proof, err := zkmultiswap.Prove(testSet, stdoutTracer)
 	if err != nil {
 		fmt.Println("Error during Prove")
 		panic(err)
 	}
 	runtime.GC()
 
-	flag := zkmultiswap.Verify(proof, updatedSetSize, publicInfo)
+	flag := zkmultiswap.Verify(proof, updatedSetSize, publicInfo, stdoutTracer)
 	if flag {
 		fmt.Println("Verification passed")
 		return
@@ -689,7 +693,7 @@ func TestNotusParallel(setsize, updatedSetSize uint32) {
 	table := multiexp.NewPrecomputeTable(setup.G, setup.N, int(maxLen))
 
 	unchangedSet := accumulator.GenBenchSet(int(setsize - updatedSetSize))
-	unchanged := accumulator.GenRepresentatives(unchangedSet, accumulator.DIHashFromPoseidon)
+	unchanged := accumulator.GenRepresentativesParallel(unchangedSet, accumulator.DIHashFromPoseidon, 0)
 	startingTime = time.Now().UTC()
 	newSet1 := append(unchanged[:], insertSet...)
 	// limit = 0 indicates the ProveMembershipParallelWithTableWithRandomizer is running with single thread
@@ -745,14 +749,14 @@ func TestNotusParallel(setsize, updatedSetSize uint32) {
 	runtime.GC()
 	testSet := zkmultiswap.GenTestSet(updatedSetSize, accumulator.TrustedSetup())
 	publicInfo := testSet.PublicPart()
-	proof, err := zkmultiswap.Prove(testSet)
+	proof, err := zkmultiswap.Prove(testSet, stdoutTracer)
 	if err != nil {
 		fmt.Println("Error during Prove")
 		panic(err)
 	}
 	runtime.GC()
 
-	flag := zkmultiswap.Verify(proof, updatedSetSize, publicInfo)
+	flag := zkmultiswap.Verify(proof, updatedSetSize, publicInfo, stdoutTracer)
 	if flag {
 		fmt.Println("Verification passed")
 		return
@@ -769,3 +773,20 @@ func TestMultiSwapWithDifferentSize() {
 	zkmultiswap.TestMultiSwap(4096) //2^12
 	zkmultiswap.TestMultiSwap(8192) //2^13
 }
+
+// TestGenRepresentativesParallel compares the time to generate the representatives on one core and on all cores
+func TestGenRepresentativesParallel(setSize int) {
+	set := accumulator.GenBenchSet(setSize)
+	for _, encodeType := range []accumulator.EncodeType{accumulator.DIHashFromPoseidon, accumulator.HashToPrimeFromSha256} {
+		startingTime := time.Now().UTC()
+		_ = accumulator.GenRepresentatives(set, encodeType)
+		duration := time.Now().UTC().Sub(startingTime)
+		fmt.Printf("GenRepresentatives with %v for set size = %d, takes [%.3f] Seconds \n", encodeType, setSize, duration.Seconds())
+
+		startingTime = time.Now().UTC()
+		_ = accumulator.GenRepresentativesParallel(set, encodeType, runtime.NumCPU())
+		duration = time.Now().UTC().Sub(startingTime)
+		fmt.Printf("GenRepresentativesParallel with %v and %d cores for set size = %d, takes [%.3f] Seconds \n",
+			encodeType, runtime.NumCPU(), setSize, duration.Seconds())
+	}
+}
@@ -0,0 +1,371 @@
+package group
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// minDiscriminantBits is the smallest discriminant accepted by NewClassGroupFromSeed, only useful for tests.
+	// Around 1024 bits are needed for 80-bit security and 3072 bits for 128-bit security.
+	minDiscriminantBits = 16
+	// rounds of Miller-Rabin test for the discriminant, together with one Baillie-PSW test
+	primalityRounds = 20
+)
+
+// Form is the binary quadratic form ax^2 + bxy + cy^2, an element of a class group
+type Form struct {
+	A *big.Int
+	B *big.Int
+	C *big.Int
+}
+
+// String returns the form as (a,b,c) in decimal
+func (f *Form) String() string {
+	return fmt.Sprintf("(%s,%s,%s)", f.A.String(), f.B.String(), f.C.String())
+}
+
+// ClassGroup is the class group of the imaginary quadratic order of discriminant D.
+// The elements are reduced forms of discriminant D and the group operation is the composition of forms.
+// Computing the order of the group is believed to be hard for large |D|, so no trusted setup is needed.
+type ClassGroup struct {
+	D *big.Int
+}
+
+// NewClassGroup returns the class group of discriminant d, which must be negative and 1 mod 4
+func NewClassGroup(d *big.Int) (*ClassGroup, error) {
+	if d == nil || d.Sign() >= 0 || new(big.Int).Mod(d, big4).Cmp(big1) != 0 {
+		return nil, ErrInvalidDiscriminant
+	}
+	return &ClassGroup{D: new(big.Int).Set(d)}, nil
+}
+
+// NewClassGroupFromSeed derives a discriminant D = -p of the given bit length from a public seed,
+// p being a prime of 7 mod 8. The seed is expanded with SHA256 in counter mode and p is the first
+// such prime from the expanded value, so anyone can recompute the group from the seed.
+// With a prime -D the class number is odd and D = 1 mod 8 makes (2, 1, (1-D)/8) a form of the group.
+func NewClassGroupFromSeed(seed []byte, bits int) (*ClassGroup, error) {
+	if bits < minDiscriminantBits {
+		return nil, ErrInvalidDiscriminant
+	}
+	p := new(big.Int).SetBytes(expandSeed(seed, (bits+7)/8))
+	for i := p.BitLen() - 1; i >= bits; i-- {
+		p.SetBit(p, i, 0)
+	}
+	p.SetBit(p, bits-1, 1)
+	// p = 7 mod 8
+	p.SetBit(p, 0, 1)
+	p.SetBit(p, 1, 1)
+	p.SetBit(p, 2, 1)
+	for !p.ProbablyPrime(primalityRounds) {
+		p.Add(p, big8)
+	}
+	return NewClassGroup(p.Neg(p))
+}
+
+// expandSeed expands the seed to length bytes with SHA256 in counter mode
+func expandSeed(seed []byte, length int) []byte {
+	buf := make([]byte, 0, length+sha256.Size)
+	for counter := uint32(0); len(buf) < length; counter++ {
+		var ctr [4]byte
+		binary.BigEndian.PutUint32(ctr[:], counter)
+		h := sha256.New()
+		h.Write(seed)
+		h.Write(ctr[:])
+		buf = h.Sum(buf)
+	}
+	return buf[:length]
+}
+
+// Identity returns the principal form (1, 1, (1-D)/4)
+func (g *ClassGroup) Identity() Element {
+	return g.identity()
+}
+
+// identity returns the principal form (1, 1, (1-D)/4)
+func (g *ClassGroup) identity() *Form {
+	c := new(big.Int).Sub(big1, g.D)
+	c.Rsh(c, 2)
+	return &Form{A: big.NewInt(1), B: big.NewInt(1), C: c}
+}
+
+// Generator returns the reduced form of (2, 1, (1-D)/8), the conventional generator for a discriminant of 1 mod 8.
+// It returns nil if D is not 1 mod 8.
+func (g *ClassGroup) Generator() *Form {
+	c := new(big.Int).Sub(big1, g.D)
+	if new(big.Int).Mod(c, big8).Sign() != 0 {
+		return nil
+	}
+	c.Rsh(c, 3)
+	return reduce(&Form{A: big.NewInt(2), B: big.NewInt(1), C: c})
+}
+
+// HashToElement derives a form from a public seed: a is the first prime from the hash of the seed
+// such that D is a square mod a, and b is the odd square root of D mod a. Nobody knows the discrete
+// logarithm of the result to the generator.
+func (g *ClassGroup) HashToElement(seed []byte) *Form {
+	bits := g.D.BitLen() / 4
+	if bits < minDiscriminantBits/2 {
+		bits = minDiscriminantBits / 2
+	}
+	a := new(big.Int).SetBytes(expandSeed(seed, (bits+7)/8))
+	a.SetBit(a, 0, 1)
+	var b, dModA big.Int
+	for {
+		if a.Cmp(big2) > 0 && a.ProbablyPrime(primalityRounds) && big.Jacobi(g.D, a) == 1 {
+			b.ModSqrt(dModA.Mod(g.D, a), a)
+			if b.Bit(0) == 0 {
+				b.Sub(a, &b)
+			}
+			// b is odd and b^2 = D mod a, D = 1 mod 8, so 4a divides b^2 - D
+			c := new(big.Int).Mul(&b, &b)
+			c.Sub(c, g.D)
+			c.Quo(c, a)
+			c.Rsh(c, 2)
+			return reduce(&Form{A: a, B: &b, C: c})
+		}
+		a.Add(a, big2)
+	}
+}
+
+// Op returns the composition of the forms a and b
+func (g *ClassGroup) Op(a, b Element) Element {
+	return compose(a.(*Form), b.(*Form))
+}
+
+// Exp returns a^x by square and multiply, a negative x raises the inverse of a
+func (g *ClassGroup) Exp(a Element, x *big.Int) Element {
+	f := a.(*Form)
+	if x.Sign() < 0 {
+		f = inverse(f)
+		x = new(big.Int).Neg(x)
+	}
+	ret := g.identity()
+	for i := x.BitLen() - 1; i >= 0; i-- {
+		ret = compose(ret, ret)
+		if x.Bit(i) == 1 {
+			ret = compose(ret, f)
+		}
+	}
+	return ret
+}
+
+// MultiExp returns bases[0]^exps[0] * bases[1]^exps[1] * ...
+func (g *ClassGroup) MultiExp(bases []Element, exps []*big.Int) Element {
+	return multiExp(g, bases, exps)
+}
+
+// Inverse returns the inverse (a, -b, c) of the form a
+func (g *ClassGroup) Inverse(a Element) Element {
+	return inverse(a.(*Form))
+}
+
+// Equal returns true if the reduced forms a and b are equal
+func (g *ClassGroup) Equal(a, b Element) bool {
+	f1, f2 := a.(*Form), b.(*Form)
+	return f1.A.Cmp(f2.A) == 0 && f1.B.Cmp(f2.B) == 0 && f1.C.Cmp(f2.C) == 0
+}
+
+// Encode returns a || sign of b || |b| with a and |b| as big-endian integers of fixed length,
+// c is determined by the discriminant
+func (g *ClassGroup) Encode(a Element) []byte {
+	f := a.(*Form)
+	size := g.encodingSize()
+	ret := make([]byte, 2*size+1)
+	f.A.FillBytes(ret[:size])
+	if f.B.Sign() < 0 {
+		ret[size] = 1
+	}
+	new(big.Int).Abs(f.B).FillBytes(ret[size+1:])
+	return ret
+}
+
+// Decode returns the form encoded by Encode, ErrInvalidElement is returned if it is not a reduced form of the group
+func (g *ClassGroup) Decode(data []byte) (Element, error) {
+	size := g.encodingSize()
+	if len(data) != 2*size+1 || data[size] > 1 {
+		return nil, ErrInvalidElement
+	}
+	a := new(big.Int).SetBytes(data[:size])
+	b := new(big.Int).SetBytes(data[size+1:])
+	if data[size] == 1 {
+		b.Neg(b)
+	}
+	if a.Sign() <= 0 {
+		return nil, ErrInvalidElement
+	}
+	// c = (b^2 - D) / 4a
+	var c, r, fourA big.Int
+	c.Mul(b, b)
+	c.Sub(&c, g.D)
+	fourA.Lsh(a, 2)
+	c.QuoRem(&c, &fourA, &r)
+	f := &Form{A: a, B: b, C: &c}
+	if r.Sign() != 0 || !g.IsElement(f) {
+		return nil, ErrInvalidElement
+	}
+	return f, nil
+}
+
+// encodingSize is the byte length of a and |b| in the encoding, a reduced form has |b| <= a <= sqrt(|D|/3)
+func (g *ClassGroup) encodingSize() int {
+	return (g.D.BitLen()/2 + 8) / 8
+}
+
+// IsElement returns true if a is a reduced form of discriminant D,
+// i.e. b^2 - 4ac = D, |b| <= a <= c, and b >= 0 if |b| = a or a = c
+func (g *ClassGroup) IsElement(a Element) bool {
+	f, ok := a.(*Form)
+	if !ok || f == nil || f.A == nil || f.B == nil || f.C == nil || f.A.Sign() <= 0 {
+		return false
+	}
+	var d, t big.Int
+	d.Mul(f.B, f.B)
+	t.Mul(f.A, f.C)
+	t.Lsh(&t, 2)
+	d.Sub(&d, &t)
+	if d.Cmp(g.D) != 0 {
+		return false
+	}
+	t.Abs(f.B)
+	cmpBA := t.Cmp(f.A)
+	cmpAC := f.A.Cmp(f.C)
+	if cmpBA > 0 || cmpAC > 0 {
+		return false
+	}
+	if (cmpBA == 0 || cmpAC == 0) && f.B.Sign() < 0 {
+		return false
+	}
+	// the form must be primitive
+	t.GCD(nil, nil, f.A, f.B)
+	t.GCD(nil, nil, &t, f.C)
+	return t.Cmp(big1) == 0
+}
+
+// String returns D in decimal
+func (g *ClassGroup) String() string {
+	return g.D.String()
+}
+
+// inverse returns the reduced form of (a, -b, c)
+func inverse(f *Form) *Form {
+	return reduce(&Form{
+		A: new(big.Int).Set(f.A),
+		B: new(big.Int).Neg(f.B),
+		C: new(big.Int).Set(f.C),
+	})
+}
+
+// compose composes two forms of the same discriminant and reduces the result,
+// following the composition algorithm of Shanks as described in the Chia class group notes.
+func compose(f1, f2 *Form) *Form {
+	var g, h, w, t1, t2 big.Int
+	// g = (b1 + b2) / 2, h = (b2 - b1) / 2, w = gcd(a1, a2, g)
+	g.Add(f1.B, f2.B)
+	g.Rsh(&g, 1)
+	h.Sub(f2.B, f1.B)
+	h.Rsh(&h, 1)
+	w.GCD(nil, nil, f1.A, f2.A)
+	w.GCD(nil, nil, &w, &g)
+
+	s := new(big.Int).Quo(f1.A, &w)
+	t := new(big.Int).Quo(f2.A, &w)
+	u := new(big.Int).Quo(&g, &w)
+	st := new(big.Int).Mul(s, t)
+
+	// solve (tu)k = hu + s*c1 mod st for k = mu + v*k'
+	tu := new(big.Int).Mul(t, u)
+	t1.Mul(&h, u)
+	t2.Mul(s, f1.C)
+	t1.Add(&t1, &t2)
+	mu, v := solveMod(tu, &t1, st)
+	// solve (tv)k' = h - t*mu mod s
+	t1.Mul(t, v)
+	t2.Mul(t, mu)
+	t2.Sub(&h, &t2)
+	lambda, _ := solveMod(&t1, &t2, s)
+	k := new(big.Int).Mul(v, lambda)
+	k.Add(k, mu)
+
+	// l = (kt - h) / s, m = (tuk - hu - c1*s) / st
+	l := new(big.Int).Mul(k, t)
+	l.Sub(l, &h)
+	l.Quo(l, s)
+	m := new(big.Int).Mul(tu, k)
+	t1.Mul(&h, u)
+	m.Sub(m, &t1)
+	t1.Mul(f1.C, s)
+	m.Sub(m, &t1)
+	m.Quo(m, st)
+
+	// (st, wu - (kt + ls), kl - wm)
+	b := new(big.Int).Mul(&w, u)
+	t1.Mul(k, t)
+	t2.Mul(l, s)
+	t1.Add(&t1, &t2)
+	b.Sub(b, &t1)
+	c := new(big.Int).Mul(k, l)
+	t1.Mul(&w, m)
+	c.Sub(c, &t1)
+	return reduce(&Form{A: st, B: b, C: c})
+}
+
+// solveMod solves ax = b mod m, the solutions are mu + v*n for any integer n.
+// The congruences in compose always have a solution.
+func solveMod(a, b, m *big.Int) (mu, v *big.Int) {
+	var g, d, q big.Int
+	g.GCD(&d, nil, a, m)
+	q.Quo(b, &g)
+	mu = new(big.Int).Mul(&q, &d)
+	mu.Mod(mu, m)
+	v = new(big.Int).Quo(m, &g)
+	return mu, v
+}
+
+// normalize makes -a < b <= a without changing the class of the form
+func normalize(f *Form) *Form {
+	var t big.Int
+	t.Neg(f.A)
+	if f.B.Cmp(&t) > 0 && f.B.Cmp(f.A) <= 0 {
+		return f
+	}
+	// r = floor((a - b) / 2a), b' = b + 2ra, c' = ar^2 + br + c
+	var r, twoA big.Int
+	twoA.Lsh(f.A, 1)
+	r.Sub(f.A, f.B)
+	// Div rounds down for a positive divisor
+	r.Div(&r, &twoA)
+	b := new(big.Int).Mul(&r, &twoA)
+	b.Add(b, f.B)
+	c := new(big.Int).Mul(f.A, &r)
+	c.Add(c, f.B)
+	c.Mul(c, &r)
+	c.Add(c, f.C)
+	return &Form{A: f.A, B: b, C: c}
+}
+
+// reduce returns the unique reduced form equivalent to f
+func reduce(f *Form) *Form {
+	f = normalize(f)
+	var s, twoC big.Int
+	for {
+		cmp := f.A.Cmp(f.C)
+		if cmp < 0 || (cmp == 0 && f.B.Sign() >= 0) {
+			break
+		}
+		// s = floor((c + b) / 2c), (a, b, c) = (c, -b + 2sc, cs^2 - bs + a)
+		twoC.Lsh(f.C, 1)
+		s.Add(f.C, f.B)
+		s.Div(&s, &twoC)
+		b := new(big.Int).Mul(&s, &twoC)
+		b.Sub(b, f.B)
+		c := new(big.Int).Mul(f.C, &s)
+		c.Sub(c, f.B)
+		c.Mul(c, &s)
+		c.Add(c, f.A)
+		f = &Form{A: f.C, B: b, C: c}
+	}
+	return normalize(f)
+}
@@ -0,0 +1,115 @@
+package group
+
+import (
+	"math/big"
+	"sync"
+)
+
+// RSAWithFactors is Z*_N for a prover who knows N = PQ. Exponentiations are done modulo P and Q
+// with exponents reduced modulo P-1 and Q-1, and recombined with the Chinese remainder theorem,
+// which is about four times faster than one exponentiation modulo N, and twice again with the halves in parallel.
+// The results are the same as RSA.
+type RSAWithFactors struct {
+	RSA
+	P *big.Int
+	Q *big.Int
+
+	pMin1 *big.Int
+	qMin1 *big.Int
+	qInv  *big.Int // Q^{-1} mod P
+}
+
+// NewRSAWithFactors returns the group Z*_N for N = pq with distinct primes p and q
+func NewRSAWithFactors(p, q *big.Int) (*RSAWithFactors, error) {
+	if p == nil || q == nil || p.Cmp(big2) <= 0 || q.Cmp(big2) <= 0 || p.Cmp(q) == 0 {
+		return nil, ErrInvalidModulus
+	}
+	qInv := new(big.Int).ModInverse(q, p)
+	if qInv == nil {
+		return nil, ErrInvalidModulus
+	}
+	return &RSAWithFactors{
+		RSA:   RSA{N: new(big.Int).Mul(p, q)},
+		P:     new(big.Int).Set(p),
+		Q:     new(big.Int).Set(q),
+		pMin1: new(big.Int).Sub(p, big1),
+		qMin1: new(big.Int).Sub(q, big1),
+		qInv:  qInv,
+	}, nil
+}
+
+// Exp returns a^x mod N, the exponentiations modulo P and Q run in parallel
+func (g *RSAWithFactors) Exp(a Element, x *big.Int) Element {
+	return g.exp(a.(*big.Int), x, true)
+}
+
+// ExpSequential returns a^x mod N with the exponentiations modulo P and Q one after the other,
+// for callers that already run one exponentiation per CPU
+func (g *RSAWithFactors) ExpSequential(a Element, x *big.Int) Element {
+	return g.exp(a.(*big.Int), x, false)
+}
+
+// ExpMany returns a^exps[0], a^exps[1], ... mod N, the exponentiations run in parallel
+func (g *RSAWithFactors) ExpMany(a Element, exps []*big.Int) []Element {
+	ret := make([]Element, len(exps))
+	var wg sync.WaitGroup
+	for i := range exps {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ret[i] = g.exp(a.(*big.Int), exps[i], false)
+		}(i)
+	}
+	wg.Wait()
+	return ret
+}
+
+// MultiExp returns bases[0]^exps[0] * bases[1]^exps[1] * ... mod N
+func (g *RSAWithFactors) MultiExp(bases []Element, exps []*big.Int) Element {
+	return multiExp(g, bases, exps)
+}
+
+// exp computes base^x mod P and mod Q and recombines them with Garner's formula
+// base^x = mq + Q * (Q^{-1} * (mp - mq) mod P)
+func (g *RSAWithFactors) exp(base, x *big.Int, parallel bool) *big.Int {
+	if x.Sign() < 0 {
+		base = new(big.Int).ModInverse(base, g.N)
+		if base == nil {
+			panic("element not invertible")
+		}
+		x = new(big.Int).Neg(x)
+	}
+	if x.Sign() == 0 {
+		return big.NewInt(1)
+	}
+	var mp, mq big.Int
+	if parallel {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			expModPrime(&mp, base, x, g.P, g.pMin1)
+		}()
+		expModPrime(&mq, base, x, g.Q, g.qMin1)
+		wg.Wait()
+	} else {
+		expModPrime(&mp, base, x, g.P, g.pMin1)
+		expModPrime(&mq, base, x, g.Q, g.qMin1)
+	}
+	ret := new(big.Int).Sub(&mp, &mq)
+	ret.Mul(ret, g.qInv)
+	ret.Mod(ret, g.P)
+	ret.Mul(ret, g.Q)
+	return ret.Add(ret, &mq)
+}
+
+// expModPrime sets z = base^x mod p for x > 0. The exponent is reduced to ((x-1) mod (p-1)) + 1,
+// which is in [1, p-1] so that a base divisible by p still gives 0.
+func expModPrime(z, base, x, p, pMin1 *big.Int) {
+	var b, e big.Int
+	b.Mod(base, p)
+	e.Sub(x, big1)
+	e.Mod(&e, pMin1)
+	e.Add(&e, big1)
+	z.Exp(&b, &e, p)
+}
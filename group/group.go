@@ -0,0 +1,83 @@
+// Package group defines groups of unknown order for the accumulator and the proofs of exponentiation.
+// RSA groups Z*_N need a trusted setup, class groups of imaginary quadratic orders only need a public seed.
+package group
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	// ErrInvalidDiscriminant is returned for a discriminant that is not negative, 1 mod 4 and large enough
+	ErrInvalidDiscriminant = errors.New("invalid discriminant")
+	// ErrInvalidModulus is returned for an RSA modulus smaller than 3
+	ErrInvalidModulus = errors.New("invalid modulus")
+	// ErrInvalidElement is returned when decoding bytes that are not an encoded element of the group
+	ErrInvalidElement = errors.New("invalid group element")
+
+	big1 = big.NewInt(1)
+	big2 = big.NewInt(2)
+	big4 = big.NewInt(4)
+	big8 = big.NewInt(8)
+)
+
+// Element is an element of a group, each Group only accepts its own element type.
+// String is used in the Fiat-Shamir transcripts.
+type Element interface {
+	String() string
+}
+
+// Group is a group of unknown order, written multiplicatively
+type Group interface {
+	// Identity returns the identity element
+	Identity() Element
+	// Op returns a*b
+	Op(a, b Element) Element
+	// Exp returns a^x, a negative x raises the inverse of a
+	Exp(a Element, x *big.Int) Element
+	// MultiExp returns bases[0]^exps[0] * bases[1]^exps[1] * ..., the slices must have the same length
+	MultiExp(bases []Element, exps []*big.Int) Element
+	// Equal returns true if a and b are the same element
+	Equal(a, b Element) bool
+	// Encode returns the canonical encoding of a, all elements of the group have the same length
+	Encode(a Element) []byte
+	// IsElement returns true if a is a valid and reduced element of the group
+	IsElement(a Element) bool
+	// String describes the group in the Fiat-Shamir transcripts
+	String() string
+}
+
+// MultiPower is implemented by groups that raise one element to several exponents
+// faster than one exponentiation at a time
+type MultiPower interface {
+	ExpMany(a Element, exps []*big.Int) []Element
+}
+
+// ExpMany returns a^exps[0], a^exps[1], ..., with the MultiPower method of the group if there is one
+func ExpMany(grp Group, a Element, exps []*big.Int) []Element {
+	if m, ok := grp.(MultiPower); ok {
+		return m.ExpMany(a, exps)
+	}
+	return expOneByOne(grp, a, exps)
+}
+
+// expOneByOne returns a^exps[0], a^exps[1], ... with one exponentiation for each exponent
+func expOneByOne(grp Group, a Element, exps []*big.Int) []Element {
+	ret := make([]Element, len(exps))
+	for i := range exps {
+		ret[i] = grp.Exp(a, exps[i])
+	}
+	return ret
+}
+
+// multiExp is the MultiExp of groups without a faster method
+func multiExp(grp Group, bases []Element, exps []*big.Int) Element {
+	if len(bases) != len(exps) {
+		panic("number of bases and exponents differ")
+	}
+	ret := grp.Identity()
+	for i := range bases {
+		ret = grp.Op(ret, grp.Exp(bases[i], exps[i]))
+	}
+	return ret
+}
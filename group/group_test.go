@@ -0,0 +1,213 @@
+package group
+
+import (
+	"math/big"
+	"testing"
+)
+
+func testClassGroup(t *testing.T) *ClassGroup {
+	t.Helper()
+	g, err := NewClassGroupFromSeed([]byte("rsa_accumulator class group test"), 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestClassNumber(t *testing.T) {
+	// the class numbers of these discriminants are prime, so (2, 1, (1-D)/8) has order h(D)
+	testCases := []struct {
+		d int64
+		h int64
+	}{
+		{-23, 3},
+		{-47, 5},
+		{-71, 7},
+	}
+	for _, tc := range testCases {
+		g, err := NewClassGroup(big.NewInt(tc.d))
+		if err != nil {
+			t.Fatal(err)
+		}
+		gen := g.Generator()
+		if !g.IsElement(gen) {
+			t.Fatalf("D = %d: generator %v is not reduced", tc.d, gen)
+		}
+		if g.Equal(gen, g.Identity()) {
+			t.Fatalf("D = %d: generator is the identity", tc.d)
+		}
+		if !g.Equal(g.Exp(gen, big.NewInt(tc.h)), g.Identity()) {
+			t.Errorf("D = %d: generator^%d is not the identity", tc.d, tc.h)
+		}
+	}
+}
+
+func TestNewClassGroup(t *testing.T) {
+	for _, d := range []int64{0, 5, -4, -6} {
+		if _, err := NewClassGroup(big.NewInt(d)); err != ErrInvalidDiscriminant {
+			t.Errorf("NewClassGroup(%d) error = %v, want %v", d, err, ErrInvalidDiscriminant)
+		}
+	}
+	g1 := testClassGroup(t)
+	g2 := testClassGroup(t)
+	if g1.D.Cmp(g2.D) != 0 {
+		t.Error("NewClassGroupFromSeed is not deterministic")
+	}
+	p := new(big.Int).Neg(g1.D)
+	if p.BitLen() != 256 || !p.ProbablyPrime(20) || p.Bit(0)&p.Bit(1)&p.Bit(2) != 1 {
+		t.Errorf("-D = %v is not a 256-bit prime of 7 mod 8", p)
+	}
+	if _, err := NewClassGroupFromSeed(nil, 8); err != ErrInvalidDiscriminant {
+		t.Errorf("NewClassGroupFromSeed with 8 bits error = %v, want %v", err, ErrInvalidDiscriminant)
+	}
+}
+
+func testGroupLaws(t *testing.T, g Group, a, b, c Element) {
+	t.Helper()
+	for _, e := range []Element{a, b, c} {
+		if !g.IsElement(e) {
+			t.Fatalf("%v is not an element", e)
+		}
+	}
+	if !g.Equal(g.Op(g.Op(a, b), c), g.Op(a, g.Op(b, c))) {
+		t.Error("Op is not associative")
+	}
+	if !g.Equal(g.Op(a, b), g.Op(b, a)) {
+		t.Error("Op is not commutative")
+	}
+	x := big.NewInt(1234567)
+	y := big.NewInt(7654321)
+	sum := new(big.Int).Add(x, y)
+	if !g.Equal(g.Op(g.Exp(a, x), g.Exp(a, y)), g.Exp(a, sum)) {
+		t.Error("a^x * a^y != a^{x+y}")
+	}
+	if !g.Equal(g.Exp(g.Exp(a, x), y), g.Exp(a, new(big.Int).Mul(x, y))) {
+		t.Error("(a^x)^y != a^{xy}")
+	}
+	one := g.Op(g.Exp(a, x), g.Exp(a, new(big.Int).Neg(x)))
+	if !g.Equal(g.Op(one, b), b) {
+		t.Error("a^x * a^{-x} is not the identity")
+	}
+	if !g.IsElement(g.Exp(a, x)) || !g.IsElement(g.Op(a, b)) {
+		t.Error("results are not reduced elements")
+	}
+	if !g.Equal(g.Op(a, g.Identity()), a) {
+		t.Error("a * 1 != a")
+	}
+	multi := g.MultiExp([]Element{a, b, c}, []*big.Int{x, y, sum})
+	expected := g.Op(g.Op(g.Exp(a, x), g.Exp(b, y)), g.Exp(c, sum))
+	if !g.Equal(multi, expected) {
+		t.Error("MultiExp differs from the product of Exp")
+	}
+	exps := []*big.Int{x, y, sum, big.NewInt(0)}
+	for _, n := range []int{1, 2, 4} {
+		powers := ExpMany(g, a, exps[:n])
+		for i := range powers {
+			if !g.Equal(powers[i], g.Exp(a, exps[i])) {
+				t.Errorf("ExpMany with %d exponents differs from Exp", n)
+			}
+		}
+	}
+	size := len(g.Encode(g.Identity()))
+	for _, e := range []Element{a, b, c, g.Identity(), g.Exp(a, x)} {
+		if len(g.Encode(e)) != size {
+			t.Errorf("encoding of %v has %d bytes, want %d", e, len(g.Encode(e)), size)
+		}
+	}
+}
+
+type decoder interface {
+	Decode(data []byte) (Element, error)
+}
+
+func testEncoding(t *testing.T, g Group, elements ...Element) {
+	t.Helper()
+	dec := g.(decoder)
+	for _, e := range elements {
+		data := g.Encode(e)
+		got, err := dec.Decode(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !g.Equal(got, e) {
+			t.Errorf("Decode(Encode(%v)) = %v", e, got)
+		}
+		if _, err = dec.Decode(data[1:]); err != ErrInvalidElement {
+			t.Errorf("Decode() of a short encoding error = %v, want %v", err, ErrInvalidElement)
+		}
+	}
+}
+
+func TestClassGroupLaws(t *testing.T) {
+	g := testClassGroup(t)
+	a := g.Generator()
+	b := g.HashToElement([]byte("b"))
+	c := g.HashToElement([]byte("c"))
+	if g.Equal(a, b) || g.Equal(b, c) {
+		t.Fatal("derived elements are equal")
+	}
+	testGroupLaws(t, g, a, b, c)
+	testEncoding(t, g, a, b, g.Inverse(c), g.Identity())
+	invalid := g.Encode(a)
+	invalid[len(invalid)-1] ^= 2
+	if _, err := g.Decode(invalid); err != ErrInvalidElement {
+		t.Errorf("Decode() of an invalid form error = %v, want %v", err, ErrInvalidElement)
+	}
+	if !g.Equal(g.Op(a, g.Inverse(a)), g.Identity()) {
+		t.Error("a * a^{-1} is not the identity")
+	}
+	if g.IsElement(&Form{A: big.NewInt(1), B: big.NewInt(1), C: big.NewInt(1)}) {
+		t.Error("form of another discriminant accepted")
+	}
+	unreduced := &Form{A: a.C, B: new(big.Int).Neg(a.B), C: a.A}
+	if a.A.Cmp(a.C) != 0 && g.IsElement(unreduced) {
+		t.Error("unreduced form accepted")
+	}
+}
+
+func TestRSALaws(t *testing.T) {
+	// 1000003 * 1000033
+	g, err := NewRSA(big.NewInt(1000036000099))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testGroupLaws(t, g, big.NewInt(4), big.NewInt(9), big.NewInt(25))
+	testEncoding(t, g, big.NewInt(1), big.NewInt(4), big.NewInt(1000036000098))
+	if g.IsElement(big.NewInt(1000003)) || g.IsElement(big.NewInt(0)) || g.IsElement(g.N) {
+		t.Error("invalid element accepted")
+	}
+	if _, err = NewRSA(big.NewInt(2)); err != ErrInvalidModulus {
+		t.Errorf("NewRSA(2) error = %v, want %v", err, ErrInvalidModulus)
+	}
+}
+
+func TestRSAWithFactors(t *testing.T) {
+	g, err := NewRSAWithFactors(big.NewInt(1000003), big.NewInt(1000033))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testGroupLaws(t, g, big.NewInt(4), big.NewInt(9), big.NewInt(25))
+	plain, _ := NewRSA(g.N)
+	exps := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(1000002), big.NewInt(-77),
+		new(big.Int).Lsh(big.NewInt(12345), 300)}
+	for _, a := range []*big.Int{big.NewInt(2), big.NewInt(1000003), big.NewInt(1000036000098)} {
+		for _, x := range exps {
+			if x.Sign() < 0 && !plain.IsElement(a) {
+				continue
+			}
+			want := plain.Exp(a, x)
+			if !g.Equal(g.Exp(a, x), want) || !g.Equal(g.ExpSequential(a, x), want) {
+				t.Errorf("Exp(%v, %v) differs from RSA.Exp()", a, x)
+			}
+		}
+	}
+	many := g.ExpMany(big.NewInt(3), exps)
+	for i, x := range exps {
+		if !g.Equal(many[i], plain.Exp(big.NewInt(3), x)) {
+			t.Errorf("ExpMany()[%d] differs from RSA.Exp()", i)
+		}
+	}
+	if _, err = NewRSAWithFactors(big.NewInt(1000003), big.NewInt(1000003)); err != ErrInvalidModulus {
+		t.Errorf("NewRSAWithFactors(p, p) error = %v, want %v", err, ErrInvalidModulus)
+	}
+}
@@ -0,0 +1,105 @@
+package group
+
+import (
+	"math/big"
+
+	"github.com/jiajunxin/multiexp"
+)
+
+// RSA is the group Z*_N, its elements are *big.Int in [1, N)
+type RSA struct {
+	N *big.Int
+}
+
+// NewRSA returns the group Z*_N
+func NewRSA(n *big.Int) (*RSA, error) {
+	if n == nil || n.Cmp(big2) <= 0 {
+		return nil, ErrInvalidModulus
+	}
+	return &RSA{N: n}, nil
+}
+
+// Identity returns 1
+func (g *RSA) Identity() Element {
+	return big.NewInt(1)
+}
+
+// Op returns a*b mod N
+func (g *RSA) Op(a, b Element) Element {
+	ret := new(big.Int).Mul(a.(*big.Int), b.(*big.Int))
+	return ret.Mod(ret, g.N)
+}
+
+// Exp returns a^x mod N, it panics if x is negative and a is not invertible.
+// Verifiers check untrusted elements with IsElement first.
+func (g *RSA) Exp(a Element, x *big.Int) Element {
+	ret := new(big.Int).Exp(a.(*big.Int), x, g.N)
+	if ret == nil {
+		panic("element not invertible")
+	}
+	return ret
+}
+
+// MultiExp returns bases[0]^exps[0] * bases[1]^exps[1] * ... mod N
+func (g *RSA) MultiExp(bases []Element, exps []*big.Int) Element {
+	return multiExp(g, bases, exps)
+}
+
+// ExpMany returns a^exps[0], a^exps[1], ... mod N. Two or four non-negative exponents
+// share the squarings of a with multiexp.
+func (g *RSA) ExpMany(a Element, exps []*big.Int) []Element {
+	x := a.(*big.Int)
+	for _, v := range exps {
+		if v.Sign() < 0 {
+			return expOneByOne(g, a, exps)
+		}
+	}
+	switch len(exps) {
+	case 2:
+		ret := multiexp.DoubleExp(x, [2]*big.Int{exps[0], exps[1]}, g.N)
+		return []Element{ret[0], ret[1]}
+	case 4:
+		ret := multiexp.FourfoldExp(x, g.N, [4]*big.Int{exps[0], exps[1], exps[2], exps[3]})
+		return []Element{ret[0], ret[1], ret[2], ret[3]}
+	default:
+		return expOneByOne(g, a, exps)
+	}
+}
+
+// Equal returns true if a = b
+func (g *RSA) Equal(a, b Element) bool {
+	return a.(*big.Int).Cmp(b.(*big.Int)) == 0
+}
+
+// Encode returns a as a big-endian integer of the byte length of N
+func (g *RSA) Encode(a Element) []byte {
+	return a.(*big.Int).FillBytes(make([]byte, (g.N.BitLen()+7)/8))
+}
+
+// Decode returns the element encoded by Encode, ErrInvalidElement is returned if it is not in Z*_N
+func (g *RSA) Decode(data []byte) (Element, error) {
+	if len(data) != (g.N.BitLen()+7)/8 {
+		return nil, ErrInvalidElement
+	}
+	x := new(big.Int).SetBytes(data)
+	if !g.IsElement(x) {
+		return nil, ErrInvalidElement
+	}
+	return x, nil
+}
+
+// IsElement returns true if a is a *big.Int in [1, N) coprime to N
+func (g *RSA) IsElement(a Element) bool {
+	x, ok := a.(*big.Int)
+	if !ok || x == nil || x.Sign() <= 0 || x.Cmp(g.N) >= 0 {
+		return false
+	}
+	var gcd big.Int
+	gcd.GCD(nil, nil, x, g.N)
+	return gcd.Cmp(big1) == 0
+}
+
+// String returns N in decimal, the same as the transcripts over Z*_N
+func (g *RSA) String() string {
+	return g.N.String()
+}
@@ -0,0 +1,60 @@
+// Package golden reads and updates the golden files of the tests, in the testdata directory of the tested package.
+// The files are rewritten when the tests run with the -update flag.
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// Update returns true if the tests run with -update, the tests then generate the content of the golden files
+func Update() bool {
+	return *update
+}
+
+// File returns the content of the golden file, the file is written with data first if -update is set
+func File(t testing.TB, name string, data []byte) []byte {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// JSON returns the content of the golden file, the file is written with the JSON encoding of v first if -update is set
+func JSON(t testing.TB, name string, v interface{}) []byte {
+	t.Helper()
+	var data []byte
+	if *update {
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return File(t, name, data)
+}
+
+// CheckJSON checks that v encodes to exactly the golden bytes
+func CheckJSON(t testing.TB, data []byte, v interface{}) {
+	t.Helper()
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, encoded) {
+		t.Errorf("encoding does not match the golden file")
+	}
+}
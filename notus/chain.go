@@ -0,0 +1,226 @@
+package notus
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+	"github.com/jiajunxin/rsa_accumulator/trace"
+)
+
+// maxReportSize bounds the length of one report in a stream, a report is a few kilobytes
+const maxReportSize = 1 << 20
+
+var (
+	// ErrMissingGenesis is returned when the chain does not start with the genesis report of epoch 1
+	ErrMissingGenesis = errors.New("chain does not start with the genesis epoch")
+	// ErrBrokenChain is returned when a report does not continue the previous one
+	ErrBrokenChain = errors.New("report does not continue the previous epoch")
+	// ErrEmptyChain is returned when a stream has no report
+	ErrEmptyChain = errors.New("no epoch reports")
+)
+
+// ChainError is the first invalid epoch of a chain
+type ChainError struct {
+	// Index is the position of the report in the chain, starting at 0
+	Index int
+	Epoch uint32
+	Err   error
+}
+
+// Error returns the error as text
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("epoch %d (report %d): %v", e.Epoch, e.Index, e.Err)
+}
+
+// Unwrap returns the reason the epoch is invalid
+func (e *ChainError) Unwrap() error {
+	return e.Err
+}
+
+// WriteReport appends the report to a stream read by ReportReader, as its binary encoding prefixed by its length
+func WriteReport(w io.Writer, report *EpochReport) error {
+	data, err := report.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+	if _, err = w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// ReportReader reads the reports written by WriteReport
+type ReportReader struct {
+	r *bufio.Reader
+}
+
+// NewReportReader returns a reader of the reports in r
+func NewReportReader(r io.Reader) *ReportReader {
+	return &ReportReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next report, io.EOF at the end of the stream and io.ErrUnexpectedEOF for a truncated report
+func (rr *ReportReader) Next() (*EpochReport, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(rr.r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxReportSize {
+		return nil, ErrInvalidReport
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(rr.r, data); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	report := new(EpochReport)
+	if err := report.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ChainVerifier verifies a chain of epochs report by report, from the genesis on. Each report must continue
+// the previous one: its epoch is the next one, its Acc_old is the Acc_new of the previous report, and its
+// transcript, PoKE proofs and zkmultiswap proof verify. After the first invalid report the verifier rejects
+// every report with the same error.
+type ChainVerifier struct {
+	setup *accumulator.Setup
+	opts  []trace.Option
+	prev  *EpochReport
+	count int
+	err   *ChainError
+}
+
+// NewChainVerifier returns a verifier expecting the genesis report, the options are passed to the SNARK verifier.
+// The verifying keys of the zkmultiswap circuits are read from the working directory.
+func NewChainVerifier(setup *accumulator.Setup, opts ...trace.Option) *ChainVerifier {
+	return &ChainVerifier{setup: setup, opts: opts}
+}
+
+// Add verifies the next report of the chain, it returns a *ChainError if the report is invalid.
+// A nil report is invalid with ErrInvalidReport, its Epoch being the one following the last verified report.
+func (c *ChainVerifier) Add(report *EpochReport) error {
+	if c.err != nil {
+		return c.err
+	}
+	if report == nil {
+		c.err = &ChainError{Index: c.count, Epoch: c.Epoch() + 1, Err: ErrInvalidReport}
+		return c.err
+	}
+	if err := c.check(report); err != nil {
+		c.err = &ChainError{Index: c.count, Epoch: report.Epoch, Err: err}
+		return c.err
+	}
+	c.prev = report
+	c.count++
+	return nil
+}
+
+func (c *ChainVerifier) check(report *EpochReport) error {
+	if c.prev == nil && !report.IsGenesis() {
+		return ErrMissingGenesis
+	}
+	if err := CheckLink(c.prev, report); err != nil {
+		return err
+	}
+	return report.Verify(c.setup, c.opts...)
+}
+
+// CheckLink checks that the report continues prev without verifying the report itself: its epoch is the next
+// one and its Acc_old is the Acc_new of prev. prev is nil for the first report of a chain, which may start at any
+// epoch, but a genesis report is only the first one and of epoch 1. It returns ErrInvalidReport for a nil report,
+// ErrMissingGenesis for a first genesis report of another epoch and ErrBrokenChain otherwise.
+func CheckLink(prev, report *EpochReport) error {
+	if report == nil {
+		return ErrInvalidReport
+	}
+	if prev == nil {
+		if report.IsGenesis() && report.Epoch != 1 {
+			return ErrMissingGenesis
+		}
+		return nil
+	}
+	if report.IsGenesis() || report.Epoch != prev.Epoch+1 || report.AccOld == nil || prev.AccNew == nil ||
+		report.AccOld.Cmp(prev.AccNew) != 0 {
+		return ErrBrokenChain
+	}
+	return nil
+}
+
+// Epoch returns the epoch of the last verified report, 0 before the genesis
+func (c *ChainVerifier) Epoch() uint32 {
+	if c.prev == nil {
+		return 0
+	}
+	return c.prev.Epoch
+}
+
+// Acc returns the accumulator of the last verified report, nil before the genesis
+func (c *ChainVerifier) Acc() *big.Int {
+	if c.prev == nil {
+		return nil
+	}
+	return new(big.Int).Set(c.prev.AccNew)
+}
+
+// Count returns the number of verified reports
+func (c *ChainVerifier) Count() int {
+	return c.count
+}
+
+// Err returns the first invalid epoch, nil if all reports verified
+func (c *ChainVerifier) Err() error {
+	if c.err == nil {
+		return nil
+	}
+	return c.err
+}
+
+// VerifyReader verifies the reports of the stream in r as they are read. A report that cannot be decoded is the
+// first invalid epoch, its Epoch being the one following the last verified report.
+func (c *ChainVerifier) VerifyReader(r io.Reader) error {
+	reader := NewReportReader(r)
+	empty := c.count == 0 && c.err == nil
+	for {
+		report, err := reader.Next()
+		if err == io.EOF {
+			if empty {
+				return ErrEmptyChain
+			}
+			return c.Err()
+		}
+		if err != nil {
+			if c.err == nil {
+				c.err = &ChainError{Index: c.count, Epoch: c.Epoch() + 1, Err: err}
+			}
+			return c.err
+		}
+		empty = false
+		if err = c.Add(report); err != nil {
+			return err
+		}
+	}
+}
+
+// VerifyFile verifies the reports of the file written by WriteReport
+func (c *ChainVerifier) VerifyFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return c.VerifyReader(f)
+}
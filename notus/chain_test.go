@@ -0,0 +1,151 @@
+package notus
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+	"github.com/jiajunxin/rsa_accumulator/store"
+)
+
+func genChain(t *testing.T, setup *accumulator.Setup) []*EpochReport {
+	t.Helper()
+	m := newTestManager(t, store.NewMemoryStore(store.NewState(setup)))
+	genesis, err := m.Genesis([]Change{{UserID: 1, Balance: 10}, {UserID: 2, Balance: 20}, {UserID: 3, Balance: 30}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports := []*EpochReport{genesis}
+	for _, changes := range [][]Change{
+		{{UserID: 1, Balance: 11}},
+		{{UserID: 2, Balance: 21}, {UserID: 3, Balance: 31}},
+		{{UserID: 3, Balance: 0}},
+	} {
+		report, err := m.Advance(changes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+func writeChain(t *testing.T, reports ...*EpochReport) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, report := range reports {
+		if err := WriteReport(&buf, report); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestChainVerifier(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	reports := genChain(t, setup)
+
+	path := filepath.Join(t.TempDir(), "epochs.bin")
+	if err := os.WriteFile(path, writeChain(t, reports...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := NewChainVerifier(setup)
+	if err := c.VerifyFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if c.Count() != 4 || c.Epoch() != 4 || c.Acc().Cmp(reports[3].AccNew) != 0 {
+		t.Errorf("verified %d reports up to epoch %d", c.Count(), c.Epoch())
+	}
+
+	// the chain is verified incrementally, a stream may continue the reports already added
+	c = NewChainVerifier(setup)
+	for _, report := range reports[:2] {
+		if err := c.Add(report); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.VerifyReader(bytes.NewReader(writeChain(t, reports[2:]...))); err != nil {
+		t.Fatal(err)
+	}
+	if c.Epoch() != 4 {
+		t.Errorf("Epoch() = %d, want 4", c.Epoch())
+	}
+}
+
+func TestChainVerifierErrors(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	reports := genChain(t, setup)
+	tampered := *reports[2]
+	tampered.QNew = new(big.Int).Set(reports[2].QOld)
+	data := writeChain(t, reports...)
+
+	for _, tc := range []struct {
+		name  string
+		data  []byte
+		index int
+		epoch uint32
+		want  error
+	}{
+		{"missing genesis", writeChain(t, reports[1:]...), 0, 2, ErrMissingGenesis},
+		{"missing epoch", writeChain(t, reports[0], reports[1], reports[3]), 2, 4, ErrBrokenChain},
+		{"repeated epoch", writeChain(t, reports[0], reports[1], reports[1]), 2, 2, ErrBrokenChain},
+		{"tampered epoch", writeChain(t, reports[0], reports[1], &tampered, reports[3]), 2, 3, ErrInvalidPoKE},
+		{"truncated stream", data[:len(data)-10], 3, 4, io.ErrUnexpectedEOF},
+	} {
+		c := NewChainVerifier(setup)
+		err := c.VerifyReader(bytes.NewReader(tc.data))
+		var chainErr *ChainError
+		if !errors.As(err, &chainErr) || !errors.Is(err, tc.want) || chainErr.Index != tc.index || chainErr.Epoch != tc.epoch {
+			t.Errorf("%s: VerifyReader() = %v, want %v at epoch %d", tc.name, err, tc.want, tc.epoch)
+			continue
+		}
+		if c.Count() != tc.index || c.Err() != err {
+			t.Errorf("%s: verified %d reports, error %v", tc.name, c.Count(), c.Err())
+		}
+		// the verifier stays on the first invalid epoch
+		if err = c.Add(reports[0]); err != chainErr {
+			t.Errorf("%s: Add() after the invalid epoch = %v", tc.name, err)
+		}
+	}
+
+	c := NewChainVerifier(setup)
+	if err := c.Add(reports[0]); err != nil {
+		t.Fatal(err)
+	}
+	var chainErr *ChainError
+	if err := c.Add(nil); !errors.As(err, &chainErr) || chainErr.Err != ErrInvalidReport || chainErr.Index != 1 || chainErr.Epoch != 2 {
+		t.Errorf("Add(nil) = %v, want %v at epoch 2", err, ErrInvalidReport)
+	}
+
+	if err := CheckLink(reports[1], reports[2]); err != nil {
+		t.Errorf("CheckLink() of consecutive reports = %v", err)
+	}
+	genesis := *reports[0]
+	genesis.Epoch = 2
+	for _, tc := range []struct {
+		name         string
+		prev, report *EpochReport
+		want         error
+	}{
+		{"nil report", reports[1], nil, ErrInvalidReport},
+		{"genesis of epoch 2", nil, &genesis, ErrMissingGenesis},
+		{"genesis after an epoch", reports[0], &genesis, ErrBrokenChain},
+		{"missing epoch", reports[1], reports[3], ErrBrokenChain},
+	} {
+		if err := CheckLink(tc.prev, tc.report); err != tc.want {
+			t.Errorf("%s: CheckLink() = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+	if err := CheckLink(nil, reports[2]); err != nil {
+		t.Errorf("CheckLink() of a chain starting at epoch 3 = %v", err)
+	}
+
+	if err := NewChainVerifier(setup).VerifyReader(bytes.NewReader(nil)); err != ErrEmptyChain {
+		t.Errorf("VerifyReader() of an empty stream = %v, want %v", err, ErrEmptyChain)
+	}
+}
@@ -0,0 +1,396 @@
+// Package notus runs the epochs of a Notus proof of liabilities. Every epoch the EpochManager removes the old
+// leaves of the updated users from the accumulator, Acc_old = Acc_mid^{x1}, inserts their new leaves,
+// Acc_new = Acc_mid^{x2}, and proves the update with the zkmultiswap SNARK and two PoKE proofs sharing its challenges.
+// The state is kept in a store.Store, committing the new state advances the epoch.
+package notus
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"math/bits"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+	"github.com/jiajunxin/rsa_accumulator/store"
+	"github.com/jiajunxin/rsa_accumulator/trace"
+	"github.com/jiajunxin/rsa_accumulator/zkmultiswap"
+)
+
+var (
+	// ErrInvalidBatch is returned for a batch of changes the circuit cannot prove, e.g. a repeated user
+	ErrInvalidBatch = errors.New("invalid batch of balance changes")
+	// ErrUnknownUser is returned when a change is for a user without a leaf
+	ErrUnknownUser = errors.New("unknown user")
+	// ErrBalanceOverflow is returned when the sum of the balances does not fit into zkmultiswap.BitLength bits
+	ErrBalanceOverflow = errors.New("sum of balances overflows")
+	// ErrNotInitialized is returned when an epoch is advanced before Genesis
+	ErrNotInitialized = errors.New("accumulator not initialized by Genesis")
+	// ErrAlreadyInitialized is returned when Genesis is run on a store with epochs
+	ErrAlreadyInitialized = errors.New("accumulator already initialized")
+	// ErrStateMismatch is returned when the stored accumulator does not match its leaves and randomizer
+	ErrStateMismatch = errors.New("stored accumulator does not match its leaves")
+	// ErrUnsupportedConfig is returned for a circuit configuration other than zkmultiswap.Config32
+	ErrUnsupportedConfig = errors.New("unsupported circuit configuration, only 32-bit user IDs and balances")
+)
+
+// Change sets the balance of a user
+type Change struct {
+	UserID  uint32
+	Balance uint32
+}
+
+// Config configures an EpochManager
+type Config struct {
+	Setup *accumulator.Setup
+	// Trapdoor is optional, with the factorization of N the exponentiations use CRT
+	Trapdoor *accumulator.TrapdoorSetup
+	Store    store.Store
+	// BatchSize is the size of the circuit whose keys are used. Smaller batches are filled up with users
+	// keeping their balances. If BatchSize is 0, every batch is proven with the circuit of its size.
+	BatchSize int
+	// NumWorkers is the number of goroutines computing the witnesses, all CPUs if <= 0
+	NumWorkers int
+	// Circuit is the configuration of the zkmultiswap circuit, the zero Config is zkmultiswap.Config32.
+	// Change and the leaves in the store hold uint32 values, any other configuration is rejected.
+	Circuit zkmultiswap.Config
+}
+
+// EpochManager advances the accumulator epoch by epoch. Its methods may be called from several goroutines,
+// the epochs are run one after the other.
+type EpochManager struct {
+	mu   sync.Mutex
+	cfg  Config
+	opts []trace.Option
+}
+
+// NewEpochManager returns a manager of the accumulator in cfg.Store, the options are passed to the SNARK prover
+func NewEpochManager(cfg *Config, opts ...trace.Option) (*EpochManager, error) {
+	if cfg == nil || cfg.Setup == nil || cfg.Store == nil || cfg.BatchSize < 0 || cfg.BatchSize == 1 {
+		return nil, ErrInvalidBatch
+	}
+	if cfg.Trapdoor != nil && cfg.Trapdoor.Setup.N.Cmp(cfg.Setup.N) != 0 {
+		return nil, accumulator.ErrInvalidInput
+	}
+	if cfg.Circuit != (zkmultiswap.Config{}) && cfg.Circuit != zkmultiswap.Config32 {
+		return nil, ErrUnsupportedConfig
+	}
+	return &EpochManager{cfg: *cfg, opts: opts}, nil
+}
+
+// State returns a copy of the current state
+func (m *EpochManager) State() *store.State {
+	return m.cfg.Store.State()
+}
+
+// Genesis accumulates the first leaves of the users with the balances as epoch 1, the store must be empty.
+// The previous hashes of the first leaves are random, hiding the balances of the first epoch.
+// The report of the genesis epoch has no proofs, it is the starting point of the chain of epochs, it is committed
+// together with the state.
+func (m *EpochManager) Genesis(balances []Change) (*EpochReport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state := m.cfg.Store.State()
+	if state.Epoch != 0 || len(state.Leaves) != 0 {
+		return nil, ErrAlreadyInitialized
+	}
+	changes, err := sortChanges(balances)
+	if err != nil {
+		return nil, err
+	}
+	const epoch = 1
+	leaves := make([]zkmultiswap.Leaf, len(changes))
+	for i, v := range changes {
+		leaves[i] = zkmultiswap.Leaf{UserID: v.UserID, Balance: v.Balance, Epoch: epoch}
+		prevHash, err := rand.Int(rand.Reader, fr.Modulus())
+		if err != nil {
+			return nil, err
+		}
+		leaves[i].PrevHash.Set(prevHash)
+	}
+	if _, err = sumLeaves(leaves); err != nil {
+		return nil, err
+	}
+	randomizer, err := genRandomizer()
+	if err != nil {
+		return nil, err
+	}
+
+	reps := representatives(leaves, m.opts)
+	base := m.exp(m.cfg.Setup.G, zkmultiswap.RandomizerProduct(randomizer))
+	acc := m.exp(base, accumulator.SetProductRecursiveFast(reps))
+	report := &EpochReport{Epoch: epoch, AccNew: new(big.Int).Set(acc)}
+	update := &store.Update{
+		Epoch:      epoch,
+		Acc:        acc,
+		Leaves:     leaves,
+		Witnesses:  m.proveMembership(base, reps),
+		Randomizer: randomizer,
+	}
+	if err = m.commit(update, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Advance runs the next epoch with the changes of the balances and returns its report. The state in the store
+// is only replaced after all proofs are generated, on an error the epoch is not advanced. The report is committed
+// together with the state, LastReport returns it again.
+func (m *EpochManager) Advance(changes []Change) (*EpochReport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state := m.cfg.Store.State()
+	if state.Epoch == 0 || state.Randomizer == nil {
+		return nil, ErrNotInitialized
+	}
+	changes, err := sortChanges(changes)
+	if err != nil {
+		return nil, err
+	}
+	if changes, err = m.fillBatch(state, changes); err != nil {
+		return nil, err
+	}
+	epoch := state.Epoch + 1
+
+	// the leaves of the updated users before and after the update
+	updated := make([]bool, len(state.Leaves))
+	oldLeaves := make([]zkmultiswap.Leaf, len(changes))
+	newLeaves := make([]zkmultiswap.Leaf, len(changes))
+	for i, v := range changes {
+		idx := state.Index(v.UserID)
+		if idx < 0 {
+			return nil, ErrUnknownUser
+		}
+		updated[idx] = true
+		oldLeaves[i] = state.Leaves[idx]
+		newLeaves[i] = oldLeaves[i].Next(v.Balance, epoch)
+	}
+	var unchangedLeaves []zkmultiswap.Leaf
+	for i := range state.Leaves {
+		if !updated[i] {
+			unchangedLeaves = append(unchangedLeaves, state.Leaves[i])
+		}
+	}
+	originalSum, err := sumLeaves(state.Leaves)
+	if err != nil {
+		return nil, err
+	}
+	updatedSum, err := sumLeaves(append(append([]zkmultiswap.Leaf{}, unchangedLeaves...), newLeaves...))
+	if err != nil {
+		return nil, err
+	}
+	randomizer, err := genRandomizer()
+	if err != nil {
+		return nil, err
+	}
+
+	setup := m.cfg.Setup
+	removed := representatives(oldLeaves, m.opts)
+	inserted := representatives(newLeaves, m.opts)
+	unchanged := representatives(unchangedLeaves, m.opts)
+	prod1 := accumulator.SetProductRecursiveFast(removed)
+	prod1.Mul(prod1, zkmultiswap.RandomizerProduct(state.Randomizer))
+	prod2 := accumulator.SetProductRecursiveFast(inserted)
+	prod2.Mul(prod2, zkmultiswap.RandomizerProduct(randomizer))
+
+	// Acc_mid accumulates the unchanged leaves without randomizer
+	accMid := new(big.Int).Set(setup.G)
+	if len(unchanged) > 0 {
+		accMid = m.exp(setup.G, accumulator.SetProductRecursiveFast(unchanged))
+	}
+	if m.exp(accMid, prod1).Cmp(state.Acc) != 0 {
+		return nil, ErrStateMismatch
+	}
+	accNew := m.exp(accMid, prod2)
+
+	transcript := zkmultiswap.SetupTranscript(setup, state.Acc, accMid, accNew, epoch)
+	challengeL1 := transcript.GetChallengeAndAppendTranscript()
+	challengeL2 := transcript.GetChallengeAndAppendTranscript()
+	var quotient1, quotient2, remainder1, remainder2 big.Int
+	quotient1.DivMod(prod1, challengeL1, &remainder1)
+	quotient2.DivMod(prod2, challengeL2, &remainder2)
+
+	input := &zkmultiswap.UpdateSet32{
+		CurrentEpochNum:  epoch,
+		OriginalSum:      originalSum,
+		UpdatedSum:       updatedSum,
+		UserID:           make([]uint32, len(changes)),
+		OriginalBalances: make([]uint32, len(changes)),
+		OriginalHashes:   make([]big.Int, len(changes)),
+		OriginalUpdEpoch: make([]uint32, len(changes)),
+		UpdatedBalances:  make([]uint32, len(changes)),
+	}
+	input.ChallengeL1.Set(challengeL1)
+	input.ChallengeL2.Set(challengeL2)
+	input.RemainderR1.Set(&remainder1)
+	input.RemainderR2.Set(&remainder2)
+	input.DeltaModL1.Mod(accumulator.Min1024, challengeL1)
+	input.DeltaModL2.Mod(accumulator.Min1024, challengeL2)
+	input.Randomizer1.Set(state.Randomizer)
+	input.Randomizer2.Set(randomizer)
+	for i := range changes {
+		input.UserID[i] = oldLeaves[i].UserID
+		input.OriginalBalances[i] = oldLeaves[i].Balance
+		input.OriginalHashes[i].Set(&oldLeaves[i].PrevHash)
+		input.OriginalUpdEpoch[i] = oldLeaves[i].Epoch
+		input.UpdatedBalances[i] = newLeaves[i].Balance
+	}
+	proof, err := zkmultiswap.Prove(input, m.opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// the witnesses of all leaves in Acc_new, in the order of the user IDs
+	next, err := state.Apply(&store.Update{Epoch: epoch, Acc: accNew, Leaves: newLeaves})
+	if err != nil {
+		return nil, err
+	}
+	base := m.exp(setup.G, zkmultiswap.RandomizerProduct(randomizer))
+	update := &store.Update{
+		Epoch:      epoch,
+		Acc:        accNew,
+		Leaves:     newLeaves,
+		Witnesses:  m.proveMembership(base, representatives(next.Leaves, m.opts)),
+		Randomizer: randomizer,
+	}
+	report := &EpochReport{
+		Epoch:      epoch,
+		BatchSize:  uint32(len(changes)),
+		AccOld:     new(big.Int).Set(state.Acc),
+		AccMid:     accMid,
+		AccNew:     new(big.Int).Set(accNew),
+		PublicInfo: *input.PublicPart(),
+		QOld:       m.exp(accMid, &quotient1),
+		QNew:       m.exp(accMid, &quotient2),
+		Proof:      *proof,
+	}
+	if err = m.commit(update, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// LastReport returns the report of the current epoch as committed with its state, e.g. to publish it again
+// after the process stopped before the report returned by Genesis or Advance was published
+func (m *EpochManager) LastReport() (*EpochReport, error) {
+	state := m.cfg.Store.State()
+	if state.Epoch == 0 {
+		return nil, ErrNotInitialized
+	}
+	if len(state.Report) == 0 {
+		return nil, ErrInvalidReport
+	}
+	report := new(EpochReport)
+	if err := report.UnmarshalBinary(state.Report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// commit persists the update together with the encoded report, the epoch is advanced iff it returns nil
+func (m *EpochManager) commit(update *store.Update, report *EpochReport) error {
+	data, err := report.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	update.Report = data
+	return m.cfg.Store.Commit(update)
+}
+
+// fillBatch adds changes keeping the balances of the users with the smallest IDs until the batch has BatchSize changes
+func (m *EpochManager) fillBatch(state *store.State, changes []Change) ([]Change, error) {
+	size := m.cfg.BatchSize
+	if size == 0 {
+		size = len(changes)
+	}
+	if len(changes) > size || size < 2 || size > len(state.Leaves) {
+		return nil, ErrInvalidBatch
+	}
+	if len(changes) == size {
+		return changes, nil
+	}
+	ret := append([]Change{}, changes...)
+	for i := 0; i < len(state.Leaves) && len(ret) < size; i++ {
+		leaf := &state.Leaves[i]
+		j := sort.Search(len(changes), func(k int) bool { return changes[k].UserID >= leaf.UserID })
+		if j < len(changes) && changes[j].UserID == leaf.UserID {
+			continue
+		}
+		ret = append(ret, Change{UserID: leaf.UserID, Balance: leaf.Balance})
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].UserID < ret[j].UserID })
+	return ret, nil
+}
+
+func (m *EpochManager) exp(base, x *big.Int) *big.Int {
+	if m.cfg.Trapdoor != nil {
+		return m.cfg.Trapdoor.Exp(base, x)
+	}
+	return accumulator.AccumulateNew(base, x, m.cfg.Setup.N)
+}
+
+func (m *EpochManager) proveMembership(base *big.Int, reps []*big.Int) []*big.Int {
+	tracer := trace.NewOptions(m.opts...).Tracer
+	tracer.StartPhase(trace.PhasePrecompute)
+	defer tracer.EndPhase(trace.PhasePrecompute)
+	if m.cfg.Trapdoor != nil {
+		return m.cfg.Trapdoor.ProveMembership(base, reps)
+	}
+	numWorkers := m.cfg.NumWorkers
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+	// ProveMembershipParallel runs on up to 2^limit goroutines
+	limit := bits.Len(uint(numWorkers - 1))
+	return accumulator.ProveMembershipParallel(base, m.cfg.Setup.N, reps, limit)
+}
+
+// representatives returns the DI hashes of the leaves
+func representatives(leaves []zkmultiswap.Leaf, opts []trace.Option) []*big.Int {
+	tracer := trace.NewOptions(opts...).Tracer
+	tracer.StartPhase(trace.PhaseGenRepresentatives)
+	defer tracer.EndPhase(trace.PhaseGenRepresentatives)
+	ret := make([]*big.Int, len(leaves))
+	for i := range leaves {
+		ret[i] = leaves[i].Representative()
+	}
+	return ret
+}
+
+// sortChanges returns the changes sorted by user ID, ErrInvalidBatch if a user is repeated
+func sortChanges(changes []Change) ([]Change, error) {
+	ret := append([]Change{}, changes...)
+	sort.Slice(ret, func(i, j int) bool { return ret[i].UserID < ret[j].UserID })
+	for i := 1; i < len(ret); i++ {
+		if ret[i].UserID == ret[i-1].UserID {
+			return nil, ErrInvalidBatch
+		}
+	}
+	return ret, nil
+}
+
+func sumLeaves(leaves []zkmultiswap.Leaf) (uint32, error) {
+	var sum uint64
+	for i := range leaves {
+		sum += uint64(leaves[i].Balance)
+	}
+	if sum >= 1<<zkmultiswap.BitLength {
+		return 0, ErrBalanceOverflow
+	}
+	return uint32(sum), nil
+}
+
+// genRandomizer returns a random positive randomizer of zkmultiswap.BitLength bits, the size checked by the circuit
+func genRandomizer() (*big.Int, error) {
+	bound := new(big.Int).Lsh(big.NewInt(1), zkmultiswap.BitLength)
+	bound.Sub(bound, big.NewInt(1))
+	ret, err := rand.Int(rand.Reader, bound)
+	if err != nil {
+		return nil, err
+	}
+	return ret.Add(ret, big.NewInt(1)), nil
+}
@@ -0,0 +1,275 @@
+package notus
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+	"github.com/jiajunxin/rsa_accumulator/store"
+	"github.com/jiajunxin/rsa_accumulator/zkmultiswap"
+)
+
+const testBatchSize = 2
+
+// TestMain generates the SNARK keys of testBatchSize in a temporary directory, the keys are read from the working directory
+func TestMain(m *testing.M) {
+	os.Exit(runInKeyDir(m))
+}
+
+func runInKeyDir(m *testing.M) int {
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	dir, err := os.MkdirTemp("", "notus")
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+	if err = os.Chdir(dir); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	defer os.Chdir(wd)
+	if err = zkmultiswap.SetupZkMultiswapWithError(testBatchSize); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	return m.Run()
+}
+
+func newTestManager(t *testing.T, s store.Store) *EpochManager {
+	t.Helper()
+	m, err := NewEpochManager(&Config{Setup: accumulator.TrustedSetup(), Store: s, BatchSize: testBatchSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestEpochManager(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	dir := t.TempDir()
+	s, err := store.Open(dir, setup, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newTestManager(t, s)
+	genesis, err := m.Genesis([]Change{{UserID: 9, Balance: 90}, {UserID: 3, Balance: 30}, {UserID: 5, Balance: 50}, {UserID: 7, Balance: 70}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !genesis.IsGenesis() || genesis.Epoch != 1 {
+		t.Fatalf("genesis report of epoch %d with batch size %d", genesis.Epoch, genesis.BatchSize)
+	}
+	if err = genesis.Verify(setup); err != nil {
+		t.Fatal(err)
+	}
+
+	prev := genesis
+	for _, changes := range [][]Change{
+		{{UserID: 5, Balance: 55}},
+		{{UserID: 9, Balance: 0}, {UserID: 3, Balance: 45}},
+	} {
+		report, err := m.Advance(changes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Epoch != prev.Epoch+1 || report.AccOld.Cmp(prev.AccNew) != 0 {
+			t.Errorf("epoch %d does not continue epoch %d", report.Epoch, prev.Epoch)
+		}
+		if err = report.Verify(setup); err != nil {
+			t.Fatalf("epoch %d: %v", report.Epoch, err)
+		}
+		data, err := report.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded EpochReport
+		if err = decoded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if err = decoded.Verify(setup); err != nil {
+			t.Fatalf("decoded epoch %d: %v", report.Epoch, err)
+		}
+		prev = report
+	}
+
+	state := m.State()
+	if state.Epoch != 3 || state.Acc.Cmp(prev.AccNew) != 0 {
+		t.Fatalf("state of epoch %d, want the accumulator of epoch 3", state.Epoch)
+	}
+	balances := map[uint32]uint32{3: 45, 5: 55, 7: 70, 9: 0}
+	for _, leaf := range state.Leaves {
+		if leaf.Balance != balances[leaf.UserID] {
+			t.Errorf("user %d has balance %d, want %d", leaf.UserID, leaf.Balance, balances[leaf.UserID])
+		}
+	}
+	// user 3 filled the batch of epoch 2 and was updated again in epoch 3, user 7 kept the genesis leaf
+	want := []uint32{3, 2, 1, 3}
+	for i, leaf := range state.Leaves {
+		if leaf.Epoch != want[i] {
+			t.Errorf("user %d has a leaf of epoch %d, want %d", leaf.UserID, leaf.Epoch, want[i])
+		}
+	}
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// the witnesses are verified on Open
+	s, err = store.Open(dir, setup, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if s.State().Acc.Cmp(state.Acc) != 0 {
+		t.Error("restored accumulator differs")
+	}
+}
+
+func TestEpochManagerSnapshotFailure(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	dir := t.TempDir()
+	s, err := store.Open(dir, setup, &store.Options{SnapshotInterval: 1, NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newTestManager(t, s)
+	if _, err = m.LastReport(); err != ErrNotInitialized {
+		t.Errorf("LastReport() before Genesis() = %v, want %v", err, ErrNotInitialized)
+	}
+	if _, err = m.Genesis([]Change{{UserID: 1, Balance: 10}, {UserID: 2, Balance: 20}}); err != nil {
+		t.Fatal(err)
+	}
+	// the temporary snapshot of the store cannot be created over a directory
+	if err = os.Mkdir(filepath.Join(dir, "snapshot.bin.tmp"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	report, err := m.Advance([]Change{{UserID: 2, Balance: 25}})
+	if err != nil {
+		t.Fatalf("Advance() with a failing snapshot = %v", err)
+	}
+	if s.SnapshotErr() == nil {
+		t.Fatal("the snapshot did not fail")
+	}
+	if m.State().Epoch != 2 {
+		t.Fatalf("state of epoch %d after Advance(), want 2", m.State().Epoch)
+	}
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the report is recovered from the log
+	s, err = store.Open(dir, setup, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	last, err := newTestManager(t, s).LastReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := report.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := last.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("LastReport() differs from the report returned by Advance()")
+	}
+	if err = last.Verify(setup); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEpochManagerErrors(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	m := newTestManager(t, store.NewMemoryStore(store.NewState(setup)))
+	if _, err := m.Advance([]Change{{UserID: 1}}); err != ErrNotInitialized {
+		t.Errorf("Advance() before Genesis() = %v, want %v", err, ErrNotInitialized)
+	}
+	if _, err := m.Genesis([]Change{{UserID: 1, Balance: 1 << 31}, {UserID: 2, Balance: 1 << 31}}); err != ErrBalanceOverflow {
+		t.Errorf("Genesis() with too large balances = %v, want %v", err, ErrBalanceOverflow)
+	}
+	if _, err := m.Genesis([]Change{{UserID: 1, Balance: 10}, {UserID: 2, Balance: 20}, {UserID: 4, Balance: 40}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Genesis([]Change{{UserID: 1}}); err != ErrAlreadyInitialized {
+		t.Errorf("Genesis() twice = %v, want %v", err, ErrAlreadyInitialized)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		changes []Change
+		want    error
+	}{
+		{"repeated user", []Change{{UserID: 1, Balance: 1}, {UserID: 1, Balance: 2}}, ErrInvalidBatch},
+		{"unknown user", []Change{{UserID: 3, Balance: 1}}, ErrUnknownUser},
+		{"batch too large", []Change{{UserID: 1}, {UserID: 2}, {UserID: 4}}, ErrInvalidBatch},
+		{"overflow", []Change{{UserID: 1, Balance: 1<<32 - 1}}, ErrBalanceOverflow},
+	} {
+		if _, err := m.Advance(tc.changes); err != tc.want {
+			t.Errorf("%s: Advance() = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+	if m.State().Epoch != 1 {
+		t.Error("a failed Advance() changed the epoch")
+	}
+
+	if _, err := NewEpochManager(&Config{Setup: setup, Store: store.NewMemoryStore(store.NewState(setup)), BatchSize: 1}); err != ErrInvalidBatch {
+		t.Errorf("NewEpochManager() with batch size 1 = %v, want %v", err, ErrInvalidBatch)
+	}
+	cfg := &Config{Setup: setup, Store: store.NewMemoryStore(store.NewState(setup)), BatchSize: testBatchSize, Circuit: zkmultiswap.Config64}
+	if _, err := NewEpochManager(cfg); err != ErrUnsupportedConfig {
+		t.Errorf("NewEpochManager() with 64-bit values = %v, want %v", err, ErrUnsupportedConfig)
+	}
+	cfg.Circuit = zkmultiswap.Config32
+	if _, err := NewEpochManager(cfg); err != nil {
+		t.Errorf("NewEpochManager() with 32-bit values = %v", err)
+	}
+}
+
+func TestEpochReportVerify(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	m := newTestManager(t, store.NewMemoryStore(store.NewState(setup)))
+	if _, err := m.Genesis([]Change{{UserID: 1, Balance: 10}, {UserID: 2, Balance: 20}, {UserID: 3, Balance: 30}}); err != nil {
+		t.Fatal(err)
+	}
+	first, err := m.Advance([]Change{{UserID: 1, Balance: 11}, {UserID: 2, Balance: 19}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := m.Advance([]Change{{UserID: 2, Balance: 18}, {UserID: 3, Balance: 31}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := *second
+	tampered.AccNew = new(big.Int).Set(first.AccNew)
+	if err = tampered.Verify(setup); err != ErrInvalidChallenge {
+		t.Errorf("Verify() with another Acc_new = %v, want %v", err, ErrInvalidChallenge)
+	}
+	tampered = *second
+	tampered.QOld = new(big.Int).Set(second.QNew)
+	if err = tampered.Verify(setup); err != ErrInvalidPoKE {
+		t.Errorf("Verify() with a wrong PoKE = %v, want %v", err, ErrInvalidPoKE)
+	}
+	tampered = *second
+	tampered.Proof = first.Proof
+	if err = tampered.Verify(setup); err != ErrInvalidSNARK {
+		t.Errorf("Verify() with the SNARK of another epoch = %v, want %v", err, ErrInvalidSNARK)
+	}
+	tampered = *second
+	tampered.Epoch++
+	if err = tampered.Verify(setup); err != ErrInvalidReport {
+		t.Errorf("Verify() with another epoch = %v, want %v", err, ErrInvalidReport)
+	}
+}
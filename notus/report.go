@@ -0,0 +1,170 @@
+package notus
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+	"github.com/jiajunxin/rsa_accumulator/codec"
+	"github.com/jiajunxin/rsa_accumulator/trace"
+	"github.com/jiajunxin/rsa_accumulator/zkmultiswap"
+)
+
+var (
+	// ErrInvalidReport is returned for a report with missing or out of range values
+	ErrInvalidReport = errors.New("invalid epoch report")
+	// ErrInvalidChallenge is returned when the public information of the SNARK does not match the transcript of the epoch
+	ErrInvalidChallenge = errors.New("challenges do not match the transcript")
+	// ErrInvalidPoKE is returned when Acc_old or Acc_new is not proven to be a power of Acc_mid
+	ErrInvalidPoKE = errors.New("invalid PoKE proof")
+	// ErrInvalidSNARK is returned when the zkmultiswap proof does not verify
+	ErrInvalidSNARK = errors.New("invalid zkmultiswap proof")
+)
+
+// EpochReport is the public output of one epoch. The PoKE proofs QOld and QNew prove the knowledge of x1 and x2
+// with QOld^{L1} * AccMid^{R1} = AccOld and QNew^{L2} * AccMid^{R2} = AccNew, the challenges L1, L2 and the remainders
+// R1, R2 being the public inputs of the SNARK, which proves that x1 and x2 are the products of the removed and the
+// inserted leaves. The genesis report has BatchSize 0 and only AccNew.
+type EpochReport struct {
+	Epoch      uint32
+	BatchSize  uint32
+	AccOld     *big.Int
+	AccMid     *big.Int
+	AccNew     *big.Int
+	PublicInfo zkmultiswap.PublicInfo
+	QOld       *big.Int
+	QNew       *big.Int
+	Proof      groth16.Proof
+}
+
+// IsGenesis returns true for the report of the first epoch
+func (r *EpochReport) IsGenesis() bool {
+	return r.BatchSize == 0
+}
+
+// Verify checks the transcript, the PoKE proofs and the SNARK of the report. The genesis report has nothing to verify
+// but its accumulator being in the group. The verifying key of zkmultiswap.Config32 and the batch size is loaded
+// from the current directory.
+func (r *EpochReport) Verify(setup *accumulator.Setup, opts ...trace.Option) error {
+	if r.AccNew == nil || r.AccNew.Sign() <= 0 || r.AccNew.Cmp(setup.N) >= 0 {
+		return ErrInvalidReport
+	}
+	if r.IsGenesis() {
+		return nil
+	}
+	if r.Proof == nil || codec.CheckReduced(setup.N, r.AccOld, r.AccMid, r.QOld, r.QNew) != nil ||
+		r.AccOld.Sign() == 0 || r.AccMid.Sign() == 0 || r.PublicInfo.CurrentEpochNum != r.Epoch {
+		return ErrInvalidReport
+	}
+
+	info := &r.PublicInfo
+	transcript := zkmultiswap.SetupTranscript(setup, r.AccOld, r.AccMid, r.AccNew, r.Epoch)
+	challengeL1 := transcript.GetChallengeAndAppendTranscript()
+	challengeL2 := transcript.GetChallengeAndAppendTranscript()
+	var deltaModL1, deltaModL2 big.Int
+	deltaModL1.Mod(accumulator.Min1024, challengeL1)
+	deltaModL2.Mod(accumulator.Min1024, challengeL2)
+	if info.ChallengeL1.Cmp(challengeL1) != 0 || info.ChallengeL2.Cmp(challengeL2) != 0 ||
+		info.DeltaModL1.Cmp(&deltaModL1) != 0 || info.DeltaModL2.Cmp(&deltaModL2) != 0 ||
+		info.RemainderR1.Sign() < 0 || info.RemainderR1.Cmp(challengeL1) >= 0 ||
+		info.RemainderR2.Sign() < 0 || info.RemainderR2.Cmp(challengeL2) >= 0 {
+		return ErrInvalidChallenge
+	}
+
+	if !verifyPoKE(setup.N, r.AccMid, r.AccOld, r.QOld, challengeL1, &info.RemainderR1) ||
+		!verifyPoKE(setup.N, r.AccMid, r.AccNew, r.QNew, challengeL2, &info.RemainderR2) {
+		return ErrInvalidPoKE
+	}
+
+	ok, err := zkmultiswap.VerifyWithConfig(&r.Proof, r.BatchSize, zkmultiswap.Config32, info, opts...)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidSNARK
+	}
+	return nil
+}
+
+// verifyPoKE returns true if q^l * base^rem = acc mod n
+func verifyPoKE(n, base, acc, q, l, rem *big.Int) bool {
+	var lhs, rhs big.Int
+	lhs.Exp(q, l, n)
+	rhs.Exp(base, rem, n)
+	lhs.Mul(&lhs, &rhs)
+	lhs.Mod(&lhs, n)
+	return lhs.Cmp(acc) == 0
+}
+
+// MarshalBinary encodes the report in the versioned binary format of package codec
+func (r *EpochReport) MarshalBinary() ([]byte, error) {
+	enc := codec.NewEncoder(codec.TypeEpochReport)
+	enc.PutUint32(r.Epoch)
+	enc.PutUint32(r.BatchSize)
+	enc.PutBigInt(r.AccNew)
+	if r.IsGenesis() {
+		return enc.Bytes(), nil
+	}
+	if r.Proof == nil {
+		return nil, ErrInvalidReport
+	}
+	var proof bytes.Buffer
+	if _, err := r.Proof.WriteRawTo(&proof); err != nil {
+		return nil, fmt.Errorf("encode proof: %w", err)
+	}
+	info := &r.PublicInfo
+	enc.PutBigInts([]*big.Int{r.AccOld, r.AccMid, r.QOld, r.QNew, &info.ChallengeL1, &info.ChallengeL2,
+		&info.RemainderR1, &info.RemainderR2, &info.DeltaModL1, &info.DeltaModL2})
+	enc.PutBytes(proof.Bytes())
+	return enc.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the report encoded by MarshalBinary
+func (r *EpochReport) UnmarshalBinary(data []byte) error {
+	dec := codec.NewDecoder(data, codec.TypeEpochReport)
+	var ret EpochReport
+	ret.Epoch = dec.Uint32()
+	ret.BatchSize = dec.Uint32()
+	ret.AccNew = dec.Nat()
+	if ret.IsGenesis() {
+		if err := dec.Finish(); err != nil {
+			return err
+		}
+		*r = ret
+		return nil
+	}
+	values := dec.BigInts()
+	proofBytes := dec.Bytes()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	if len(values) != 10 {
+		return codec.ErrInvalidEncoding
+	}
+	for _, v := range values {
+		if v.Sign() < 0 {
+			return codec.ErrInvalidEncoding
+		}
+	}
+	proof := groth16.NewProof(ecc.BN254)
+	if n, err := proof.ReadFrom(bytes.NewReader(proofBytes)); err != nil || n != int64(len(proofBytes)) {
+		return codec.ErrInvalidEncoding
+	}
+	ret.AccOld, ret.AccMid, ret.QOld, ret.QNew = values[0], values[1], values[2], values[3]
+	info := &ret.PublicInfo
+	info.ChallengeL1.Set(values[4])
+	info.ChallengeL2.Set(values[5])
+	info.RemainderR1.Set(values[6])
+	info.RemainderR2.Set(values[7])
+	info.DeltaModL1.Set(values[8])
+	info.DeltaModL2.Set(values[9])
+	info.CurrentEpochNum = ret.Epoch
+	ret.Proof = proof
+	*r = ret
+	return nil
+}
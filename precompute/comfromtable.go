@@ -1,11 +1,21 @@
 package precompute
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"sync"
 
-	"github.com/jiajunxin/rsa_accumulator/accumulator"
+	"github.com/jiajunxin/rsa_accumulator/group"
+)
+
+var (
+	// ErrInvalidTableSize is returned when the table size is not positive or not smaller than the bit length
+	ErrInvalidTableSize = errors.New("invalid table size, larger than input bitLen")
+	// ErrInvalidTable is returned when the pre-compute table is empty or unbalanced
+	ErrInvalidTable = errors.New("invalid pre-compute table")
+	// ErrInvalidExponent is returned when the exponent is not positive
+	ErrInvalidExponent = errors.New("invalid x, not positive")
 )
 
 var (
@@ -16,9 +26,10 @@ var (
 
 // PreTable only allows to pre-compute a power of 2 for the base g.
 // base[0] should be g and n[0] is 0.
-// base[i] = g^{2^{n[i]}} mod N
+// base[i] = g^{2^{n[i]}} in the group of the table
 type PreTable struct {
-	base []*big.Int
+	grp  group.Group
+	base []group.Element
 	n    []int
 }
 
@@ -30,53 +41,66 @@ func PrintTable(p *PreTable) {
 	}
 }
 
-// GenPreTable generates a precomputation table for RSA accumulators
+// GenPreTable generates a precomputation table for RSA accumulators, it panics on invalid sizes
 func GenPreTable(base, N *big.Int, bitLen, tableSize int) *PreTable {
-	if bitLen <= tableSize {
-		panic("invalid table size, larger than intput bitLen")
+	table, err := GenPreTableWithError(base, N, bitLen, tableSize)
+	if err != nil {
+		panic(err)
+	}
+	return table
+}
+
+// GenPreTableWithError generates a precomputation table for RSA accumulators,
+// ErrInvalidTableSize is returned if tableSize is not in [1, bitLen)
+func GenPreTableWithError(base, N *big.Int, bitLen, tableSize int) (*PreTable, error) {
+	return GenPreTableInGroup(&group.RSA{N: N}, new(big.Int).Set(base), bitLen, tableSize)
+}
+
+// GenPreTableInGroup generates a precomputation table for the base in any group,
+// ErrInvalidTableSize is returned if tableSize is not in [1, bitLen)
+func GenPreTableInGroup(grp group.Group, base group.Element, bitLen, tableSize int) (*PreTable, error) {
+	if tableSize < 1 || bitLen <= tableSize {
+		return nil, ErrInvalidTableSize
 	}
 	var table PreTable
-	table.base = make([]*big.Int, tableSize)
+	table.grp = grp
+	table.base = make([]group.Element, tableSize)
 	table.n = make([]int, tableSize)
 
 	stepSize := bitLen / tableSize
 
-	table.base[0] = new(big.Int)
-	table.base[0].Set(base)
+	table.base[0] = base
 	table.n[0] = 0
 
 	var step big.Int
 	step.Exp(big2, big.NewInt(int64(stepSize)), nil)
 	for i := 1; i < tableSize; i++ {
 		table.n[i] = table.n[i-1] + stepSize
-		table.base[i] = accumulator.AccumulateNew(table.base[i-1],
-			&step, N)
+		table.base[i] = grp.Exp(table.base[i-1], &step)
 	}
 
-	return &table
+	return &table, nil
 }
 
-// ComputeFromTable computes g^x mod N with the pre-computation table.
-func ComputeFromTable(table *PreTable, x, N *big.Int) *big.Int {
+// checkTableInput returns an error if the table or the exponent cannot be used by ComputeFromTable
+func checkTableInput(table *PreTable, x *big.Int) error {
 	// Todo: more checks for the validity of the table
-	if len(table.base) != len(table.n) {
-		panic("invalid pre-compute table, unbalanced")
-	}
-	if len(table.base) < 1 {
-		panic("invalid pre-compute table, too small")
+	if table == nil || len(table.base) != len(table.n) || len(table.base) < 1 {
+		return ErrInvalidTable
 	}
 	if x.Cmp(big0) < 1 {
-		panic("invalid x, negative")
+		return ErrInvalidExponent
 	}
+	return nil
+}
 
-	// Now, we divide x according to the n
+// splitExponent divides x according to the n of the table, so that g^x = prod base[i]^{subX[i]}
+func splitExponent(table *PreTable, x *big.Int) []*big.Int {
 	// We first find out how many sub part can we separate x according to the table
 	length := x.BitLen()
 	var iCounter int
 	var xCopy big.Int
 	xCopy.Set(x)
-	// fmt.Println("len(table.n) = ", len(table.n))
-	// fmt.Println("len(x) = ", length)
 	for iCounter = 0; iCounter < len(table.n); iCounter++ {
 		if table.n[iCounter] >= length {
 			break
@@ -86,122 +110,107 @@ func ComputeFromTable(table *PreTable, x, N *big.Int) *big.Int {
 		iCounter--
 	}
 
-	// fmt.Println("iCounter = ", iCounter)
-	subX := make([]big.Int, iCounter+2)
-
+	subX := make([]*big.Int, iCounter+1)
 	for i := 1; i < iCounter+1; i++ {
 		var modulo big.Int
 		modulo.Exp(big2, big.NewInt(int64(table.n[i]-table.n[i-1])), nil)
-		subX[i-1].Mod(&xCopy, &modulo)
-		//fmt.Println("subX[i-1] = ", subX[i-1].String())
+		subX[i-1] = new(big.Int).Mod(&xCopy, &modulo)
 		xCopy.Rsh(&xCopy, uint(table.n[i]-table.n[i-1]))
 	}
-	subX[iCounter].Set(&xCopy)
-	//fmt.Println("subX[iCounter+1] = ", subX[iCounter+1].String())
-
-	// --------------start of test code------------------------------
-	//the following code tests the if the separation for x is correct or not
-	// var prod, temp big.Int
-	// for i := 0; i < iCounter+1; i++ {
-	// 	if i == 0 {
-	// 		prod.Add(&prod, &subX[i])
-	// 		continue
-	// 	}
-	// 	temp.Exp(big2, big.NewInt(int64(table.n[i])), nil)
-	// 	temp.Mul(&temp, &subX[i])
-	// 	prod.Add(&prod, &temp)
-	// }
-	// fmt.Println("prod = ", prod.String())
-	// fmt.Println("x = ", x.String())
-	// --------------end of test code------------------------------
+	subX[iCounter] = &xCopy
+	return subX
+}
 
-	// The next part can be paralleled
-	var prod big.Int
-	prod.SetInt64(1)
-	for i := 0; i < iCounter+1; i++ {
-		if i == 0 {
-			temp := accumulator.AccumulateNew(table.base[0], &subX[0], N)
-			prod.Mul(&prod, temp)
-			continue
-		}
-		temp := accumulator.AccumulateNew(table.base[i], &subX[i], N)
-		prod.Mul(&prod, temp)
-		prod.Mod(&prod, N)
+// ComputeFromTable computes g^x mod N with the pre-computation table, it panics on invalid input.
+func ComputeFromTable(table *PreTable, x, N *big.Int) *big.Int {
+	ret, err := ComputeFromTableWithError(table, x, N)
+	if err != nil {
+		panic(err)
 	}
-	return &prod
+	return ret
 }
 
-// ComputeFromTableParallel computes g^x mod N with the pre-computation table in parallel.
-func ComputeFromTableParallel(table *PreTable, x, N *big.Int) *big.Int {
-	// Todo: more checks for the validity of the table
-	if len(table.base) != len(table.n) {
-		panic("invalid pre-compute table, unbalanced")
+// ComputeFromTableWithError computes g^x mod N with the pre-computation table, the table must be generated over Z*_N.
+func ComputeFromTableWithError(table *PreTable, x, N *big.Int) (*big.Int, error) {
+	ret, err := computeFromTable(&group.RSA{N: N}, table, x)
+	if err != nil {
+		return nil, err
 	}
-	if len(table.base) < 1 {
-		panic("invalid pre-compute table, too small")
+	return ret.(*big.Int), nil
+}
+
+// ComputeFromTableInGroup computes g^x in the group of the pre-computation table.
+func ComputeFromTableInGroup(table *PreTable, x *big.Int) (group.Element, error) {
+	if table == nil {
+		return nil, ErrInvalidTable
 	}
-	if x.Cmp(big0) < 1 {
-		panic("invalid x, negative")
+	return computeFromTable(table.grp, table, x)
+}
+
+func computeFromTable(grp group.Group, table *PreTable, x *big.Int) (group.Element, error) {
+	if err := checkTableInput(table, x); err != nil {
+		return nil, err
 	}
+	subX := splitExponent(table, x)
+	return grp.MultiExp(table.base[:len(subX)], subX), nil
+}
 
-	// Now, we divide x according to the n
-	// We first find out how many sub part can we separate x according to the table
-	length := x.BitLen()
-	var iCounter int
-	var xCopy big.Int
-	xCopy.Set(x)
-	for iCounter = 0; iCounter < len(table.n); iCounter++ {
-		if table.n[iCounter] >= length {
-			break
-		}
+// ComputeFromTableParallel computes g^x mod N with the pre-computation table in parallel, it panics on invalid input.
+func ComputeFromTableParallel(table *PreTable, x, N *big.Int) *big.Int {
+	ret, err := ComputeFromTableParallelWithError(table, x, N)
+	if err != nil {
+		panic(err)
 	}
-	if iCounter == len(table.n) {
-		iCounter--
+	return ret
+}
+
+// ComputeFromTableParallelWithError computes g^x mod N with the pre-computation table in parallel,
+// the table must be generated over Z*_N.
+func ComputeFromTableParallelWithError(table *PreTable, x, N *big.Int) (*big.Int, error) {
+	ret, err := computeFromTableParallel(&group.RSA{N: N}, table, x)
+	if err != nil {
+		return nil, err
 	}
+	return ret.(*big.Int), nil
+}
 
-	subX := make([]big.Int, iCounter+2)
-	for i := 1; i < iCounter+1; i++ {
-		var modulo big.Int
-		modulo.Exp(big2, big.NewInt(int64(table.n[i]-table.n[i-1])), nil)
-		subX[i-1].Mod(&xCopy, &modulo)
-		xCopy.Rsh(&xCopy, uint(table.n[i]-table.n[i-1]))
+// ComputeFromTableParallelInGroup computes g^x in the group of the pre-computation table in parallel.
+func ComputeFromTableParallelInGroup(table *PreTable, x *big.Int) (group.Element, error) {
+	if table == nil {
+		return nil, ErrInvalidTable
 	}
-	subX[iCounter].Set(&xCopy)
+	return computeFromTableParallel(table.grp, table, x)
+}
+
+func computeFromTableParallel(grp group.Group, table *PreTable, x *big.Int) (group.Element, error) {
+	if err := checkTableInput(table, x); err != nil {
+		return nil, err
+	}
+	subX := splitExponent(table, x)
 
 	// The next part can be paralleled
-	var prod big.Int
-	prod.SetInt64(1)
-	c := make(chan *big.Int, iCounter)
-	for i := 0; i < iCounter+1; i++ {
-		if i == 0 {
-			go getAccumulate(table.base[0], &subX[0], N, c)
-			// temp := accumulator.AccumulateNew(table.base[0], &subX[0], N)
-			// prod.Mul(&prod, temp)
-			continue
-		}
-		go getAccumulate(table.base[i], &subX[i], N, c)
-		//temp := accumulator.AccumulateNew(table.base[i], &subX[i], N)
+	c := make(chan group.Element, len(subX))
+	for i := range subX {
+		go getAccumulate(grp, table.base[i], subX[i], c)
 	}
 
 	var mutex sync.Mutex
+	prod := grp.Identity()
 	i := 0
 	for v := range c {
 		mutex.Lock()
-		prod.Mul(&prod, v)
-		prod.Mod(&prod, N)
+		prod = grp.Op(prod, v)
 		i++
 		mutex.Unlock()
-		if i == iCounter+1 {
+		if i == len(subX) {
 			close(c)
 			break
 		}
 	}
 
-	return &prod
+	return prod, nil
 }
 
-func getAccumulate(base, exp, N *big.Int, c chan *big.Int) {
-	var ret big.Int
-	ret.Exp(base, exp, N)
-	c <- &ret
+func getAccumulate(grp group.Group, base group.Element, exp *big.Int, c chan group.Element) {
+	c <- grp.Exp(base, exp)
 }
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/jiajunxin/rsa_accumulator/accumulator"
+	"github.com/jiajunxin/rsa_accumulator/group"
 )
 
 const (
@@ -192,3 +193,59 @@ func BenchmarkPrecompute(b *testing.B) {
 //
 //	}
 //}
+
+func TestComputeFromTableWithError(t *testing.T) {
+	setup := getSetup()
+	if _, err := GenPreTableWithError(setup.G, setup.N, 16, 16); err != ErrInvalidTableSize {
+		t.Errorf("expected ErrInvalidTableSize, got %v", err)
+	}
+	if _, err := GenPreTableWithError(setup.G, setup.N, 16, 0); err != ErrInvalidTableSize {
+		t.Errorf("expected ErrInvalidTableSize, got %v", err)
+	}
+	table, err := GenPreTableWithError(setup.G, setup.N, 64, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := big.NewInt(123456789)
+	got, err := ComputeFromTableWithError(table, x, setup.N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := new(big.Int).Exp(setup.G, x, setup.N); got.Cmp(want) != 0 {
+		t.Errorf("ComputeFromTableWithError() = %v, want %v", got, want)
+	}
+	if _, err = ComputeFromTableWithError(table, big.NewInt(0), setup.N); err != ErrInvalidExponent {
+		t.Errorf("expected ErrInvalidExponent, got %v", err)
+	}
+	if _, err = ComputeFromTableParallelWithError(&PreTable{}, x, setup.N); err != ErrInvalidTable {
+		t.Errorf("expected ErrInvalidTable, got %v", err)
+	}
+}
+
+func TestComputeFromTableInGroup(t *testing.T) {
+	grp, err := group.NewClassGroupFromSeed([]byte("precompute test"), 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := grp.Generator()
+	table, err := GenPreTableInGroup(grp, g, 64, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := new(big.Int).SetUint64(0xfedcba9876543210)
+	want := grp.Exp(g, x)
+	got, err := ComputeFromTableInGroup(table, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !grp.Equal(got, want) {
+		t.Errorf("ComputeFromTableInGroup() = %v, want %v", got, want)
+	}
+	got, err = ComputeFromTableParallelInGroup(table, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !grp.Equal(got, want) {
+		t.Errorf("ComputeFromTableParallelInGroup() = %v, want %v", got, want)
+	}
+}
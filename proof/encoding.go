@@ -0,0 +1,205 @@
+package proof
+
+import (
+	"math/big"
+
+	"github.com/jiajunxin/rsa_accumulator/codec"
+)
+
+// MarshalBinary encodes the proof in the versioned binary format of package codec
+func (p *PoKEStarProof) MarshalBinary() ([]byte, error) {
+	if p.Q == nil || p.R == nil {
+		return nil, codec.ErrInvalidEncoding
+	}
+	enc := codec.NewEncoder(codec.TypePoKEStarProof)
+	enc.PutBigInt(p.Q)
+	enc.PutBigInt(p.R)
+	return enc.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the proof encoded by MarshalBinary
+func (p *PoKEStarProof) UnmarshalBinary(data []byte) error {
+	dec := codec.NewDecoder(data, codec.TypePoKEStarProof)
+	q := dec.Nat()
+	r := dec.Nat()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	p.Q, p.R = q, r
+	return nil
+}
+
+// CheckReduced checks that the group elements of the proof are reduced modulo n
+func (p *PoKEStarProof) CheckReduced(n *big.Int) error {
+	return codec.CheckReduced(n, p.Q)
+}
+
+// MarshalBinary encodes the proof in the versioned binary format of package codec
+func (p *ZKPoKEProof) MarshalBinary() ([]byte, error) {
+	values := []*big.Int{p.z, p.Ag, p.Au, p.Qg, p.Qu, p.rx, p.rrho}
+	enc := codec.NewEncoder(codec.TypeZKPoKEProof)
+	for _, v := range values {
+		if v == nil {
+			return nil, codec.ErrInvalidEncoding
+		}
+		enc.PutBigInt(v)
+	}
+	return enc.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the proof encoded by MarshalBinary
+func (p *ZKPoKEProof) UnmarshalBinary(data []byte) error {
+	dec := codec.NewDecoder(data, codec.TypeZKPoKEProof)
+	var ret ZKPoKEProof
+	for _, v := range []**big.Int{&ret.z, &ret.Ag, &ret.Au, &ret.Qg, &ret.Qu, &ret.rx, &ret.rrho} {
+		*v = dec.Nat()
+	}
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	*p = ret
+	return nil
+}
+
+// CheckReduced checks that the group elements of the proof are reduced modulo n
+func (p *ZKPoKEProof) CheckReduced(n *big.Int) error {
+	return codec.CheckReduced(n, p.z, p.Ag, p.Au, p.Qg, p.Qu)
+}
+
+// MarshalBinary encodes the proof in the versioned binary format of package codec
+func (p *PoEProof) MarshalBinary() ([]byte, error) {
+	if p.Q == nil {
+		return nil, codec.ErrInvalidEncoding
+	}
+	enc := codec.NewEncoder(codec.TypePoEProof)
+	enc.PutBigInt(p.Q)
+	return enc.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the proof encoded by MarshalBinary
+func (p *PoEProof) UnmarshalBinary(data []byte) error {
+	dec := codec.NewDecoder(data, codec.TypePoEProof)
+	q := dec.Nat()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	p.Q = q
+	return nil
+}
+
+// CheckReduced checks that the group elements of the proof are reduced modulo n
+func (p *PoEProof) CheckReduced(n *big.Int) error {
+	return codec.CheckReduced(n, p.Q)
+}
+
+// MarshalBinary encodes the proof in the versioned binary format of package codec
+func (r *RangeProof) MarshalBinary() ([]byte, error) {
+	if r.c == nil || r.response == nil || r.response.TAU == nil || !isInt3Set(r.commit3) ||
+		!isInt4Set(r.response.Z4) || !isInt4Set(r.response.T4) {
+		return nil, codec.ErrInvalidEncoding
+	}
+	enc := codec.NewEncoder(codec.TypeRangeProof)
+	enc.PutBigInt(r.c)
+	for _, v := range r.commit3 {
+		enc.PutBigInt(v)
+	}
+	enc.PutBytes(r.commitment[:])
+	for _, v := range r.response.Z4 {
+		enc.PutBigInt(v)
+	}
+	for _, v := range r.response.T4 {
+		enc.PutBigInt(v)
+	}
+	enc.PutBigInt(r.response.TAU)
+	return enc.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the proof encoded by MarshalBinary
+func (r *RangeProof) UnmarshalBinary(data []byte) error {
+	dec := codec.NewDecoder(data, codec.TypeRangeProof)
+	c := dec.Nat()
+	var commit3 Int3
+	for i := range commit3 {
+		commit3[i] = dec.Nat()
+	}
+	var commitment rpCommitment
+	copy(commitment[:], dec.FixedBytes(rpCommitLen))
+	response := new(rpResponse)
+	for i := range response.Z4 {
+		response.Z4[i] = dec.BigInt()
+	}
+	for i := range response.T4 {
+		response.T4[i] = dec.BigInt()
+	}
+	response.TAU = dec.BigInt()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	*r = *NewRangeProof(c, commit3, commitment, response)
+	return nil
+}
+
+// CheckReduced checks that the commitments of the proof are reduced modulo n
+func (r *RangeProof) CheckReduced(n *big.Int) error {
+	return codec.CheckReduced(n, r.c, r.commit3[0], r.commit3[1], r.commit3[2])
+}
+
+// MarshalBinary encodes the argument in the versioned binary format of package codec
+func (a *ArgOfPositivity) MarshalBinary() ([]byte, error) {
+	if a.response == nil || a.response.T == nil || !isInt3Set(a.commit3) ||
+		!isInt3Set(a.response.Z3) || !isInt3Set(a.response.T3) {
+		return nil, codec.ErrInvalidEncoding
+	}
+	enc := codec.NewEncoder(codec.TypeArgOfPositivity)
+	for _, v := range a.commit3 {
+		enc.PutBigInt(v)
+	}
+	enc.PutBytes(a.commitment[:])
+	for _, v := range a.response.Z3 {
+		enc.PutBigInt(v)
+	}
+	for _, v := range a.response.T3 {
+		enc.PutBigInt(v)
+	}
+	enc.PutBigInt(a.response.T)
+	return enc.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the argument encoded by MarshalBinary
+func (a *ArgOfPositivity) UnmarshalBinary(data []byte) error {
+	dec := codec.NewDecoder(data, codec.TypeArgOfPositivity)
+	var commit3 Int3
+	for i := range commit3 {
+		commit3[i] = dec.Nat()
+	}
+	var commitment zkAoPCommitment
+	copy(commitment[:], dec.FixedBytes(zkAoPCommitLen))
+	response := new(zkAoPResponse)
+	for i := range response.Z3 {
+		response.Z3[i] = dec.BigInt()
+	}
+	for i := range response.T3 {
+		response.T3[i] = dec.BigInt()
+	}
+	response.T = dec.BigInt()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	*a = *NewArgOfPositivity(commit3, commitment, response)
+	return nil
+}
+
+// CheckReduced checks that the commitments of the argument are reduced modulo n
+func (a *ArgOfPositivity) CheckReduced(n *big.Int) error {
+	return codec.CheckReduced(n, a.commit3[0], a.commit3[1], a.commit3[2])
+}
+
+// isInt3Set returns true if none of the three integers is nil
+func isInt3Set(f Int3) bool {
+	return f[0] != nil && f[1] != nil && f[2] != nil
+}
+
+// isInt4Set returns true if none of the four integers is nil
+func isInt4Set(f Int4) bool {
+	return f[0] != nil && f[1] != nil && f[2] != nil && f[3] != nil
+}
@@ -0,0 +1,71 @@
+package proof
+
+import (
+	"math/big"
+
+	fiatshamir "github.com/jiajunxin/rsa_accumulator/fiat-shamir"
+	"github.com/jiajunxin/rsa_accumulator/group"
+)
+
+// The following proofs work over any group of unknown order,
+// PoEProve and PoKEStarProve are the same proofs over Z*_N.
+
+// GroupPoEProof contains the proofs for PoE in a group
+type GroupPoEProof struct {
+	Q group.Element
+}
+
+// GroupPoKEStarProof contains the proofs for PoKE* in a group
+type GroupPoKEStarProof struct {
+	Q group.Element
+	R *big.Int
+}
+
+// PoEProveInGroup proves base^x = C in the group
+func PoEProveInGroup(grp group.Group, base, C group.Element, x *big.Int) (*GroupPoEProof, error) {
+	if x.Sign() < 0 || !grp.Equal(grp.Exp(base, x), C) {
+		return nil, ErrInvalidStatement
+	}
+	transcript := fiatshamir.InitTranscript([]string{"PoE", base.String(), grp.String(), C.String(), x.String()}, fiatshamir.Max252)
+	l := transcript.GetPrimeChallengeUsingTranscript()
+	var q big.Int
+	q.Div(x, l)
+	return &GroupPoEProof{Q: grp.Exp(base, &q)}, nil
+}
+
+// PoEVerifyInGroup checks the proof, returns true if everything is good
+func PoEVerifyInGroup(grp group.Group, base, C group.Element, x *big.Int, proof *GroupPoEProof) bool {
+	if proof == nil || x.Sign() < 0 || !grp.IsElement(proof.Q) {
+		return false
+	}
+	transcript := fiatshamir.InitTranscript([]string{"PoE", base.String(), grp.String(), C.String(), x.String()}, fiatshamir.Max252)
+	l := transcript.GetPrimeChallengeUsingTranscript()
+	var r big.Int
+	r.Mod(x, l)
+	return grp.Equal(grp.MultiExp([]group.Element{proof.Q, base}, []*big.Int{l, &r}), C)
+}
+
+// PoKEStarProveInGroup proves knowledge of x s.t. g^x = C in the group, g must be a fixed public element
+func PoKEStarProveInGroup(grp group.Group, g, C group.Element, x *big.Int) (*GroupPoKEStarProof, error) {
+	if !grp.Equal(grp.Exp(g, x), C) {
+		return nil, ErrInvalidStatement
+	}
+	transcript := fiatshamir.InitTranscript([]string{"PoKEStar", g.String(), grp.String(), C.String()}, fiatshamir.Max252)
+	l := transcript.GetPrimeChallengeUsingTranscript()
+	var q, r big.Int
+	q.DivMod(x, l, &r)
+	return &GroupPoKEStarProof{Q: grp.Exp(g, &q), R: &r}, nil
+}
+
+// PoKEStarVerifyInGroup checks the proof, returns true if everything is good
+func PoKEStarVerifyInGroup(grp group.Group, g, C group.Element, proof *GroupPoKEStarProof) bool {
+	if proof == nil || proof.R == nil || !grp.IsElement(proof.Q) {
+		return false
+	}
+	transcript := fiatshamir.InitTranscript([]string{"PoKEStar", g.String(), grp.String(), C.String()}, fiatshamir.Max252)
+	l := transcript.GetPrimeChallengeUsingTranscript()
+	if proof.R.Sign() < 0 || proof.R.Cmp(l) >= 0 {
+		return false
+	}
+	return grp.Equal(grp.MultiExp([]group.Element{proof.Q, g}, []*big.Int{l, proof.R}), C)
+}
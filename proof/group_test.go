@@ -0,0 +1,72 @@
+package proof
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/group"
+)
+
+func testClassGroup(t *testing.T) (*group.ClassGroup, group.Element) {
+	t.Helper()
+	g, err := group.NewClassGroupFromSeed([]byte("proof test"), 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g, g.Generator()
+}
+
+func TestPoEInGroup(t *testing.T) {
+	grp, g := testClassGroup(t)
+	x := new(big.Int).Lsh(big.NewInt(12345), 400)
+	C := grp.Exp(g, x)
+	proof, err := PoEProveInGroup(grp, g, C, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !PoEVerifyInGroup(grp, g, C, x, proof) {
+		t.Error("valid PoE rejected")
+	}
+	if PoEVerifyInGroup(grp, g, C, new(big.Int).Add(x, big.NewInt(1)), proof) {
+		t.Error("PoE for another exponent accepted")
+	}
+	if _, err = PoEProveInGroup(grp, g, g, x); err != ErrInvalidStatement {
+		t.Errorf("PoEProveInGroup() error = %v, want %v", err, ErrInvalidStatement)
+	}
+
+	// over Z*_N the proof is the same as PoEProve
+	n, _ := new(big.Int).SetString("1000036000099", 10)
+	rsa := &group.RSA{N: n}
+	base := big.NewInt(4)
+	rsaC := new(big.Int).Exp(base, x, n)
+	expected, err := PoEProve(base, n, rsaC, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaProof, err := PoEProveInGroup(rsa, base, rsaC, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rsa.Equal(rsaProof.Q, expected.Q) {
+		t.Error("PoE over Z*_N differs from PoEProve")
+	}
+}
+
+func TestPoKEStarInGroup(t *testing.T) {
+	grp, g := testClassGroup(t)
+	x := new(big.Int).Lsh(big.NewInt(54321), 300)
+	C := grp.Exp(g, x)
+	proof, err := PoKEStarProveInGroup(grp, g, C, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !PoKEStarVerifyInGroup(grp, g, C, proof) {
+		t.Error("valid PoKE* rejected")
+	}
+	if PoKEStarVerifyInGroup(grp, g, grp.Op(C, g), proof) {
+		t.Error("PoKE* for another statement accepted")
+	}
+	if _, err = PoKEStarProveInGroup(grp, g, g, x); err != ErrInvalidStatement {
+		t.Errorf("PoKEStarProveInGroup() error = %v, want %v", err, ErrInvalidStatement)
+	}
+}
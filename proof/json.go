@@ -0,0 +1,341 @@
+package proof
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+
+	"github.com/jiajunxin/rsa_accumulator/codec"
+)
+
+// type names in the JSON encodings
+const (
+	jsonTypePublicParameters = "PublicParameters"
+	jsonTypePoKEStarProof    = "PoKEStarProof"
+	jsonTypeZKPoKEProof      = "ZKPoKEProof"
+	jsonTypePoEProof         = "PoEProof"
+	jsonTypeRangeProof       = "RangeProof"
+	jsonTypeArgOfPositivity  = "ArgOfPositivity"
+)
+
+type publicParametersJSON struct {
+	codec.Header
+	N string `json:"n"`
+	G string `json:"g"`
+	H string `json:"h"`
+}
+
+// MarshalJSON encodes the public parameters as JSON with hex integers
+func (pp *PublicParameters) MarshalJSON() ([]byte, error) {
+	if pp.N == nil || pp.G == nil || pp.H == nil {
+		return nil, codec.ErrInvalidEncoding
+	}
+	return json.Marshal(publicParametersJSON{
+		Header: codec.NewHeader(jsonTypePublicParameters),
+		N:      codec.EncodeHex(pp.N),
+		G:      codec.EncodeHex(pp.G),
+		H:      codec.EncodeHex(pp.H),
+	})
+}
+
+// UnmarshalJSON decodes the public parameters encoded by MarshalJSON, G and H must be reduced modulo N
+func (pp *PublicParameters) UnmarshalJSON(data []byte) error {
+	var v publicParametersJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.Check(jsonTypePublicParameters); err != nil {
+		return err
+	}
+	values, err := codec.DecodeNatHexList(v.N, v.G, v.H)
+	if err != nil {
+		return err
+	}
+	if err = codec.CheckReduced(values[0], values[1], values[2]); err != nil {
+		return err
+	}
+	pp.N, pp.G, pp.H = values[0], values[1], values[2]
+	return nil
+}
+
+type pokeStarProofJSON struct {
+	codec.Header
+	Q string `json:"q"`
+	R string `json:"r"`
+}
+
+// MarshalJSON encodes the proof as JSON with hex integers
+func (p *PoKEStarProof) MarshalJSON() ([]byte, error) {
+	if p.Q == nil || p.R == nil {
+		return nil, codec.ErrInvalidEncoding
+	}
+	return json.Marshal(pokeStarProofJSON{
+		Header: codec.NewHeader(jsonTypePoKEStarProof),
+		Q:      codec.EncodeHex(p.Q),
+		R:      codec.EncodeHex(p.R),
+	})
+}
+
+// UnmarshalJSON decodes the proof encoded by MarshalJSON
+func (p *PoKEStarProof) UnmarshalJSON(data []byte) error {
+	var v pokeStarProofJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.Check(jsonTypePoKEStarProof); err != nil {
+		return err
+	}
+	values, err := codec.DecodeNatHexList(v.Q, v.R)
+	if err != nil {
+		return err
+	}
+	p.Q, p.R = values[0], values[1]
+	return nil
+}
+
+type zkPoKEProofJSON struct {
+	codec.Header
+	Z    string `json:"z"`
+	Ag   string `json:"ag"`
+	Au   string `json:"au"`
+	Qg   string `json:"qg"`
+	Qu   string `json:"qu"`
+	Rx   string `json:"rx"`
+	Rrho string `json:"rrho"`
+}
+
+// MarshalJSON encodes the proof as JSON with hex integers
+func (p *ZKPoKEProof) MarshalJSON() ([]byte, error) {
+	values := []*big.Int{p.z, p.Ag, p.Au, p.Qg, p.Qu, p.rx, p.rrho}
+	for _, v := range values {
+		if v == nil {
+			return nil, codec.ErrInvalidEncoding
+		}
+	}
+	return json.Marshal(zkPoKEProofJSON{
+		Header: codec.NewHeader(jsonTypeZKPoKEProof),
+		Z:      codec.EncodeHex(p.z),
+		Ag:     codec.EncodeHex(p.Ag),
+		Au:     codec.EncodeHex(p.Au),
+		Qg:     codec.EncodeHex(p.Qg),
+		Qu:     codec.EncodeHex(p.Qu),
+		Rx:     codec.EncodeHex(p.rx),
+		Rrho:   codec.EncodeHex(p.rrho),
+	})
+}
+
+// UnmarshalJSON decodes the proof encoded by MarshalJSON
+func (p *ZKPoKEProof) UnmarshalJSON(data []byte) error {
+	var v zkPoKEProofJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.Check(jsonTypeZKPoKEProof); err != nil {
+		return err
+	}
+	values, err := codec.DecodeNatHexList(v.Z, v.Ag, v.Au, v.Qg, v.Qu, v.Rx, v.Rrho)
+	if err != nil {
+		return err
+	}
+	p.z, p.Ag, p.Au, p.Qg, p.Qu, p.rx, p.rrho = values[0], values[1], values[2], values[3], values[4], values[5], values[6]
+	return nil
+}
+
+type poeProofJSON struct {
+	codec.Header
+	Q string `json:"q"`
+}
+
+// MarshalJSON encodes the proof as JSON with hex integers
+func (p *PoEProof) MarshalJSON() ([]byte, error) {
+	if p.Q == nil {
+		return nil, codec.ErrInvalidEncoding
+	}
+	return json.Marshal(poeProofJSON{
+		Header: codec.NewHeader(jsonTypePoEProof),
+		Q:      codec.EncodeHex(p.Q),
+	})
+}
+
+// UnmarshalJSON decodes the proof encoded by MarshalJSON
+func (p *PoEProof) UnmarshalJSON(data []byte) error {
+	var v poeProofJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.Check(jsonTypePoEProof); err != nil {
+		return err
+	}
+	q, err := codec.DecodeNatHex(v.Q)
+	if err != nil {
+		return err
+	}
+	p.Q = q
+	return nil
+}
+
+type rangeProofJSON struct {
+	codec.Header
+	C          string    `json:"c"`
+	Commit3    [3]string `json:"commit3"`
+	Commitment string    `json:"commitment"`
+	Z4         [4]string `json:"z4"`
+	T4         [4]string `json:"t4"`
+	Tau        string    `json:"tau"`
+}
+
+// MarshalJSON encodes the proof as JSON with hex integers and the hex commitment
+func (r *RangeProof) MarshalJSON() ([]byte, error) {
+	if r.c == nil || r.response == nil || r.response.TAU == nil || !isInt3Set(r.commit3) ||
+		!isInt4Set(r.response.Z4) || !isInt4Set(r.response.T4) {
+		return nil, codec.ErrInvalidEncoding
+	}
+	return json.Marshal(rangeProofJSON{
+		Header:     codec.NewHeader(jsonTypeRangeProof),
+		C:          codec.EncodeHex(r.c),
+		Commit3:    encodeHexInt3(r.commit3),
+		Commitment: hex.EncodeToString(r.commitment[:]),
+		Z4:         encodeHexInt4(r.response.Z4),
+		T4:         encodeHexInt4(r.response.T4),
+		Tau:        codec.EncodeHex(r.response.TAU),
+	})
+}
+
+// UnmarshalJSON decodes the proof encoded by MarshalJSON
+func (r *RangeProof) UnmarshalJSON(data []byte) error {
+	var v rangeProofJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.Check(jsonTypeRangeProof); err != nil {
+		return err
+	}
+	c, err := codec.DecodeNatHex(v.C)
+	if err != nil {
+		return err
+	}
+	commit3, err := decodeNatHexInt3(v.Commit3)
+	if err != nil {
+		return err
+	}
+	var commitment rpCommitment
+	if err = decodeHexBytes(commitment[:], v.Commitment); err != nil {
+		return err
+	}
+	response := new(rpResponse)
+	if response.Z4, err = decodeHexInt4(v.Z4); err != nil {
+		return err
+	}
+	if response.T4, err = decodeHexInt4(v.T4); err != nil {
+		return err
+	}
+	if response.TAU, err = codec.DecodeHex(v.Tau); err != nil {
+		return err
+	}
+	*r = *NewRangeProof(c, commit3, commitment, response)
+	return nil
+}
+
+type argOfPositivityJSON struct {
+	codec.Header
+	Commit3    [3]string `json:"commit3"`
+	Commitment string    `json:"commitment"`
+	Z3         [3]string `json:"z3"`
+	T3         [3]string `json:"t3"`
+	T          string    `json:"t"`
+}
+
+// MarshalJSON encodes the argument as JSON with hex integers and the hex commitment
+func (a *ArgOfPositivity) MarshalJSON() ([]byte, error) {
+	if a.response == nil || a.response.T == nil || !isInt3Set(a.commit3) ||
+		!isInt3Set(a.response.Z3) || !isInt3Set(a.response.T3) {
+		return nil, codec.ErrInvalidEncoding
+	}
+	return json.Marshal(argOfPositivityJSON{
+		Header:     codec.NewHeader(jsonTypeArgOfPositivity),
+		Commit3:    encodeHexInt3(a.commit3),
+		Commitment: hex.EncodeToString(a.commitment[:]),
+		Z3:         encodeHexInt3(a.response.Z3),
+		T3:         encodeHexInt3(a.response.T3),
+		T:          codec.EncodeHex(a.response.T),
+	})
+}
+
+// UnmarshalJSON decodes the argument encoded by MarshalJSON
+func (a *ArgOfPositivity) UnmarshalJSON(data []byte) error {
+	var v argOfPositivityJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.Check(jsonTypeArgOfPositivity); err != nil {
+		return err
+	}
+	commit3, err := decodeNatHexInt3(v.Commit3)
+	if err != nil {
+		return err
+	}
+	var commitment zkAoPCommitment
+	if err = decodeHexBytes(commitment[:], v.Commitment); err != nil {
+		return err
+	}
+	response := new(zkAoPResponse)
+	if response.Z3, err = decodeHexInt3(v.Z3); err != nil {
+		return err
+	}
+	if response.T3, err = decodeHexInt3(v.T3); err != nil {
+		return err
+	}
+	if response.T, err = codec.DecodeHex(v.T); err != nil {
+		return err
+	}
+	*a = *NewArgOfPositivity(commit3, commitment, response)
+	return nil
+}
+
+func encodeHexInt3(f Int3) [3]string {
+	return [3]string{codec.EncodeHex(f[0]), codec.EncodeHex(f[1]), codec.EncodeHex(f[2])}
+}
+
+func encodeHexInt4(f Int4) [4]string {
+	return [4]string{codec.EncodeHex(f[0]), codec.EncodeHex(f[1]), codec.EncodeHex(f[2]), codec.EncodeHex(f[3])}
+}
+
+func decodeHexInt3(s [3]string) (Int3, error) {
+	var ret Int3
+	values, err := codec.DecodeHexList(s[:]...)
+	if err != nil {
+		return ret, err
+	}
+	copy(ret[:], values)
+	return ret, nil
+}
+
+func decodeNatHexInt3(s [3]string) (Int3, error) {
+	var ret Int3
+	values, err := codec.DecodeNatHexList(s[:]...)
+	if err != nil {
+		return ret, err
+	}
+	copy(ret[:], values)
+	return ret, nil
+}
+
+func decodeHexInt4(s [4]string) (Int4, error) {
+	var ret Int4
+	values, err := codec.DecodeHexList(s[:]...)
+	if err != nil {
+		return ret, err
+	}
+	copy(ret[:], values)
+	return ret, nil
+}
+
+// decodeHexBytes decodes the lower case hex string into dst, the length must match exactly
+func decodeHexBytes(dst []byte, s string) error {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(dst) || hex.EncodeToString(b) != s {
+		return codec.ErrInvalidHex
+	}
+	copy(dst, b)
+	return nil
+}
@@ -0,0 +1,124 @@
+package proof_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+	"github.com/jiajunxin/rsa_accumulator/codec"
+	"github.com/jiajunxin/rsa_accumulator/internal/golden"
+	"github.com/jiajunxin/rsa_accumulator/proof"
+)
+
+func testStatement() (*proof.PublicParameters, *big.Int, *big.Int, *big.Int) {
+	setup := accumulator.TrustedSetup()
+	pp := proof.NewPublicParameters(setup.N, setup.G, setup.H)
+	x := big.NewInt(123456789)
+	r := big.NewInt(987654321)
+	return pp, x, r, new(big.Int).Exp(pp.G, x, pp.N)
+}
+
+func TestPublicParametersJSON(t *testing.T) {
+	pp, _, _, _ := testStatement()
+	data := golden.JSON(t, "public_parameters.json", pp)
+	golden.CheckJSON(t, data, pp)
+	var decoded proof.PublicParameters
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.N.Cmp(pp.N) != 0 || decoded.G.Cmp(pp.G) != 0 || decoded.H.Cmp(pp.H) != 0 {
+		t.Errorf("decoded public parameters do not match")
+	}
+	var poe proof.PoEProof
+	if err := json.Unmarshal(data, &poe); err != codec.ErrWrongType {
+		t.Errorf("expected ErrWrongType, got %v", err)
+	}
+}
+
+func TestPoEProofJSON(t *testing.T) {
+	pp, x, _, _ := testStatement()
+	// the exponent must be larger than the challenge to make the quotient non-trivial
+	x.Exp(x, big.NewInt(40), nil)
+	C := new(big.Int).Exp(pp.G, x, pp.N)
+	poe, err := proof.PoEProve(pp.G, pp.N, C, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := golden.JSON(t, "poe_proof.json", poe)
+	golden.CheckJSON(t, data, poe)
+	var decoded proof.PoEProof
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !proof.PoEVerify(pp.G, pp.N, C, x, &decoded) {
+		t.Errorf("decoded PoE proof rejected")
+	}
+}
+
+func TestZKPoKEProofJSON(t *testing.T) {
+	pp, x, _, C := testStatement()
+	var v interface{}
+	if golden.Update() {
+		zkpoke, err := proof.ZKPoKEProve(pp, pp.G, x, C)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v = zkpoke
+	}
+	data := golden.JSON(t, "zkpoke_proof.json", v)
+	var decoded proof.ZKPoKEProof
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !proof.ZKPoKEVerify(pp, pp.G, C, &decoded) {
+		t.Errorf("decoded ZKPoKE proof rejected")
+	}
+	golden.CheckJSON(t, data, &decoded)
+}
+
+func TestRangeProofJSON(t *testing.T) {
+	pp, x, r, _ := testStatement()
+	a, b := big.NewInt(0), big.NewInt(1<<30)
+	var v interface{}
+	if golden.Update() {
+		rp, err := proof.NewRPProver(pp, r, a, b).Prove(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v = rp
+	}
+	data := golden.JSON(t, "range_proof.json", v)
+	var decoded proof.RangeProof
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !proof.NewRPVerifier(pp, a, b).Verify(&decoded) {
+		t.Errorf("decoded range proof rejected")
+	}
+	golden.CheckJSON(t, data, &decoded)
+}
+
+func TestArgOfPositivityJSON(t *testing.T) {
+	pp, x, r, _ := testStatement()
+	var v interface{}
+	if golden.Update() {
+		aop, err := proof.NewZKAoPProver(pp, r).Prove(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v = aop
+	}
+	data := golden.JSON(t, "arg_of_positivity.json", v)
+	var decoded proof.ArgOfPositivity
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	c := new(big.Int).Exp(pp.G, x, pp.N)
+	c.Mul(c, new(big.Int).Exp(pp.H, r, pp.N))
+	c.Mod(c, pp.N)
+	if !proof.NewZKAoPVerifier(pp, c).Verify(&decoded) {
+		t.Errorf("decoded argument of positivity rejected")
+	}
+	golden.CheckJSON(t, data, &decoded)
+}
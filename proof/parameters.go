@@ -3,6 +3,8 @@ package proof
 import (
 	"crypto/rand"
 	"math/big"
+
+	"github.com/jiajunxin/rsa_accumulator/group"
 )
 
 const (
@@ -28,6 +30,18 @@ func NewPublicParameters(n, g, h *big.Int) *PublicParameters {
 	}
 }
 
+// areUnits returns true if the values are in Z*_N. The verifiers raise them to negative exponents,
+// which panics for a value sharing a factor with N.
+func areUnits(n *big.Int, values ...*big.Int) bool {
+	grp := &group.RSA{N: n}
+	for _, v := range values {
+		if v == nil || !grp.IsElement(v) {
+			return false
+		}
+	}
+	return true
+}
+
 // Int4 is the 4-number big integer group
 type Int4 [int4Len]*big.Int
 
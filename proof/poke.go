@@ -10,16 +10,16 @@ import (
 	"math/big"
 
 	fiatshamir "github.com/jiajunxin/rsa_accumulator/fiat-shamir"
+	"github.com/jiajunxin/rsa_accumulator/group"
 )
 
+// ErrInvalidStatement is returned when the prover inputs do not satisfy the statement to prove
+var ErrInvalidStatement = errors.New("invalid statement")
+
 // MultiExp computes g^x * h^r mod n
 func MultiExp(g, x, h, r, n *big.Int) *big.Int {
-	var temp1, temp2 big.Int
-	temp1.Exp(g, x, n)
-	temp2.Exp(h, r, n)
-	temp1.Mul(&temp1, &temp2)
-	temp1.Mod(&temp1, n)
-	return &temp1
+	grp := &group.RSA{N: n}
+	return grp.MultiExp([]group.Element{g, h}, []*big.Int{x, r}).(*big.Int)
 }
 
 // PoKEStarProof contains the proofs for PoKE
@@ -30,33 +30,19 @@ type PoKEStarProof struct {
 
 // PoKEStarProve proves knowledge of x s.t.  g^x = C
 func PoKEStarProve(pp *PublicParameters, C, x *big.Int) (*PoKEStarProof, error) {
-	var ret PoKEStarProof
-	ret.Q = new(big.Int)
-	ret.R = new(big.Int)
-	var temp, q, l big.Int
-	temp.Exp(pp.G, x, pp.N)
-	if temp.Cmp(C) != 0 {
-		return nil, errors.New("PoKEStar inputs a invalid statement")
+	p, err := PoKEStarProveInGroup(&group.RSA{N: pp.N}, pp.G, C, x)
+	if err != nil {
+		return nil, err
 	}
-
-	transcript := fiatshamir.InitTranscript([]string{"PoKEStar", pp.G.String(), pp.N.String(), C.String()}, fiatshamir.Max252)
-	l.Set(transcript.GetPrimeChallengeUsingTranscript())
-	q.DivMod(x, &l, ret.R)
-	ret.Q.Exp(pp.G, &q, pp.N)
-	return &ret, nil
+	return &PoKEStarProof{Q: p.Q.(*big.Int), R: p.R}, nil
 }
 
 // PoKEStarVerify checks the proof, returns true if everything is good
 func PoKEStarVerify(pp *PublicParameters, C *big.Int, proof *PoKEStarProof) bool {
-	if proof == nil {
+	if proof == nil || proof.CheckReduced(pp.N) != nil {
 		return false
 	}
-	var temp, l big.Int
-	transcript := fiatshamir.InitTranscript([]string{"PoKEStar", pp.G.String(), pp.N.String(), C.String()}, fiatshamir.Max252)
-	l.Set(transcript.GetPrimeChallengeUsingTranscript())
-
-	temp.Set(MultiExp(proof.Q, &l, pp.G, proof.R, pp.N))
-	return temp.Cmp(C) == 0
+	return PoKEStarVerifyInGroup(&group.RSA{N: pp.N}, pp.G, C, &GroupPoKEStarProof{Q: proof.Q, R: proof.R})
 }
 
 // ZKPoKEProof contains the proofs for ZKPoKE
@@ -73,10 +59,10 @@ type ZKPoKEProof struct {
 // ZKPoKEProve proves in zero-knowledge of knowledge x s.t. u^x =w mod N
 func ZKPoKEProve(pp *PublicParameters, u, x, w *big.Int) (*ZKPoKEProof, error) {
 	var ret ZKPoKEProof
-	var temp, c, l big.Int
-	temp.Exp(u, x, pp.N)
-	if temp.Cmp(w) != 0 {
-		return nil, errors.New("ZKPoKEProve inputs a invalid statement")
+	var c, l big.Int
+	grp := &group.RSA{N: pp.N}
+	if !grp.Equal(grp.Exp(u, x), w) {
+		return nil, ErrInvalidStatement
 	}
 
 	b := new(big.Int).Set(pp.N)
@@ -95,9 +81,10 @@ func ZKPoKEProve(pp *PublicParameters, u, x, w *big.Int) (*ZKPoKEProof, error) {
 		return nil, err
 	}
 
-	ret.z = new(big.Int).Set(MultiExp(pp.G, x, pp.H, rhox, pp.N))
-	ret.Ag = new(big.Int).Set(MultiExp(pp.G, k, pp.H, rhok, pp.N))
-	ret.Au = new(big.Int).Exp(u, k, pp.N)
+	gh := []group.Element{pp.G, pp.H}
+	ret.z = grp.MultiExp(gh, []*big.Int{x, rhox}).(*big.Int)
+	ret.Ag = grp.MultiExp(gh, []*big.Int{k, rhok}).(*big.Int)
+	ret.Au = grp.Exp(u, k).(*big.Int)
 
 	transcript := fiatshamir.InitTranscript([]string{"ZKPoKE", pp.G.String(), pp.H.String(),
 		pp.N.String(), u.String(), w.String(), ret.z.String(), ret.Ag.String(), ret.Au.String()}, fiatshamir.Max252)
@@ -114,8 +101,8 @@ func ZKPoKEProve(pp *PublicParameters, u, x, w *big.Int) (*ZKPoKEProof, error) {
 	qx.DivMod(&sx, &l, &rx)
 	qrho.DivMod(&srho, &l, &rrho)
 
-	ret.Qg = new(big.Int).Set(MultiExp(pp.G, &qx, pp.H, &qrho, pp.N))
-	ret.Qu = new(big.Int).Exp(u, &qx, pp.N)
+	ret.Qg = grp.MultiExp(gh, []*big.Int{&qx, &qrho}).(*big.Int)
+	ret.Qu = grp.Exp(u, &qx).(*big.Int)
 	ret.rx = new(big.Int).Set(&rx)
 	ret.rrho = new(big.Int).Set(&rrho)
 
@@ -124,7 +111,8 @@ func ZKPoKEProve(pp *PublicParameters, u, x, w *big.Int) (*ZKPoKEProof, error) {
 
 // ZKPoKEVerify checks the proof, returns true if everything is good
 func ZKPoKEVerify(pp *PublicParameters, u, w *big.Int, proof *ZKPoKEProof) bool {
-	if proof == nil {
+	if proof == nil || proof.rx == nil || proof.rrho == nil || proof.CheckReduced(pp.N) != nil ||
+		!areUnits(pp.N, pp.G, pp.H, u, w) {
 		return false
 	}
 	var c, l big.Int
@@ -132,24 +120,22 @@ func ZKPoKEVerify(pp *PublicParameters, u, w *big.Int, proof *ZKPoKEProof) bool
 		pp.N.String(), u.String(), w.String(), proof.z.String(), proof.Ag.String(), proof.Au.String()}, fiatshamir.Max252)
 	c.Set(transcript.GetIntChallengeUsingTranscript())
 	l.Set(transcript.GetPrimeChallengeUsingTranscript())
+	// the remainders of the honest prover are in [0, l)
+	if proof.rx.Sign() < 0 || proof.rx.Cmp(&l) >= 0 || proof.rrho.Sign() < 0 || proof.rrho.Cmp(&l) >= 0 {
+		return false
+	}
 
-	var lhs, rhs big.Int
-	// checking the fist condition
-	lhs.Exp(proof.Qg, &l, pp.N)
-	lhs.Mul(&lhs, MultiExp(pp.G, proof.rx, pp.H, proof.rrho, pp.N))
-	lhs.Mod(&lhs, pp.N)
-
-	rhs.Exp(proof.z, &c, pp.N)
-	rhs.Mul(&rhs, proof.Ag)
-	rhs.Mod(&rhs, pp.N)
-	if lhs.Cmp(&rhs) != 0 {
+	grp := &group.RSA{N: pp.N}
+	// checking the fist condition, Qg^l * g^rx * h^rrho = z^c * Ag
+	lhs := grp.MultiExp([]group.Element{proof.Qg, pp.G, pp.H}, []*big.Int{&l, proof.rx, proof.rrho})
+	rhs := grp.Op(grp.Exp(proof.z, &c), proof.Ag)
+	if !grp.Equal(lhs, rhs) {
 		return false
 	}
-	lhs.Set(MultiExp(proof.Qu, &l, u, proof.rx, pp.N))
-	rhs.Exp(w, &c, pp.N)
-	rhs.Mul(&rhs, proof.Au)
-	rhs.Mod(&rhs, pp.N)
-	return lhs.Cmp(&rhs) == 0
+	// Qu^l * u^rx = w^c * Au
+	lhs = grp.MultiExp([]group.Element{proof.Qu, u}, []*big.Int{&l, proof.rx})
+	rhs = grp.Op(grp.Exp(w, &c), proof.Au)
+	return grp.Equal(lhs, rhs)
 }
 
 // PoEProof contains the proofs for PoE
@@ -159,30 +145,17 @@ type PoEProof struct {
 
 // PoEProve proves g^x = C
 func PoEProve(base, mod, C, x *big.Int) (*PoEProof, error) {
-	var ret PoEProof
-	ret.Q = new(big.Int)
-	var temp, q, l big.Int
-	temp.Exp(base, x, mod)
-	if temp.Cmp(C) != 0 {
-		return nil, errors.New("PoKEStar inputs a invalid statement")
+	p, err := PoEProveInGroup(&group.RSA{N: mod}, base, C, x)
+	if err != nil {
+		return nil, err
 	}
-
-	transcript := fiatshamir.InitTranscript([]string{"PoE", base.String(), mod.String(), C.String(), x.String()}, fiatshamir.Max252)
-	l.Set(transcript.GetPrimeChallengeUsingTranscript())
-	q.Div(x, &l)
-	ret.Q.Exp(base, &q, mod)
-	return &ret, nil
+	return &PoEProof{Q: p.Q.(*big.Int)}, nil
 }
 
 // PoEVerify checks the proof, returns true if everything is good
 func PoEVerify(base, mod, C, x *big.Int, proof *PoEProof) bool {
-	if proof == nil {
+	if proof == nil || proof.CheckReduced(mod) != nil {
 		return false
 	}
-	var temp, l, r big.Int
-	transcript := fiatshamir.InitTranscript([]string{"PoE", base.String(), mod.String(), C.String(), x.String()}, fiatshamir.Max252)
-	l.Set(transcript.GetPrimeChallengeUsingTranscript())
-	r.Mod(x, &l)
-	temp.Set(MultiExp(proof.Q, &l, base, &r, mod))
-	return temp.Cmp(C) == 0
+	return PoEVerifyInGroup(&group.RSA{N: mod}, base, C, x, &GroupPoEProof{Q: proof.Q})
 }
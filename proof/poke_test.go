@@ -0,0 +1,94 @@
+package proof
+
+import (
+	"math/big"
+	"testing"
+)
+
+// the verifiers reject group elements that are not reduced modulo N, even if they are congruent to valid ones
+func TestVerifyUnreduced(t *testing.T) {
+	n, _ := new(big.Int).SetString("1000036000099", 10)
+	pp := NewPublicParameters(n, big.NewInt(4), big.NewInt(9))
+	x := big.NewInt(123456789)
+	C := new(big.Int).Exp(pp.G, x, n)
+
+	zkpoke, err := ZKPoKEProve(pp, pp.G, x, C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ZKPoKEVerify(pp, pp.G, C, zkpoke) {
+		t.Fatal("valid ZKPoKE proof rejected")
+	}
+	tampered := *zkpoke
+	tampered.Qg = new(big.Int).Add(zkpoke.Qg, n)
+	if ZKPoKEVerify(pp, pp.G, C, &tampered) {
+		t.Error("ZKPoKE proof with an unreduced Qg accepted")
+	}
+
+	poke, err := PoKEStarProve(pp, C, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PoKEStarVerify(pp, C, &PoKEStarProof{Q: new(big.Int).Add(poke.Q, n), R: poke.R}) {
+		t.Error("PoKE* proof with an unreduced Q accepted")
+	}
+
+	poe, err := PoEProve(pp.G, n, C, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PoEVerify(pp.G, n, C, x, &PoEProof{Q: new(big.Int).Add(poe.Q, n)}) {
+		t.Error("PoE proof with an unreduced Q accepted")
+	}
+}
+
+// the verifiers reject values sharing a factor with N instead of panicking on their inverse
+func TestVerifyNotInvertible(t *testing.T) {
+	n, _ := new(big.Int).SetString("1000036000099", 10) // 1000003 * 1000033
+	p := big.NewInt(1000003)
+	pp := NewPublicParameters(n, big.NewInt(4), big.NewInt(9))
+	x := big.NewInt(123456789)
+	C := new(big.Int).Exp(pp.G, x, n)
+
+	zkpoke, err := ZKPoKEProve(pp, pp.G, x, C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ZKPoKEVerify(pp, p, C, zkpoke) || ZKPoKEVerify(pp, pp.G, p, zkpoke) {
+		t.Error("ZKPoKE proof with a non-invertible statement accepted")
+	}
+	tampered := *zkpoke
+	tampered.rx = new(big.Int).Neg(zkpoke.rx)
+	if ZKPoKEVerify(pp, pp.G, C, &tampered) {
+		t.Error("ZKPoKE proof with a negative rx accepted")
+	}
+
+	r := big.NewInt(987654321)
+	c := new(big.Int).Mul(C, new(big.Int).Exp(pp.H, r, n))
+	c.Mod(c, n)
+	rp, err := NewRPProver(pp, r, big.NewInt(0), big.NewInt(1<<30)).Prove(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	badRP := *rp
+	badRP.c = p
+	if NewRPVerifier(pp, big.NewInt(0), big.NewInt(1<<30)).Verify(&badRP) {
+		t.Error("range proof of a non-invertible commitment accepted")
+	}
+	badRP = *rp
+	badRP.commit3[1] = p
+	if NewRPVerifier(pp, big.NewInt(0), big.NewInt(1<<30)).Verify(&badRP) {
+		t.Error("range proof with a non-invertible commitment accepted")
+	}
+
+	aop, err := NewZKAoPProver(pp, r).Prove(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if NewZKAoPVerifier(pp, p).Verify(aop) {
+		t.Error("argument of positivity of a non-invertible commitment accepted")
+	}
+	if !NewZKAoPVerifier(pp, c).Verify(aop) {
+		t.Error("valid argument of positivity rejected")
+	}
+}
@@ -394,6 +394,11 @@ func NewRPVerifier(pp *PublicParameters, a, b *big.Int) *RPVerifier {
 
 // Verify verifies the range proof
 func (r *RPVerifier) Verify(proof *RangeProof) bool {
+	if proof == nil || proof.response == nil || proof.response.TAU == nil || !isInt4Set(proof.response.Z4) ||
+		!isInt4Set(proof.response.T4) || r.a == nil || r.b == nil ||
+		!areUnits(r.pp.N, r.pp.G, r.pp.H, proof.c, proof.commit3[0], proof.commit3[1], proof.commit3[2]) {
+		return false
+	}
 	r.c4[0] = new(big.Int).ModInverse(proof.c, r.pp.N)
 	opt := iPool.Get().(*big.Int)
 	defer iPool.Put(opt)
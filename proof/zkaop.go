@@ -336,6 +336,11 @@ func NewZKAoPVerifier(pp *PublicParameters, c *big.Int) *ZKAoPVerifier {
 
 // Verify verifies the argument of positivity
 func (r *ZKAoPVerifier) Verify(proof *ArgOfPositivity) bool {
+	if proof == nil || proof.response == nil || proof.response.T == nil || !isInt3Set(proof.response.Z3) ||
+		!isInt3Set(proof.response.T3) ||
+		!areUnits(r.pp.N, r.pp.G, r.pp.H, r.c, proof.commit3[0], proof.commit3[1], proof.commit3[2]) {
+		return false
+	}
 	opt := iPool.Get().(*big.Int)
 	defer iPool.Put(opt)
 	for i := 0; i < int3Len; i++ {
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"math/big"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+	"github.com/jiajunxin/rsa_accumulator/codec"
+	"github.com/jiajunxin/rsa_accumulator/zkmultiswap"
+)
+
+// MarshalBinary encodes the update without its witnesses in the versioned binary format of package codec
+func (u *Update) MarshalBinary() ([]byte, error) {
+	enc := codec.NewEncoder(codec.TypeStoreUpdate)
+	putEpoch(enc, u.Epoch, u.Acc, u.Leaves, u.Randomizer, u.Report)
+	return enc.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the update encoded by MarshalBinary, the witnesses are nil
+func (u *Update) UnmarshalBinary(data []byte) error {
+	dec := codec.NewDecoder(data, codec.TypeStoreUpdate)
+	epoch, acc, leaves, randomizer, report := getEpoch(dec)
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	u.Epoch, u.Acc, u.Leaves, u.Witnesses, u.Randomizer, u.Report = epoch, acc, leaves, nil, randomizer, report
+	return nil
+}
+
+// marshalSnapshot encodes the state with its witnesses together with the setup it belongs to
+func marshalSnapshot(setup *accumulator.Setup, s *State) ([]byte, error) {
+	setupBytes, err := setup.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	enc := codec.NewEncoder(codec.TypeStoreSnapshot)
+	enc.PutBytes(setupBytes)
+	putEpoch(enc, s.Epoch, s.Acc, s.Leaves, s.Randomizer, s.Report)
+	enc.PutBigInts(s.Witnesses)
+	return enc.Bytes(), nil
+}
+
+// unmarshalSnapshot decodes the state encoded by marshalSnapshot, ErrSetupMismatch if it is not of the setup
+func unmarshalSnapshot(setup *accumulator.Setup, data []byte) (*State, error) {
+	dec := codec.NewDecoder(data, codec.TypeStoreSnapshot)
+	setupBytes := dec.Bytes()
+	epoch, acc, leaves, randomizer, report := getEpoch(dec)
+	witnesses := dec.BigInts()
+	if err := dec.Finish(); err != nil {
+		return nil, err
+	}
+	stored := new(accumulator.Setup)
+	if err := stored.UnmarshalBinary(setupBytes); err != nil {
+		return nil, err
+	}
+	if stored.N.Cmp(setup.N) != 0 || stored.G.Cmp(setup.G) != 0 {
+		return nil, ErrSetupMismatch
+	}
+	return &State{Epoch: epoch, Acc: acc, Leaves: leaves, Witnesses: witnesses, Randomizer: randomizer, Report: report}, nil
+}
+
+// putEpoch encodes the fields shared by the update and the state, a nil randomizer is encoded as 0
+func putEpoch(enc *codec.Encoder, epoch uint32, acc *big.Int, leaves []zkmultiswap.Leaf, randomizer *big.Int, report []byte) {
+	enc.PutUint32(epoch)
+	enc.PutBigInt(acc)
+	enc.PutUint32(uint32(len(leaves)))
+	for i := range leaves {
+		enc.PutUint32(leaves[i].UserID)
+		enc.PutUint32(leaves[i].Balance)
+		enc.PutUint32(leaves[i].Epoch)
+		enc.PutBigInt(&leaves[i].PrevHash)
+	}
+	if randomizer == nil {
+		randomizer = new(big.Int)
+	}
+	enc.PutBigInt(randomizer)
+	enc.PutBytes(report)
+}
+
+func getEpoch(dec *codec.Decoder) (uint32, *big.Int, []zkmultiswap.Leaf, *big.Int, []byte) {
+	epoch := dec.Uint32()
+	acc := dec.Nat()
+	count := dec.Uint32()
+	var leaves []zkmultiswap.Leaf
+	for i := uint32(0); i < count && dec.Err() == nil; i++ {
+		leaf := zkmultiswap.Leaf{UserID: dec.Uint32(), Balance: dec.Uint32(), Epoch: dec.Uint32()}
+		if prevHash := dec.Nat(); prevHash != nil {
+			leaf.PrevHash.Set(prevHash)
+		}
+		leaves = append(leaves, leaf)
+	}
+	randomizer := dec.Nat()
+	if randomizer != nil && randomizer.Sign() == 0 {
+		randomizer = nil
+	}
+	report := dec.Bytes()
+	if len(report) == 0 {
+		report = nil
+	}
+	return epoch, acc, leaves, randomizer, report
+}
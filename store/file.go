@@ -0,0 +1,315 @@
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+)
+
+const (
+	// DefaultSnapshotInterval is the number of epochs logged between two snapshots
+	DefaultSnapshotInterval = 16
+
+	snapshotFile = "snapshot.bin"
+	logFile      = "epochs.log"
+	tempSuffix   = ".tmp"
+	// recordHeaderSize is the length and the CRC-32C of the payload of a log record
+	recordHeaderSize = 8
+)
+
+// ErrCorruptLog is returned when a log record before the last one is damaged, which a crash cannot cause
+var ErrCorruptLog = errors.New("corrupt epoch log")
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Options configures a FileStore
+type Options struct {
+	// SnapshotInterval is the number of commits between two snapshots, DefaultSnapshotInterval if <= 0
+	SnapshotInterval int
+	// NumWorkers is the number of goroutines computing and verifying the witnesses on Open, all CPUs if <= 0
+	NumWorkers int
+	// NoSync skips fsync, for tests only since a crash may then lose committed epochs
+	NoSync bool
+}
+
+// FileStore is a Store in one directory. Every commit appends the update without its witnesses to epochs.log
+// and syncs it before returning, so that a record has the size of the changes of the epoch.
+// Every SnapshotInterval commits the whole state is written to snapshot.bin, replacing the old snapshot
+// atomically, and the log is emptied.
+type FileStore struct {
+	mu      sync.Mutex
+	dir     string
+	setup   *accumulator.Setup
+	opts    Options
+	state   *State
+	log     *os.File
+	size    int64 // length of the intact records in the log
+	pending int   // commits since the last snapshot
+	snapErr error // error of the last snapshot, nil if it succeeded
+}
+
+// Open restores the state in dir, creating the directory if needed. The snapshot is loaded and the logged
+// updates after it are applied, the witnesses are then recomputed with State.ProveWitnesses. A record torn by
+// a crash at the end of the log is dropped, then the restored state is verified with State.Verify. opts may be nil.
+func Open(dir string, setup *accumulator.Setup, opts *Options) (*FileStore, error) {
+	s := &FileStore{dir: dir, setup: setup}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.SnapshotInterval <= 0 {
+		s.opts.SnapshotInterval = DefaultSnapshotInterval
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	// a snapshot interrupted before the rename is incomplete
+	if err := os.Remove(s.path(snapshotFile + tempSuffix)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.path(snapshotFile))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		s.state = NewState(setup)
+	case err != nil:
+		return nil, err
+	default:
+		if s.state, err = unmarshalSnapshot(setup, data); err != nil {
+			return nil, err
+		}
+	}
+
+	s.log, err = os.OpenFile(s.path(logFile), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.replay(); err != nil {
+		s.log.Close()
+		return nil, err
+	}
+	if err = s.state.Verify(setup, s.opts.NumWorkers); err != nil {
+		s.log.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// replay applies the logged updates to the state of the snapshot, recomputes the witnesses if there are any,
+// and truncates a torn last record
+func (s *FileStore) replay() error {
+	data, err := io.ReadAll(s.log)
+	if err != nil {
+		return err
+	}
+	offset, replayed := 0, false
+	for offset < len(data) {
+		payload, ok := readRecord(data[offset:])
+		if !ok {
+			if offset+recordHeaderSize+len(payload) < len(data) {
+				return ErrCorruptLog
+			}
+			break
+		}
+		var update Update
+		if err = update.UnmarshalBinary(payload); err != nil {
+			return ErrCorruptLog
+		}
+		offset += recordHeaderSize + len(payload)
+		s.pending++
+		// updates up to the epoch of the snapshot are left over from a crash before the log was emptied
+		if update.Epoch <= s.state.Epoch {
+			continue
+		}
+		if s.state, err = s.state.Apply(&update); err != nil {
+			return ErrCorruptLog
+		}
+		replayed = true
+	}
+	// the log has no witnesses, compute them once for the last epoch
+	if replayed {
+		s.state.ProveWitnesses(s.setup, s.opts.NumWorkers)
+	}
+	if offset < len(data) {
+		if err = s.log.Truncate(int64(offset)); err != nil {
+			return err
+		}
+		if err = s.sync(s.log); err != nil {
+			return err
+		}
+	}
+	s.size = int64(offset)
+	_, err = s.log.Seek(s.size, io.SeekStart)
+	return err
+}
+
+// readRecord returns the payload of the record at the start of data and whether it is complete and intact.
+// An incomplete record returns the part of the payload that is present.
+func readRecord(data []byte) ([]byte, bool) {
+	if len(data) < recordHeaderSize {
+		return nil, false
+	}
+	length := binary.BigEndian.Uint32(data)
+	sum := binary.BigEndian.Uint32(data[4:])
+	payload := data[recordHeaderSize:]
+	if uint64(length) > uint64(len(payload)) {
+		return payload, false
+	}
+	payload = payload[:length]
+	return payload, crc32.Checksum(payload, crcTable) == sum
+}
+
+// State returns a copy of the last committed state
+func (s *FileStore) State() *State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.Clone()
+}
+
+// Commit applies the update, appends it to the log and syncs the log. An error means that the update is not
+// committed. Every SnapshotInterval commits it takes a snapshot, a failed snapshot does not fail the commit
+// of the logged update: it is reported by SnapshotErr and retried at the next commit.
+// The witnesses of the update are not verified.
+func (s *FileStore) Commit(update *Update) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.log == nil {
+		return ErrClosed
+	}
+	next, err := s.state.Apply(update)
+	if err != nil {
+		return err
+	}
+	if err = withWitnesses(next); err != nil {
+		return err
+	}
+	payload, err := update.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	record := make([]byte, recordHeaderSize, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record, uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:], crc32.Checksum(payload, crcTable))
+	record = append(record, payload...)
+	if _, err = s.log.Write(record); err == nil {
+		err = s.sync(s.log)
+	}
+	if err != nil {
+		// drop the partial record so that the next commit does not follow a damaged one
+		if s.log.Truncate(s.size) == nil {
+			_, _ = s.log.Seek(s.size, io.SeekStart)
+		}
+		return err
+	}
+	s.size += int64(len(record))
+	s.state = next
+	s.pending++
+	if s.pending >= s.opts.SnapshotInterval {
+		_ = s.snapshot()
+	}
+	return nil
+}
+
+// SnapshotErr returns the error of the last snapshot taken by Commit or Snapshot, nil if it succeeded
+func (s *FileStore) SnapshotErr() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapErr
+}
+
+// Snapshot writes the current state to the snapshot and empties the log
+func (s *FileStore) Snapshot() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.log == nil {
+		return ErrClosed
+	}
+	return s.snapshot()
+}
+
+// snapshot runs writeSnapshot and keeps its error for SnapshotErr
+func (s *FileStore) snapshot() error {
+	s.snapErr = s.writeSnapshot()
+	return s.snapErr
+}
+
+// writeSnapshot writes the state to a temporary file renamed over the old snapshot, then empties the log.
+// A crash in between leaves a log of updates already in the snapshot, which Open skips.
+func (s *FileStore) writeSnapshot() error {
+	data, err := marshalSnapshot(s.setup, s.state)
+	if err != nil {
+		return err
+	}
+	temp := s.path(snapshotFile + tempSuffix)
+	f, err := os.OpenFile(temp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = s.sync(f)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(temp, s.path(snapshotFile)); err != nil {
+		return err
+	}
+	if err = s.syncDir(); err != nil {
+		return err
+	}
+	if err = s.log.Truncate(0); err != nil {
+		return err
+	}
+	if _, err = s.log.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	s.size, s.pending = 0, 0
+	return s.sync(s.log)
+}
+
+// Close closes the log, the state is already persisted by Commit
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.log == nil {
+		return ErrClosed
+	}
+	err := s.log.Close()
+	s.log = nil
+	return err
+}
+
+func (s *FileStore) path(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+func (s *FileStore) sync(f *os.File) error {
+	if s.opts.NoSync {
+		return nil
+	}
+	return f.Sync()
+}
+
+// syncDir makes the rename of the snapshot durable
+func (s *FileStore) syncDir() error {
+	if s.opts.NoSync {
+		return nil
+	}
+	d, err := os.Open(s.dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if closeErr := d.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,277 @@
+package store
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+)
+
+// commitEpochs commits the epochs from+1 to to, updating the users 1, 3, 5 and one new user per epoch.
+// The randomizer of an epoch is its number.
+func commitEpochs(t *testing.T, setup *accumulator.Setup, s *FileStore, from, to uint32) {
+	t.Helper()
+	for epoch := from + 1; epoch <= to; epoch++ {
+		update := genRandomizedUpdate(t, setup, s.State(), epoch, big.NewInt(int64(epoch)), 1, 3, 5, 100+epoch)
+		if err := s.Commit(update); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFileStoreRestore(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	dir := t.TempDir()
+	s, err := Open(dir, setup, &Options{SnapshotInterval: 3, NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.State().Epoch != 0 {
+		t.Fatal("a new store does not start at epoch 0")
+	}
+	commitEpochs(t, setup, s, 0, 5)
+	want := s.State()
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Commit(nil); err != ErrClosed {
+		t.Errorf("Commit() after Close() = %v, want %v", err, ErrClosed)
+	}
+
+	// epochs 1 to 3 are in the snapshot, 4 and 5 in the log
+	s, err = Open(dir, setup, &Options{SnapshotInterval: 3, NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalStates(s.State(), want) {
+		t.Error("restored state differs from the committed one")
+	}
+	commitEpochs(t, setup, s, 5, 7)
+	want = s.State()
+	s.Close()
+
+	s, err = Open(dir, setup, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if !equalStates(s.State(), want) {
+		t.Error("restored state differs from the committed one")
+	}
+
+	other := *setup
+	other.G = big.NewInt(4)
+	if _, err = Open(dir, &other, nil); err != ErrSetupMismatch {
+		t.Errorf("Open() with another setup = %v, want %v", err, ErrSetupMismatch)
+	}
+}
+
+func TestFileStoreTornRecord(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	dir := t.TempDir()
+	s, err := Open(dir, setup, &Options{NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitEpochs(t, setup, s, 0, 3)
+	want := s.State()
+	s.Close()
+
+	logPath := filepath.Join(dir, logFile)
+	intact, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first half of a record of epoch 4, as written before a crash
+	payload, err := genUpdate(t, setup, want, 4, 7).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	torn := append(append([]byte{}, intact...), 0, 0, byte(len(payload)>>8), byte(len(payload)), 1, 2, 3, 4)
+	torn = append(torn, payload[:len(payload)/2]...)
+	if err = os.WriteFile(logPath, torn, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(dir, setup, &Options{NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalStates(s.State(), want) {
+		t.Error("state after a torn record differs from the committed one")
+	}
+	if got, _ := os.ReadFile(logPath); len(got) != len(intact) {
+		t.Errorf("log of %d bytes after Open(), want the %d intact bytes", len(got), len(intact))
+	}
+	commitEpochs(t, setup, s, 3, 4)
+	want = s.State()
+	s.Close()
+	if s, err = Open(dir, setup, &Options{NoSync: true}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	if !equalStates(s.State(), want) {
+		t.Error("state after recovering from a torn record differs from the committed one")
+	}
+}
+
+func TestFileStoreCorruptLog(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	dir := t.TempDir()
+	s, err := Open(dir, setup, &Options{NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitEpochs(t, setup, s, 0, 2)
+	s.Close()
+
+	logPath := filepath.Join(dir, logFile)
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[recordHeaderSize+10] ^= 1
+	if err = os.WriteFile(logPath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Open(dir, setup, nil); err != ErrCorruptLog {
+		t.Errorf("Open() with a damaged first record = %v, want %v", err, ErrCorruptLog)
+	}
+}
+
+func TestFileStoreLogBeforeSnapshot(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	dir := t.TempDir()
+	s, err := Open(dir, setup, &Options{NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitEpochs(t, setup, s, 0, 2)
+	logPath := filepath.Join(dir, logFile)
+	logged, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	commitEpochs(t, setup, s, 2, 3)
+	want := s.State()
+	s.Close()
+
+	// a crash after the snapshot was renamed but before the log was emptied
+	if err = os.WriteFile(logPath, logged, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, snapshotFile+tempSuffix), []byte{1, 2}, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err = Open(dir, setup, &Options{NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if got := s.State(); got.Epoch != 2 {
+		t.Errorf("restored epoch %d, want 2 of the snapshot", got.Epoch)
+	}
+	commitEpochs(t, setup, s, 2, 3)
+	if !equalStates(s.State(), want) {
+		t.Error("state after replaying the old log differs from the committed one")
+	}
+}
+
+func TestFileStoreInconsistent(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	dir := t.TempDir()
+	s, err := Open(dir, setup, &Options{NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := genUpdate(t, setup, s.State(), 1, 1, 2)
+	update.Acc = new(big.Int).Set(update.Witnesses[0])
+	// Commit does not verify the witnesses
+	if err = s.Commit(update); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	if _, err = Open(dir, setup, nil); err != ErrInconsistentState {
+		t.Errorf("Open() with a wrong accumulator value = %v, want %v", err, ErrInconsistentState)
+	}
+}
+
+func TestFileStoreLogsChangesOnly(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	dir := t.TempDir()
+	s, err := Open(dir, setup, &Options{SnapshotInterval: 100, NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	// every epoch changes 4 leaves while the state grows by one leaf
+	commitEpochs(t, setup, s, 0, 8)
+	data, err := os.ReadFile(filepath.Join(dir, logFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sizes []int
+	for len(data) > 0 {
+		payload, ok := readRecord(data)
+		if !ok {
+			t.Fatal("damaged log record")
+		}
+		sizes = append(sizes, len(payload))
+		data = data[recordHeaderSize+len(payload):]
+	}
+	if len(sizes) != 8 {
+		t.Fatalf("%d log records, want 8", len(sizes))
+	}
+	// the values may differ by a few bytes, the witnesses of the 6 more leaves would add one group element each
+	if sizes[7] >= sizes[1]+setup.N.BitLen()/8 {
+		t.Errorf("log record of %d bytes at epoch 8, %d bytes at epoch 2", sizes[7], sizes[1])
+	}
+}
+
+func TestFileStoreSnapshotFailure(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	dir := t.TempDir()
+	s, err := Open(dir, setup, &Options{SnapshotInterval: 2, NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the temporary snapshot cannot be created over a directory
+	temp := filepath.Join(dir, snapshotFile+tempSuffix)
+	if err = os.Mkdir(temp, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	commitEpochs(t, setup, s, 0, 3)
+	if s.SnapshotErr() == nil {
+		t.Fatal("SnapshotErr() = nil after a failed snapshot")
+	}
+	if got := s.State().Epoch; got != 3 {
+		t.Fatalf("epoch %d after the failed snapshots, want 3", got)
+	}
+	if _, err = os.Stat(filepath.Join(dir, snapshotFile)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("snapshot written over a directory: %v", err)
+	}
+
+	// the snapshot is retried at the next commit
+	if err = os.Remove(temp); err != nil {
+		t.Fatal(err)
+	}
+	commitEpochs(t, setup, s, 3, 4)
+	if err = s.SnapshotErr(); err != nil {
+		t.Fatalf("SnapshotErr() after a retried snapshot = %v", err)
+	}
+	want := s.State()
+	s.Close()
+	if s, err = Open(dir, setup, &Options{NoSync: true}); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if !equalStates(s.State(), want) {
+		t.Error("restored state differs from the committed one")
+	}
+}
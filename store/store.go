@@ -0,0 +1,307 @@
+// Package store keeps the state of a Notus accumulator across restarts: the accumulator value, the leaves of all
+// users and their membership witnesses. FileStore persists every epoch update in an append-only log and writes
+// a snapshot of the whole state periodically, the state is restored and verified when the store is opened.
+package store
+
+import (
+	"errors"
+	"math/big"
+	"math/bits"
+	"runtime"
+	"sync"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+	"github.com/jiajunxin/rsa_accumulator/zkmultiswap"
+)
+
+var (
+	// ErrInvalidUpdate is returned when an update cannot be applied to the state
+	ErrInvalidUpdate = errors.New("invalid epoch update")
+	// ErrBrokenHashChain is returned when an updated leaf does not point to the hash of the leaf it replaces
+	ErrBrokenHashChain = errors.New("leaf does not extend the hash chain of the user")
+	// ErrInconsistentState is returned when the leaves, the witnesses and the accumulator value do not match
+	ErrInconsistentState = errors.New("inconsistent accumulator state")
+	// ErrSetupMismatch is returned when a stored state belongs to another setup
+	ErrSetupMismatch = errors.New("stored state belongs to another setup")
+	// ErrClosed is returned when using a closed store
+	ErrClosed = errors.New("store is closed")
+)
+
+// Store persists the state of the accumulator epoch by epoch
+type Store interface {
+	// State returns a copy of the last committed state
+	State() *State
+	// Commit applies the update and persists the new state, the state is unchanged on an error
+	Commit(update *Update) error
+	// Close releases the resources of the store
+	Close() error
+}
+
+// State is the accumulator of one epoch. Leaves are sorted by UserID and Witnesses[i] is the membership
+// witness of Leaves[i], Witnesses[i]^{DI hash of Leaves[i]} = Acc mod N. Randomizer is the secret randomizer
+// of the zkmultiswap update that produced Acc, it is positive or nil if Acc is not randomized.
+// Report is the encoded public report of the epoch, it is opaque to the store.
+type State struct {
+	Epoch      uint32
+	Acc        *big.Int
+	Leaves     []zkmultiswap.Leaf
+	Witnesses  []*big.Int
+	Randomizer *big.Int
+	Report     []byte
+}
+
+// Update is the change of the state in one epoch. Leaves are the new leaves of the epoch sorted by UserID,
+// either of new users or replacing the leaf of a user, and Witnesses are the witnesses of all leaves after the update.
+// The witnesses are not part of the binary encoding, a FileStore logs the changed leaves only and recomputes the
+// witnesses with State.ProveWitnesses when it is opened. Report is committed together with the update,
+// so the report of the last epoch is not lost if the process stops before publishing it.
+type Update struct {
+	Epoch      uint32
+	Acc        *big.Int
+	Leaves     []zkmultiswap.Leaf
+	Witnesses  []*big.Int
+	Randomizer *big.Int
+	Report     []byte
+}
+
+// NewState returns the empty state of epoch 0, the accumulator value is setup.G
+func NewState(setup *accumulator.Setup) *State {
+	return &State{Acc: new(big.Int).Set(setup.G)}
+}
+
+// Clone returns a deep copy of the state
+func (s *State) Clone() *State {
+	return &State{
+		Epoch:      s.Epoch,
+		Acc:        new(big.Int).Set(s.Acc),
+		Leaves:     cloneLeaves(s.Leaves),
+		Witnesses:  cloneInts(s.Witnesses),
+		Randomizer: cloneInt(s.Randomizer),
+		Report:     cloneBytes(s.Report),
+	}
+}
+
+// Index returns the index of the leaf of the user, or -1
+func (s *State) Index(userID uint32) int {
+	lo, hi := 0, len(s.Leaves)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if s.Leaves[mid].UserID < userID {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo < len(s.Leaves) && s.Leaves[lo].UserID == userID {
+		return lo
+	}
+	return -1
+}
+
+// Apply returns the state after the update, the state itself is not changed.
+// It checks the structure of the update and the hash chains of the replaced leaves, not the witnesses.
+// The update may have no witnesses, the state then has none until ProveWitnesses.
+func (s *State) Apply(update *Update) (*State, error) {
+	if update == nil || update.Acc == nil || update.Epoch <= s.Epoch || (update.Randomizer != nil && update.Randomizer.Sign() <= 0) {
+		return nil, ErrInvalidUpdate
+	}
+	for i := range update.Leaves {
+		if i > 0 && update.Leaves[i].UserID <= update.Leaves[i-1].UserID {
+			return nil, ErrInvalidUpdate
+		}
+		if update.Leaves[i].Epoch > update.Epoch {
+			return nil, ErrInvalidUpdate
+		}
+	}
+
+	// merge the two sorted lists of leaves
+	leaves := make([]zkmultiswap.Leaf, 0, len(s.Leaves)+len(update.Leaves))
+	i, j := 0, 0
+	for i < len(s.Leaves) || j < len(update.Leaves) {
+		switch {
+		case j == len(update.Leaves) || (i < len(s.Leaves) && s.Leaves[i].UserID < update.Leaves[j].UserID):
+			leaves = append(leaves, cloneLeaf(&s.Leaves[i]))
+			i++
+		case i == len(s.Leaves) || update.Leaves[j].UserID < s.Leaves[i].UserID:
+			leaves = append(leaves, cloneLeaf(&update.Leaves[j]))
+			j++
+		default:
+			poseidonhash, _ := s.Leaves[i].Hash()
+			var prev big.Int
+			poseidonhash.ToBigIntRegular(&prev)
+			if prev.Cmp(&update.Leaves[j].PrevHash) != 0 {
+				return nil, ErrBrokenHashChain
+			}
+			leaves = append(leaves, cloneLeaf(&update.Leaves[j]))
+			i++
+			j++
+		}
+	}
+	if update.Witnesses != nil && len(update.Witnesses) != len(leaves) {
+		return nil, ErrInvalidUpdate
+	}
+	return &State{
+		Epoch:      update.Epoch,
+		Acc:        new(big.Int).Set(update.Acc),
+		Leaves:     leaves,
+		Witnesses:  cloneInts(update.Witnesses),
+		Randomizer: cloneInt(update.Randomizer),
+		Report:     cloneBytes(update.Report),
+	}, nil
+}
+
+// Verify returns ErrInconsistentState unless the leaves are sorted, no leaf is from a later epoch,
+// and every witness proves the membership of its leaf in Acc. It runs one exponentiation per leaf
+// on numWorkers goroutines, all CPUs if numWorkers <= 0.
+func (s *State) Verify(setup *accumulator.Setup, numWorkers int) error {
+	if s.Acc == nil || s.Acc.Sign() <= 0 || s.Acc.Cmp(setup.N) >= 0 || len(s.Witnesses) != len(s.Leaves) {
+		return ErrInconsistentState
+	}
+	for i := range s.Leaves {
+		if i > 0 && s.Leaves[i].UserID <= s.Leaves[i-1].UserID {
+			return ErrInconsistentState
+		}
+		if s.Leaves[i].Epoch > s.Epoch {
+			return ErrInconsistentState
+		}
+	}
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed bool
+	)
+	jobs := make(chan int, numWorkers)
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if !accumulator.VerifyMembershipWithRep(setup, s.Acc, s.Leaves[i].Representative(),
+					accumulator.DIHashFromPoseidon, s.Witnesses[i]) {
+					mu.Lock()
+					failed = true
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range s.Leaves {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if failed {
+		return ErrInconsistentState
+	}
+	return nil
+}
+
+// ProveWitnesses recomputes the witnesses of all leaves from the leaves and the randomizer, the base of the
+// accumulator being setup.G raised to zkmultiswap.RandomizerProduct(Randomizer), or setup.G if Randomizer is nil.
+// It runs on numWorkers goroutines, all CPUs if numWorkers <= 0. Acc is not checked, see Verify.
+func (s *State) ProveWitnesses(setup *accumulator.Setup, numWorkers int) {
+	if len(s.Leaves) == 0 {
+		s.Witnesses = nil
+		return
+	}
+	base := setup.G
+	if s.Randomizer != nil {
+		base = new(big.Int).Exp(setup.G, zkmultiswap.RandomizerProduct(s.Randomizer), setup.N)
+	}
+	reps := make([]*big.Int, len(s.Leaves))
+	for i := range s.Leaves {
+		reps[i] = s.Leaves[i].Representative()
+	}
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+	// ProveMembershipParallel runs on up to 2^limit goroutines
+	limit := bits.Len(uint(numWorkers - 1))
+	s.Witnesses = accumulator.ProveMembershipParallel(base, setup.N, reps, limit)
+}
+
+// withWitnesses returns ErrInvalidUpdate unless the committed state has the witnesses of all its leaves
+func withWitnesses(next *State) error {
+	if len(next.Witnesses) != len(next.Leaves) {
+		return ErrInvalidUpdate
+	}
+	return nil
+}
+
+func cloneLeaf(leaf *zkmultiswap.Leaf) zkmultiswap.Leaf {
+	ret := zkmultiswap.Leaf{UserID: leaf.UserID, Balance: leaf.Balance, Epoch: leaf.Epoch}
+	ret.PrevHash.Set(&leaf.PrevHash)
+	return ret
+}
+
+func cloneLeaves(leaves []zkmultiswap.Leaf) []zkmultiswap.Leaf {
+	ret := make([]zkmultiswap.Leaf, len(leaves))
+	for i := range leaves {
+		ret[i] = cloneLeaf(&leaves[i])
+	}
+	return ret
+}
+
+func cloneInt(v *big.Int) *big.Int {
+	if v == nil {
+		return nil
+	}
+	return new(big.Int).Set(v)
+}
+
+func cloneInts(values []*big.Int) []*big.Int {
+	ret := make([]*big.Int, len(values))
+	for i, v := range values {
+		ret[i] = cloneInt(v)
+	}
+	return ret
+}
+
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+// MemoryStore is a Store keeping the state in memory only, for tests and short-lived provers
+type MemoryStore struct {
+	mu    sync.Mutex
+	state *State
+}
+
+// NewMemoryStore returns a store starting from the state
+func NewMemoryStore(state *State) *MemoryStore {
+	return &MemoryStore{state: state.Clone()}
+}
+
+// State returns a copy of the last committed state
+func (m *MemoryStore) State() *State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.Clone()
+}
+
+// Commit applies the update
+func (m *MemoryStore) Commit(update *Update) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	next, err := m.state.Apply(update)
+	if err != nil {
+		return err
+	}
+	if err = withWitnesses(next); err != nil {
+		return err
+	}
+	m.state = next
+	return nil
+}
+
+// Close does nothing
+func (m *MemoryStore) Close() error {
+	return nil
+}
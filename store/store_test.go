@@ -0,0 +1,160 @@
+package store
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+	"github.com/jiajunxin/rsa_accumulator/zkmultiswap"
+)
+
+// genUpdate returns the update of prev with new leaves for the users at the epoch, accumulated from setup.G
+func genUpdate(t *testing.T, setup *accumulator.Setup, prev *State, epoch uint32, users ...uint32) *Update {
+	t.Helper()
+	return genRandomizedUpdate(t, setup, prev, epoch, nil, users...)
+}
+
+// genRandomizedUpdate is genUpdate accumulated from setup.G^{RandomizerProduct(randomizer)} if randomizer is not nil,
+// the report is the epoch byte
+func genRandomizedUpdate(t *testing.T, setup *accumulator.Setup, prev *State, epoch uint32, randomizer *big.Int,
+	users ...uint32) *Update {
+	t.Helper()
+	update := &Update{Epoch: epoch, Acc: setup.G, Randomizer: randomizer, Report: []byte{byte(epoch)}}
+	for _, id := range users {
+		if i := prev.Index(id); i >= 0 {
+			update.Leaves = append(update.Leaves, prev.Leaves[i].Next(prev.Leaves[i].Balance+id, epoch))
+		} else {
+			leaf := zkmultiswap.Leaf{UserID: id, Balance: id, Epoch: epoch}
+			leaf.PrevHash.SetInt64(int64(id))
+			update.Leaves = append(update.Leaves, leaf)
+		}
+	}
+	// compute the witnesses once the leaves are merged
+	next, err := prev.Apply(update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next.ProveWitnesses(setup, 0)
+	update.Witnesses = next.Witnesses
+	update.Acc = accumulator.AccumulateNew(next.Witnesses[0], next.Leaves[0].Representative(), setup.N)
+	return update
+}
+
+func equalStates(a, b *State) bool {
+	if a.Epoch != b.Epoch || a.Acc.Cmp(b.Acc) != 0 || len(a.Leaves) != len(b.Leaves) || len(a.Witnesses) != len(b.Witnesses) {
+		return false
+	}
+	if (a.Randomizer == nil) != (b.Randomizer == nil) || (a.Randomizer != nil && a.Randomizer.Cmp(b.Randomizer) != 0) {
+		return false
+	}
+	if !bytes.Equal(a.Report, b.Report) {
+		return false
+	}
+	for i := range a.Leaves {
+		x, y := &a.Leaves[i], &b.Leaves[i]
+		if x.UserID != y.UserID || x.Balance != y.Balance || x.Epoch != y.Epoch || x.PrevHash.Cmp(&y.PrevHash) != 0 {
+			return false
+		}
+		if a.Witnesses[i].Cmp(b.Witnesses[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestStateApply(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	state := NewState(setup)
+	update := genUpdate(t, setup, state, 1, 3, 5, 7)
+	next, err := state.Apply(update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = next.Verify(setup, 2); err != nil {
+		t.Fatal(err)
+	}
+	if next.Index(5) != 1 || next.Index(4) != -1 || state.Epoch != 0 || len(state.Leaves) != 0 {
+		t.Error("Apply() changed the state or merged the leaves wrongly")
+	}
+
+	update = genUpdate(t, setup, next, 2, 1, 5)
+	after, err := next.Apply(update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = after.Verify(setup, 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(after.Leaves) != 4 || after.Leaves[2].Epoch != 2 || after.Leaves[3].Epoch != 1 {
+		t.Errorf("Apply() leaves = %v", after.Leaves)
+	}
+
+	broken := genUpdate(t, setup, next, 2, 5)
+	broken.Leaves[0].PrevHash.SetInt64(5)
+	if _, err = next.Apply(broken); err != ErrBrokenHashChain {
+		t.Errorf("Apply() with a broken hash chain = %v, want %v", err, ErrBrokenHashChain)
+	}
+	stale := genUpdate(t, setup, next, 2, 9)
+	stale.Epoch = 1
+	if _, err = next.Apply(stale); err != ErrInvalidUpdate {
+		t.Errorf("Apply() of an old epoch = %v, want %v", err, ErrInvalidUpdate)
+	}
+	unsorted := genUpdate(t, setup, next, 2, 9, 11)
+	unsorted.Leaves[0], unsorted.Leaves[1] = unsorted.Leaves[1], unsorted.Leaves[0]
+	if _, err = next.Apply(unsorted); err != ErrInvalidUpdate {
+		t.Errorf("Apply() of unsorted leaves = %v, want %v", err, ErrInvalidUpdate)
+	}
+
+	after.Witnesses[1] = new(big.Int).Set(after.Witnesses[0])
+	if err = after.Verify(setup, 0); err != ErrInconsistentState {
+		t.Errorf("Verify() with a wrong witness = %v, want %v", err, ErrInconsistentState)
+	}
+}
+
+func TestStateProveWitnesses(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	state := NewState(setup)
+	update := genRandomizedUpdate(t, setup, state, 1, big.NewInt(7), 2, 4, 6)
+	withoutWitnesses := *update
+	withoutWitnesses.Witnesses = nil
+	next, err := state.Apply(&withoutWitnesses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = next.Verify(setup, 0); err != ErrInconsistentState {
+		t.Errorf("Verify() without witnesses = %v, want %v", err, ErrInconsistentState)
+	}
+	next.ProveWitnesses(setup, 2)
+	if err = next.Verify(setup, 0); err != nil {
+		t.Fatal(err)
+	}
+	for i := range next.Witnesses {
+		if next.Witnesses[i].Cmp(update.Witnesses[i]) != 0 {
+			t.Errorf("ProveWitnesses() witness %d differs from the committed one", i)
+		}
+	}
+	if err = NewMemoryStore(state).Commit(&withoutWitnesses); err != ErrInvalidUpdate {
+		t.Errorf("Commit() without witnesses = %v, want %v", err, ErrInvalidUpdate)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	var s Store = NewMemoryStore(NewState(setup))
+	update := genUpdate(t, setup, s.State(), 1, 2, 4)
+	if err := s.Commit(update); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Commit(update); err != ErrInvalidUpdate {
+		t.Errorf("Commit() twice = %v, want %v", err, ErrInvalidUpdate)
+	}
+	state := s.State()
+	state.Leaves[0].Balance++
+	if s.State().Leaves[0].Balance == state.Leaves[0].Balance {
+		t.Error("State() does not return a copy")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
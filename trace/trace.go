@@ -0,0 +1,136 @@
+// Package trace lets callers observe the phases of long running computations.
+// The library is quiet by default, a Tracer passed with WithTracer receives
+// the start and the end of every phase.
+package trace
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// Phase names one step of a computation
+type Phase string
+
+// Phases reported by the accumulator and zkmultiswap packages
+const (
+	PhaseGenRepresentatives Phase = "representative generation"
+	PhasePrecompute         Phase = "proof precompute"
+	PhaseSNARKLoad          Phase = "SNARK load"
+	PhaseSNARKProve         Phase = "SNARK prove"
+	PhaseSNARKVerify        Phase = "SNARK verify"
+	PhasePublicWitness      Phase = "public witness check"
+)
+
+// Tracer observes the phases of a computation, the methods may be called from several goroutines
+type Tracer interface {
+	StartPhase(phase Phase)
+	EndPhase(phase Phase)
+}
+
+// Nop is the default Tracer that ignores all phases
+var Nop Tracer = nopTracer{}
+
+type nopTracer struct{}
+
+func (nopTracer) StartPhase(Phase) {}
+func (nopTracer) EndPhase(Phase)   {}
+
+// Trapdoor is a secret of the prover that speeds up its exponentiations modulo N,
+// e.g. the *accumulator.TrapdoorSetup set by accumulator.WithTrapdoor
+type Trapdoor interface {
+	// Modulus returns the N the trapdoor belongs to
+	Modulus() *big.Int
+}
+
+// Options holds the observers of one call, the goroutines it may use and the secrets of the prover
+type Options struct {
+	Tracer Tracer
+	// NumWorkers is the number of goroutines of the parallel phases that take a worker count, all CPUs if <= 0
+	NumWorkers int
+	// Trapdoor is used by the provers that document it, nil if unknown
+	Trapdoor Trapdoor
+}
+
+// Option configures the Options of one call
+type Option func(*Options)
+
+// WithTracer sets the Tracer of the call
+func WithTracer(t Tracer) Option {
+	return func(o *Options) {
+		o.Tracer = t
+	}
+}
+
+// WithNumWorkers sets the number of goroutines of the parallel phases, n <= 0 uses all CPUs
+func WithNumWorkers(n int) Option {
+	return func(o *Options) {
+		o.NumWorkers = n
+	}
+}
+
+// NewOptions applies the options on the defaults, the default Tracer is Nop
+func NewOptions(opts ...Option) *Options {
+	ret := &Options{Tracer: Nop}
+	for _, opt := range opts {
+		opt(ret)
+	}
+	if ret.Tracer == nil {
+		ret.Tracer = Nop
+	}
+	return ret
+}
+
+// Event is one finished phase
+type Event struct {
+	Phase    Phase
+	Start    time.Time
+	Duration time.Duration
+}
+
+// EventTracer measures the duration of every phase and passes it to the handler as an Event
+type EventTracer struct {
+	mu      sync.Mutex
+	started map[Phase][]time.Time
+	handler func(Event)
+}
+
+// NewEventTracer returns an EventTracer calling the handler at the end of every phase
+func NewEventTracer(handler func(Event)) *EventTracer {
+	return &EventTracer{
+		started: make(map[Phase][]time.Time),
+		handler: handler,
+	}
+}
+
+// StartPhase records the start time of the phase, the same phase may be nested
+func (t *EventTracer) StartPhase(phase Phase) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.started[phase] = append(t.started[phase], time.Now())
+}
+
+// EndPhase emits the Event of the latest started phase, a phase that is not started is ignored
+func (t *EventTracer) EndPhase(phase Phase) {
+	end := time.Now()
+	t.mu.Lock()
+	stack := t.started[phase]
+	if len(stack) == 0 {
+		t.mu.Unlock()
+		return
+	}
+	start := stack[len(stack)-1]
+	t.started[phase] = stack[:len(stack)-1]
+	t.mu.Unlock()
+	t.handler(Event{Phase: phase, Start: start, Duration: end.Sub(start)})
+}
+
+// NewWriterTracer returns a Tracer printing the duration of every phase to w,
+// e.g. os.Stdout for the output of the earlier versions
+func NewWriterTracer(w io.Writer) Tracer {
+	return NewEventTracer(func(e Event) {
+		fmt.Fprintf(w, "Running %s Takes [%.3f] Seconds \n", e.Phase, e.Duration.Seconds())
+	})
+}
@@ -0,0 +1,45 @@
+package trace
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEventTracer(t *testing.T) {
+	var events []Event
+	tracer := NewEventTracer(func(e Event) {
+		events = append(events, e)
+	})
+	opts := NewOptions(WithTracer(tracer))
+	opts.Tracer.StartPhase(PhaseSNARKLoad)
+	opts.Tracer.StartPhase(PhaseSNARKProve)
+	opts.Tracer.EndPhase(PhaseSNARKProve)
+	opts.Tracer.EndPhase(PhaseSNARKLoad)
+	opts.Tracer.EndPhase(PhaseSNARKVerify)
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if events[0].Phase != PhaseSNARKProve || events[1].Phase != PhaseSNARKLoad {
+		t.Errorf("events in wrong order: %v", events)
+	}
+	if events[1].Duration < events[0].Duration {
+		t.Errorf("outer phase is shorter than the inner phase")
+	}
+}
+
+func TestDefaultOptions(t *testing.T) {
+	if NewOptions().Tracer != Nop || NewOptions(WithTracer(nil)).Tracer != Nop {
+		t.Errorf("default tracer should be Nop")
+	}
+	if NewOptions().NumWorkers != 0 || NewOptions(WithNumWorkers(3)).NumWorkers != 3 {
+		t.Errorf("unexpected number of workers")
+	}
+	var buf bytes.Buffer
+	tracer := NewWriterTracer(&buf)
+	tracer.StartPhase(PhaseGenRepresentatives)
+	tracer.EndPhase(PhaseGenRepresentatives)
+	if !strings.HasPrefix(buf.String(), "Running representative generation Takes") {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
@@ -31,10 +31,13 @@ type Circuit struct {
 	OriginalHashes   []frontend.Variable // list of user hasher before update
 	OriginalUpdEpoch []frontend.Variable // list of user updated epoch number before update
 	UpdatedBalances  []frontend.Variable // list of user balances after update
+	//------------------------------not part of the witness--------------------------------------
+	Config Config `gnark:"-"` // bit lengths of user IDs and balances, the zero value is DefaultConfig
 }
 
 // Define declares the circuit constraints
 func (circuit Circuit) Define(api frontend.API) error {
+	config := circuit.Config.orDefault()
 	api.ToBinary(circuit.Randomizer1, BitLength)
 	api.ToBinary(circuit.Randomizer2, BitLength)
 	api.AssertIsLess(circuit.DeltaModL1, circuit.ChallengeL1)
@@ -50,25 +53,26 @@ func (circuit Circuit) Define(api frontend.API) error {
 	// ToBinary not only returns the binary, but additionaly checks if the binary representation is same as the input,
 	// which means the input can be represented with the bit-length
 	api.ToBinary(circuit.CurrentEpochNum, BitLength)
-	api.ToBinary(circuit.OriginalSum, BitLength)
-	api.ToBinary(circuit.UpdatedSum, BitLength)
+	api.ToBinary(circuit.OriginalSum, config.BalanceBits)
+	api.ToBinary(circuit.UpdatedSum, config.BalanceBits)
 
 	// check we do not have repeating IDs and IDs in correct range
 	for i := 0; i < len(circuit.UserID)-1; i++ {
 		api.AssertIsLess(circuit.UserID[i], circuit.UserID[i+1])
 	}
-	//api.ToBinary(circuit.UserID[len(circuit.UserID)-1], BitLength)
+	// the IDs are in ascending order, so the largest one being in range is enough
+	if len(circuit.UserID) > 0 {
+		api.ToBinary(circuit.UserID[len(circuit.UserID)-1], config.IDBits)
+	}
 
 	for i := 0; i < len(circuit.UserID); i++ {
-		api.ToBinary(circuit.OriginalBalances[i], BitLength)
+		api.ToBinary(circuit.OriginalBalances[i], config.BalanceBits)
 		api.AssertIsLess(circuit.OriginalUpdEpoch[i], circuit.CurrentEpochNum)
-		api.ToBinary(circuit.UpdatedBalances[i], BitLength)
+		api.ToBinary(circuit.UpdatedBalances[i], config.BalanceBits)
 	}
 
 	var remainder1, remainder2 frontend.Variable = 1, 1
 	tempSum := circuit.OriginalSum
-	tempSum = api.Sub(tempSum, circuit.OriginalBalances[0])
-	tempSum = api.Add(tempSum, circuit.UpdatedBalances[0])
 	for i := 0; i < len(circuit.UserID); i++ {
 		tempHash0 := poseidon.Poseidon(api, circuit.UserID[i], circuit.OriginalBalances[i], circuit.OriginalUpdEpoch[i], circuit.OriginalHashes[i])
 		//api.Println(tempHash0)
@@ -100,7 +104,13 @@ func (circuit Circuit) Define(api frontend.API) error {
 
 // InitCircuitWithSize init a circuit with challenges, OriginalHashes and CurrentEpochNum value 1, all other values 0. Use for test purpose only.
 func InitCircuitWithSize(size uint32) *Circuit {
+	return InitCircuitWithConfig(size, DefaultConfig)
+}
+
+// InitCircuitWithConfig is InitCircuitWithSize for the bit lengths of config
+func InitCircuitWithConfig(size uint32, config Config) *Circuit {
 	var circuit Circuit
+	circuit.Config = config.orDefault()
 	circuit.ChallengeL1 = 1
 	circuit.ChallengeL2 = 1
 	circuit.RemainderR1 = 0
@@ -133,8 +143,17 @@ func AssignCircuit(input *UpdateSet32) *Circuit {
 	if !input.IsValid() {
 		panic("error in InitCircuit, the input set is invalid")
 	}
+	return AssignUpdateSet(input.UpdateSet())
+}
+
+// AssignUpdateSet assign a circuit of the configuration of the input with UpdateSet values.
+func AssignUpdateSet(input *UpdateSet) *Circuit {
+	if !input.IsValid() {
+		panic("error in AssignUpdateSet, the input set is invalid")
+	}
 	var circuit Circuit
 	size := len(input.OriginalBalances)
+	circuit.Config = input.Config.orDefault()
 	circuit.ChallengeL1 = input.ChallengeL1
 	circuit.ChallengeL2 = input.ChallengeL2
 	circuit.RemainderR1 = input.RemainderR1
@@ -22,17 +22,29 @@ import (
 )
 
 const (
-	// BitLength is the bit length of the user ID, balnace and epoch number. It can be 32, 64 or any valid number within the field
+	// BitLength is the bit length of the epoch number and of the randomizers, and of the user ID and balance of UpdateSet32.
+	// Other bit lengths of user IDs and balances are set by Config
 	BitLength = 32
 	// CurrentEpochNum is used for *test purpose* only. It should be larger than the test set size and all OriginalUpdEpoch
 	CurrentEpochNum = 1000000
 	// OriginalSum is used for *test purpose* only. It should be larger than 0 and the updated balance should also be positive
 	OriginalSum = 10000
 
-	// KeyPathPrefix denotes the path to store the circuit and keys. fileName = KeyPathPrefix + "_" + strconv.FormatInt(int64(size), 10) + different names
+	// KeyPathPrefix denotes the path to store the circuit and keys. fileName = Config.KeyPath(size) + different names
 	KeyPathPrefix = "zkmultiswap"
+	// CircuitVersion is part of the key file names. It changes with the constraints of the circuit,
+	// so that the keys of an older circuit are reported as not found instead of producing invalid proofs
+	CircuitVersion = 3
+	// TranscriptDomain starts the transcript of SetupTranscript. It changes with the content of the transcript,
+	// so that the challenges of an older transcript do not match and its proofs are rejected
+	TranscriptDomain = "zkmultiswap/transcript/v2"
 )
 
+// KeyPath returns the path prefix of the circuit and the keys of DefaultConfig for the set size
+func KeyPath(size uint32) string {
+	return DefaultConfig.KeyPath(size)
+}
+
 // UpdateSet32 is one set for the prover with uint32 for CurrentEpochNum,
 type UpdateSet32 struct {
 	ChallengeL1      big.Int
@@ -53,6 +65,28 @@ type UpdateSet32 struct {
 	UpdatedBalances  []uint32
 }
 
+// UpdateSet is one set for the prover with the user IDs, balances and sums of the bit lengths of Config.
+// Epoch numbers are BitLength bits as in UpdateSet32.
+type UpdateSet struct {
+	Config           Config
+	ChallengeL1      big.Int
+	ChallengeL2      big.Int
+	RemainderR1      big.Int
+	RemainderR2      big.Int
+	CurrentEpochNum  uint32
+	DeltaModL1       big.Int
+	DeltaModL2       big.Int
+	Randomizer1      big.Int
+	Randomizer2      big.Int
+	OriginalSum      big.Int
+	UpdatedSum       big.Int
+	UserID           []big.Int
+	OriginalBalances []big.Int
+	OriginalHashes   []big.Int
+	OriginalUpdEpoch []uint32
+	UpdatedBalances  []big.Int
+}
+
 // PublicInfo is the public information part of UpdateSet32
 type PublicInfo struct {
 	ChallengeL1     big.Int
@@ -84,6 +118,64 @@ func (input *UpdateSet32) IsValid() bool {
 	return true
 }
 
+// IsValid returns true only if the input is valid for multiSwap and all values fit into the bit lengths of its Config
+func (input *UpdateSet) IsValid() bool {
+	if !input.Config.IsValid() || len(input.UserID) < 2 {
+		return false
+	}
+	if len(input.UserID) != len(input.OriginalBalances) || len(input.UserID) != len(input.OriginalHashes) ||
+		len(input.UserID) != len(input.OriginalUpdEpoch) || len(input.UserID) != len(input.UpdatedBalances) {
+		return false
+	}
+	config := input.Config.orDefault()
+	if !inRange(&input.OriginalSum, config.BalanceBits) || !inRange(&input.UpdatedSum, config.BalanceBits) {
+		return false
+	}
+	for i := range input.UserID {
+		if !inRange(&input.UserID[i], config.IDBits) || !inRange(&input.OriginalBalances[i], config.BalanceBits) ||
+			!inRange(&input.UpdatedBalances[i], config.BalanceBits) {
+			return false
+		}
+	}
+	return true
+}
+
+// UpdateSet returns the set as an UpdateSet of Config32
+func (input *UpdateSet32) UpdateSet() *UpdateSet {
+	ret := &UpdateSet{
+		Config:           Config32,
+		CurrentEpochNum:  input.CurrentEpochNum,
+		UserID:           make([]big.Int, len(input.UserID)),
+		OriginalBalances: make([]big.Int, len(input.OriginalBalances)),
+		OriginalHashes:   make([]big.Int, len(input.OriginalHashes)),
+		OriginalUpdEpoch: append([]uint32(nil), input.OriginalUpdEpoch...),
+		UpdatedBalances:  make([]big.Int, len(input.UpdatedBalances)),
+	}
+	ret.ChallengeL1.Set(&input.ChallengeL1)
+	ret.ChallengeL2.Set(&input.ChallengeL2)
+	ret.RemainderR1.Set(&input.RemainderR1)
+	ret.RemainderR2.Set(&input.RemainderR2)
+	ret.DeltaModL1.Set(&input.DeltaModL1)
+	ret.DeltaModL2.Set(&input.DeltaModL2)
+	ret.Randomizer1.Set(&input.Randomizer1)
+	ret.Randomizer2.Set(&input.Randomizer2)
+	ret.OriginalSum.SetUint64(uint64(input.OriginalSum))
+	ret.UpdatedSum.SetUint64(uint64(input.UpdatedSum))
+	for i := range input.UserID {
+		ret.UserID[i].SetUint64(uint64(input.UserID[i]))
+	}
+	for i := range input.OriginalBalances {
+		ret.OriginalBalances[i].SetUint64(uint64(input.OriginalBalances[i]))
+	}
+	for i := range input.OriginalHashes {
+		ret.OriginalHashes[i].Set(&input.OriginalHashes[i])
+	}
+	for i := range input.UpdatedBalances {
+		ret.UpdatedBalances[i].SetUint64(uint64(input.UpdatedBalances[i]))
+	}
+	return ret
+}
+
 func getRandomAcc(setup *accumulator.Setup) *big.Int {
 	var ret big.Int
 	rand := accumulator.GenRandomizer()
@@ -91,13 +183,57 @@ func getRandomAcc(setup *accumulator.Setup) *big.Int {
 	return &ret
 }
 
-// SetupTranscript should takes in all public information regarding the MultiSwap
+// SetupTranscript takes in all public information regarding the MultiSwap, the challenges L1 and L2 depend on the three accumulators
 func SetupTranscript(setup *accumulator.Setup, accOld, accMid, accNew *big.Int, CurrentEpochNum uint32) *fiatshamir.Transcript {
-	transcript := fiatshamir.InitTranscript([]string{setup.G.String(), setup.N.String()}, fiatshamir.Max252)
+	transcript := fiatshamir.InitTranscript([]string{TranscriptDomain, setup.G.String(), setup.N.String()}, fiatshamir.Max252)
+	transcript.AppendSlice([]string{accOld.String(), accMid.String(), accNew.String()})
 	transcript.Append(strconv.Itoa(int(CurrentEpochNum)))
 	return transcript
 }
 
+// RandomizerProduct returns the product of the 8 Poseidon hashes of the randomizer and 0 to 7.
+// Because gnark cannot support 2048-bits large integers, we are using the product of 8 255-bits random numbers
+// to replace one large RSA-domain randomizer.
+func RandomizerProduct(randomizer *big.Int) *big.Int {
+	ret := big.NewInt(1)
+	var tempInt big.Int
+	for i := 0; i < 8; i++ {
+		tempHash := poseidon.Poseidon(accumulator.ElementFromBigInt(randomizer), accumulator.ElementFromUint32(uint32(i)))
+		tempHash.ToBigIntRegular(&tempInt)
+		ret.Mul(ret, &tempInt)
+	}
+	return ret
+}
+
+// genTestPublicInfo returns the public information of the update removing and inserting the sets, Acc_mid is random
+func genTestPublicInfo(setup *accumulator.Setup, removeSet, insertSet []*big.Int, randomizer1, randomizer2 *big.Int, currentEpochNum uint32) *PublicInfo {
+	prod1 := accumulator.SetProductRecursiveFast(removeSet)
+	prod2 := accumulator.SetProductRecursiveFast(insertSet)
+	prod1.Mul(prod1, RandomizerProduct(randomizer1))
+	prod2.Mul(prod2, RandomizerProduct(randomizer2))
+
+	// get accumulators
+	accMid := getRandomAcc(setup)
+	var accOld, accNew big.Int
+	accOld.Exp(accMid, prod1, setup.N)
+	accNew.Exp(accMid, prod2, setup.N)
+
+	// get challenge
+	transcript := SetupTranscript(setup, &accOld, accMid, &accNew, currentEpochNum)
+	challengeL1 := transcript.GetChallengeAndAppendTranscript()
+	challengeL2 := transcript.GetChallengeAndAppendTranscript()
+
+	var ret PublicInfo
+	ret.ChallengeL1.Set(challengeL1)
+	ret.ChallengeL2.Set(challengeL2)
+	ret.RemainderR1.Mod(prod1, challengeL1)
+	ret.RemainderR2.Mod(prod2, challengeL2)
+	ret.CurrentEpochNum = currentEpochNum
+	ret.DeltaModL1.Mod(accumulator.Min1024, challengeL1)
+	ret.DeltaModL2.Mod(accumulator.Min1024, challengeL2)
+	return &ret
+}
+
 // GenTestSet generates a set of values for test purpose.
 func GenTestSet(setsize uint32, setup *accumulator.Setup) *UpdateSet32 {
 	var ret UpdateSet32
@@ -116,6 +252,11 @@ func GenTestSet(setsize uint32, setup *accumulator.Setup) *UpdateSet32 {
 		ret.OriginalHashes[i].SetInt64(int64(j))
 		ret.UpdatedBalances[i] = j
 	}
+	// the first user transfers 1 to the second one, the sum is unchanged
+	if setsize >= 2 {
+		ret.UpdatedBalances[0]--
+		ret.UpdatedBalances[1]++
+	}
 	ret.OriginalSum = OriginalSum
 	ret.UpdatedSum = OriginalSum // UpdatedSum can be any valid positive numbers, but we are testing the case UpdatedSum = OriginalSum for simplicity
 
@@ -132,57 +273,94 @@ func GenTestSet(setsize uint32, setup *accumulator.Setup) *UpdateSet32 {
 		insertSet[i] = accumulator.DIHashPoseidon(accumulator.ElementFromUint32(ret.UserID[i]), accumulator.ElementFromUint32(ret.UpdatedBalances[i]),
 			accumulator.ElementFromUint32(ret.CurrentEpochNum), poseidonhash)
 	}
-	prod1 := accumulator.SetProductRecursiveFast(removeSet)
-	prod2 := accumulator.SetProductRecursiveFast(insertSet)
-
 	// Randomizers are FIXED!!! for test purpose
 	ret.Randomizer1 = *big.NewInt(200)
 	ret.Randomizer2 = *big.NewInt(300)
-	var tempInt big.Int
-	// because gnark cannot support 2048-bits large integers, we are using the product of 8 255-bits random numbers to replace one large RSA-domain randomizer.
-	for i := 0; i < 8; i++ {
-		tempHash := poseidon.Poseidon(accumulator.ElementFromBigInt(&ret.Randomizer1), accumulator.ElementFromUint32(uint32(i)))
-		tempHash.ToBigIntRegular(&tempInt)
-		prod1.Mul(prod1, &tempInt)
+	info := genTestPublicInfo(setup, removeSet, insertSet, &ret.Randomizer1, &ret.Randomizer2, ret.CurrentEpochNum)
+	ret.ChallengeL1 = info.ChallengeL1
+	ret.ChallengeL2 = info.ChallengeL2
+	ret.RemainderR1 = info.RemainderR1
+	ret.RemainderR2 = info.RemainderR2
+	ret.DeltaModL1 = info.DeltaModL1
+	ret.DeltaModL2 = info.DeltaModL2
 
-		tempHash = poseidon.Poseidon(accumulator.ElementFromBigInt(&ret.Randomizer2), accumulator.ElementFromUint32(uint32(i)))
-		tempHash.ToBigIntRegular(&tempInt)
-		prod2.Mul(prod2, &tempInt)
+	if !ret.IsValid() {
+		panic("error in GenTestSet, the generated test set is invalid")
 	}
+	return &ret
+}
 
-	// get accumulators
-	accMid := getRandomAcc(setup)
-	var accOld, accNew big.Int
-	accOld.Exp(accMid, prod1, setup.N)
-	accNew.Exp(accMid, prod2, setup.N)
+// GenTestSetWithConfig generates a set of values of the bit lengths of config for test purpose. The values of
+// GenTestSet are shifted left to the top 32 bits, so that they use the whole bit lengths.
+func GenTestSetWithConfig(setsize uint32, config Config, setup *accumulator.Setup) *UpdateSet {
+	config = config.orDefault()
+	if !config.IsValid() || config.IDBits < 32 || config.BalanceBits < 32 {
+		panic("error in GenTestSetWithConfig, bit lengths of at least 32 are required")
+	}
+	idShift, balanceShift := uint(config.IDBits-32), uint(config.BalanceBits-32)
+	ret := UpdateSet{Config: config, CurrentEpochNum: CurrentEpochNum}
+	ret.UserID = make([]big.Int, setsize)
+	ret.OriginalBalances = make([]big.Int, setsize)
+	ret.OriginalUpdEpoch = make([]uint32, setsize)
+	ret.OriginalHashes = make([]big.Int, setsize)
+	ret.UpdatedBalances = make([]big.Int, setsize)
+	for i := uint32(0); i < setsize; i++ {
+		j := int64(i*2 + 1)
+		ret.UserID[i].Lsh(big.NewInt(j), idShift)
+		ret.OriginalBalances[i].Lsh(big.NewInt(j), balanceShift)
+		ret.OriginalUpdEpoch[i] = 10
+		ret.OriginalHashes[i].SetInt64(j)
+		ret.UpdatedBalances[i].Set(&ret.OriginalBalances[i])
+	}
+	// the first user transfers to the second one as in GenTestSet
+	if setsize >= 2 {
+		unit := new(big.Int).Lsh(big.NewInt(1), balanceShift)
+		ret.UpdatedBalances[0].Sub(&ret.UpdatedBalances[0], unit)
+		ret.UpdatedBalances[1].Add(&ret.UpdatedBalances[1], unit)
+	}
+	ret.OriginalSum.Lsh(big.NewInt(OriginalSum), balanceShift)
+	ret.UpdatedSum.Set(&ret.OriginalSum)
 
-	// get challenge
-	transcript := SetupTranscript(setup, &accOld, accMid, &accNew, ret.CurrentEpochNum)
-	challengeL1 := transcript.GetChallengeAndAppendTranscript()
-	challengeL2 := transcript.GetChallengeAndAppendTranscript()
+	removeSet := make([]*big.Int, setsize)
+	insertSet := make([]*big.Int, setsize)
+	var poseidonhash *fr.Element
+	for i := uint32(0); i < setsize; i++ {
+		userID := accumulator.ElementFromBigInt(&ret.UserID[i])
+		poseidonhash, removeSet[i] = accumulator.PoseidonAndDIHash(userID, accumulator.ElementFromBigInt(&ret.OriginalBalances[i]),
+			accumulator.ElementFromUint32(ret.OriginalUpdEpoch[i]), accumulator.ElementFromBigInt(&ret.OriginalHashes[i]))
+		insertSet[i] = accumulator.DIHashPoseidon(userID, accumulator.ElementFromBigInt(&ret.UpdatedBalances[i]),
+			accumulator.ElementFromUint32(ret.CurrentEpochNum), poseidonhash)
+	}
 
-	// get remainder
-	remainderR1 := big.NewInt(1)
-	remainderR2 := big.NewInt(1)
-	remainderR1.Mod(prod1, challengeL1)
-	remainderR2.Mod(prod2, challengeL2)
-
-	ret.ChallengeL1 = *challengeL1
-	ret.ChallengeL2 = *challengeL2
-	ret.RemainderR1 = *remainderR1
-	ret.RemainderR2 = *remainderR2
-	var deltaModL1, deltaModL2 big.Int
-	deltaModL1.Mod(accumulator.Min1024, challengeL1)
-	deltaModL2.Mod(accumulator.Min1024, challengeL2)
-	ret.DeltaModL1 = deltaModL1
-	ret.DeltaModL2 = deltaModL2
+	ret.Randomizer1.SetInt64(200)
+	ret.Randomizer2.SetInt64(300)
+	info := genTestPublicInfo(setup, removeSet, insertSet, &ret.Randomizer1, &ret.Randomizer2, ret.CurrentEpochNum)
+	ret.ChallengeL1 = info.ChallengeL1
+	ret.ChallengeL2 = info.ChallengeL2
+	ret.RemainderR1 = info.RemainderR1
+	ret.RemainderR2 = info.RemainderR2
+	ret.DeltaModL1 = info.DeltaModL1
+	ret.DeltaModL2 = info.DeltaModL2
 
 	if !ret.IsValid() {
-		panic("error in GenTestSet, the generated test set is invalid")
+		panic("error in GenTestSetWithConfig, the generated test set is invalid")
 	}
 	return &ret
 }
 
+// PublicPart returns the public information of the set
+func (input *UpdateSet) PublicPart() *PublicInfo {
+	var ret PublicInfo
+	ret.ChallengeL1 = input.ChallengeL1
+	ret.ChallengeL2 = input.ChallengeL2
+	ret.RemainderR1 = input.RemainderR1
+	ret.RemainderR2 = input.RemainderR2
+	ret.CurrentEpochNum = input.CurrentEpochNum
+	ret.DeltaModL1 = input.DeltaModL1
+	ret.DeltaModL2 = input.DeltaModL2
+	return &ret
+}
+
 // PublicPart returns a new UpdateSet32 with same public part and hidden part 0
 func (input *UpdateSet32) PublicPart() *PublicInfo {
 	var ret PublicInfo
@@ -197,7 +375,7 @@ func (input *UpdateSet32) PublicPart() *PublicInfo {
 }
 
 func isCircuitExist(testSetSize uint32) bool {
-	fileName := KeyPathPrefix + "_" + strconv.FormatInt(int64(testSetSize), 10) + ".ccs.save"
+	fileName := DefaultConfig.KeyPath(testSetSize) + ".ccs.save"
 	_, err := os.Stat(fileName)
 	if err == nil {
 		return true
@@ -246,8 +424,7 @@ func TestMultiSwapAndOutputSmartContract(testSetSize uint32) {
 	} else {
 		fmt.Println("Circuit have already been compiled for test purpose.")
 	}
-	fileName := KeyPathPrefix + "_" + strconv.FormatInt(int64(testSetSize), 10)
-	vk, err := LoadVerifyingKey(fileName)
+	vk, err := LoadVerifyingKey(DefaultConfig.KeyPath(testSetSize))
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,66 @@
+package zkmultiswap
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// MaxBitLength is the largest bit length of user IDs and balances. The sums of balances are computed in the BN254
+// scalar field of 254 bits, 128-bits balances leave room for any batch size without wrapping around the modulus.
+const MaxBitLength = 128
+
+// Config is the bit lengths of the values checked by the circuit. Epoch numbers and randomizers are always BitLength
+// bits, the epoch number being public information. The zero Config is DefaultConfig. Leaf, and so the packages
+// notus, store and client, hold uint32 values and work with Config32 only, notus rejects any other Config.
+type Config struct {
+	IDBits      int // bit length of user IDs
+	BalanceBits int // bit length of balances and of their sums
+}
+
+var (
+	// Config32 is the configuration of UpdateSet32, with 32-bits user IDs and balances
+	Config32 = Config{IDBits: 32, BalanceBits: 32}
+	// Config64 has 64-bits user IDs and balances, enough for balances in satoshi
+	Config64 = Config{IDBits: 64, BalanceBits: 64}
+	// Config128 has 128-bits user IDs and balances, enough for balances in wei
+	Config128 = Config{IDBits: 128, BalanceBits: 128}
+	// DefaultConfig is the configuration used by the functions without a Config
+	DefaultConfig = Config32
+)
+
+func (c Config) orDefault() Config {
+	if c == (Config{}) {
+		return DefaultConfig
+	}
+	return c
+}
+
+// IsValid returns true if the bit lengths are positive and at most MaxBitLength
+func (c Config) IsValid() bool {
+	c = c.orDefault()
+	return c.IDBits > 0 && c.IDBits <= MaxBitLength && c.BalanceBits > 0 && c.BalanceBits <= MaxBitLength
+}
+
+// String returns the bit lengths of the configuration
+func (c Config) String() string {
+	c = c.orDefault()
+	return fmt.Sprintf("id%d_balance%d", c.IDBits, c.BalanceBits)
+}
+
+// KeyPath returns the path prefix of the circuit and the keys of the set size. The path has the CircuitVersion,
+// so that the keys of an older circuit, DefaultConfig included, are not found, and the bit lengths of the
+// configurations other than DefaultConfig so that several variants can coexist.
+func (c Config) KeyPath(size uint32) string {
+	c = c.orDefault()
+	prefix := KeyPathPrefix + "_v" + strconv.Itoa(CircuitVersion)
+	if c != DefaultConfig {
+		prefix += "_" + c.String()
+	}
+	return prefix + "_" + strconv.FormatInt(int64(size), 10)
+}
+
+// inRange returns true if 0 <= x < 2^bits
+func inRange(x *big.Int, bits int) bool {
+	return x.Sign() >= 0 && x.BitLen() <= bits
+}
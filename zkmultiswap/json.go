@@ -0,0 +1,55 @@
+package zkmultiswap
+
+import (
+	"encoding/json"
+
+	"github.com/jiajunxin/rsa_accumulator/codec"
+)
+
+type publicInfoJSON struct {
+	codec.Header
+	ChallengeL1     string `json:"challengeL1"`
+	ChallengeL2     string `json:"challengeL2"`
+	RemainderR1     string `json:"remainderR1"`
+	RemainderR2     string `json:"remainderR2"`
+	CurrentEpochNum uint32 `json:"currentEpochNum"`
+	DeltaModL1      string `json:"deltaModL1"`
+	DeltaModL2      string `json:"deltaModL2"`
+}
+
+// MarshalJSON encodes the public information as JSON with hex integers
+func (info *PublicInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(publicInfoJSON{
+		Header:          codec.NewHeader("PublicInfo"),
+		ChallengeL1:     codec.EncodeHex(&info.ChallengeL1),
+		ChallengeL2:     codec.EncodeHex(&info.ChallengeL2),
+		RemainderR1:     codec.EncodeHex(&info.RemainderR1),
+		RemainderR2:     codec.EncodeHex(&info.RemainderR2),
+		CurrentEpochNum: info.CurrentEpochNum,
+		DeltaModL1:      codec.EncodeHex(&info.DeltaModL1),
+		DeltaModL2:      codec.EncodeHex(&info.DeltaModL2),
+	})
+}
+
+// UnmarshalJSON decodes the public information encoded by MarshalJSON
+func (info *PublicInfo) UnmarshalJSON(data []byte) error {
+	var v publicInfoJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.Check("PublicInfo"); err != nil {
+		return err
+	}
+	values, err := codec.DecodeNatHexList(v.ChallengeL1, v.ChallengeL2, v.RemainderR1, v.RemainderR2, v.DeltaModL1, v.DeltaModL2)
+	if err != nil {
+		return err
+	}
+	info.ChallengeL1.Set(values[0])
+	info.ChallengeL2.Set(values[1])
+	info.RemainderR1.Set(values[2])
+	info.RemainderR2.Set(values[3])
+	info.CurrentEpochNum = v.CurrentEpochNum
+	info.DeltaModL1.Set(values[4])
+	info.DeltaModL2.Set(values[5])
+	return nil
+}
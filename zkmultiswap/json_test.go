@@ -0,0 +1,30 @@
+package zkmultiswap
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/internal/golden"
+)
+
+func TestPublicInfoJSON(t *testing.T) {
+	var info PublicInfo
+	info.ChallengeL1.SetInt64(1000003)
+	info.ChallengeL2.SetInt64(1000033)
+	info.RemainderR1.SetInt64(12345)
+	info.RemainderR2.SetInt64(67890)
+	info.CurrentEpochNum = CurrentEpochNum
+	info.DeltaModL1.SetInt64(424242)
+	info.DeltaModL2.SetInt64(0)
+
+	data := golden.JSON(t, "public_info.json", &info)
+	var decoded PublicInfo
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ChallengeL1.Cmp(&info.ChallengeL1) != 0 || decoded.RemainderR2.Cmp(&info.RemainderR2) != 0 ||
+		decoded.DeltaModL2.Cmp(&info.DeltaModL2) != 0 || decoded.CurrentEpochNum != info.CurrentEpochNum {
+		t.Errorf("decoded public information does not match")
+	}
+	golden.CheckJSON(t, data, &decoded)
+}
@@ -0,0 +1,48 @@
+package zkmultiswap
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+)
+
+// Leaf is the accumulated record of one user, the same values as one entry of the original part of UpdateSet32.
+// PrevHash is the Poseidon part of the hash of the previous leaf of the user, building a hash chain of its balances.
+type Leaf struct {
+	UserID   uint32
+	Balance  uint32
+	Epoch    uint32
+	PrevHash big.Int
+}
+
+// Hash returns the Poseidon hash of the leaf together with its DI hash, the element in the accumulator
+func (leaf *Leaf) Hash() (*fr.Element, *big.Int) {
+	return accumulator.PoseidonAndDIHash(accumulator.ElementFromUint32(leaf.UserID), accumulator.ElementFromUint32(leaf.Balance),
+		accumulator.ElementFromUint32(leaf.Epoch), accumulator.ElementFromBigInt(&leaf.PrevHash))
+}
+
+// Representative returns the DI hash of the leaf
+func (leaf *Leaf) Representative() *big.Int {
+	_, ret := leaf.Hash()
+	return ret
+}
+
+// Next returns the leaf of the user replacing this one with the balance at the epoch
+func (leaf *Leaf) Next(balance, epoch uint32) Leaf {
+	poseidonhash, _ := leaf.Hash()
+	ret := Leaf{UserID: leaf.UserID, Balance: balance, Epoch: epoch}
+	poseidonhash.ToBigIntRegular(&ret.PrevHash)
+	return ret
+}
+
+// Leaves returns the original leaves of the update set, in the order of UserID
+func (input *UpdateSet32) Leaves() []Leaf {
+	ret := make([]Leaf, len(input.UserID))
+	for i := range ret {
+		ret[i] = Leaf{UserID: input.UserID[i], Balance: input.OriginalBalances[i], Epoch: input.OriginalUpdEpoch[i]}
+		ret[i].PrevHash.Set(&input.OriginalHashes[i])
+	}
+	return ret
+}
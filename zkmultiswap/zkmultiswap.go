@@ -1,12 +1,11 @@
 package zkmultiswap
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
 	"runtime"
-	"strconv"
-	"time"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
@@ -14,19 +13,28 @@ import (
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/jiajunxin/rsa_accumulator/trace"
+)
+
+var (
+	// ErrKeyNotFound is returned when the proving key, the r1cs or the verifying key cannot be loaded
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrInvalidStatement is returned when the input set is not a valid statement for the circuit
+	ErrInvalidStatement = errors.New("invalid statement")
+	// ErrInvalidConfig is returned when the bit lengths of a Config are out of range
+	ErrInvalidConfig = errors.New("invalid bit lengths")
 )
 
 // LoadVerifyingKey load the verification key from the filepath
 func LoadVerifyingKey(filepath string) (verifyingKey groth16.VerifyingKey, err error) {
 	verifyingKey = groth16.NewVerifyingKey(ecc.BN254)
-	f, _ := os.Open(filepath + ".vk.save")
-	_, err = verifyingKey.ReadFrom(f)
+	f, err := os.Open(filepath + ".vk.save")
 	if err != nil {
-		return verifyingKey, fmt.Errorf("read file error")
+		return verifyingKey, fmt.Errorf("%w: %v", ErrKeyNotFound, err)
 	}
-	err = f.Close()
-	if err != nil {
-		return verifyingKey, fmt.Errorf("close file error")
+	defer f.Close()
+	if _, err = verifyingKey.ReadFrom(f); err != nil {
+		return verifyingKey, fmt.Errorf("%w: read verifying key: %v", ErrKeyNotFound, err)
 	}
 	return verifyingKey, nil
 }
@@ -34,78 +42,86 @@ func LoadVerifyingKey(filepath string) (verifyingKey groth16.VerifyingKey, err e
 // SetupZkMultiswap generates the circuit and public/verification keys with Groth16
 // "keyPathPrefix".pk* are for public keys, "keyPathPrefix".ccs* are for r1cs, "keyPathPrefix".vk,save is for verification keys
 func SetupZkMultiswap(size uint32) {
-	// compiles our circuit into a R1CS
-	circuit := InitCircuitWithSize(size)
 	fmt.Println("Start Compiling")
-	r1cs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, circuit) //, frontend.IgnoreUnconstrainedInputs()
-	if err != nil {
+	if err := SetupZkMultiswapWithError(size); err != nil {
 		panic(err)
 	}
-	fmt.Println("Finish Compiling")
-	fmt.Println("Number of constrains: ", r1cs.GetNbConstraints())
+	fmt.Println("Finish Setup")
+}
 
-	fileName := KeyPathPrefix + "_" + strconv.FormatInt(int64(size), 10)
-	err = groth16.SetupLazyWithDump(r1cs, fileName)
+// SetupZkMultiswapWithError is SetupZkMultiswap returning the compile or setup error instead of panicking
+func SetupZkMultiswapWithError(size uint32) error {
+	return SetupZkMultiswapWithConfig(size, DefaultConfig)
+}
+
+// SetupZkMultiswapWithConfig is SetupZkMultiswapWithError for the bit lengths of config, the files are named by
+// config.KeyPath(size)
+func SetupZkMultiswapWithConfig(size uint32, config Config) error {
+	if !config.IsValid() {
+		return ErrInvalidConfig
+	}
+	// compiles our circuit into a R1CS
+	circuit := InitCircuitWithConfig(size, config)
+	r1cs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, circuit) //, frontend.IgnoreUnconstrainedInputs()
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("compile circuit: %w", err)
 	}
-	fmt.Println("Finish Setup")
+	return groth16.SetupLazyWithDump(r1cs, config.KeyPath(size))
 }
 
 // Prove is used to generate a Groth16 proof and public witness for the zkMultiSwap
-func Prove(input *UpdateSet32) (*groth16.Proof, error) {
-	fmt.Println("Start Proving")
-	fileName := KeyPathPrefix + "_" + strconv.FormatInt(int64(len(input.UserID)), 10)
-	startingTime := time.Now().UTC()
+func Prove(input *UpdateSet32, opts ...trace.Option) (*groth16.Proof, error) {
+	if !input.IsValid() {
+		return nil, ErrInvalidStatement
+	}
+	return ProveUpdateSet(input.UpdateSet(), opts...)
+}
+
+// ProveUpdateSet is Prove for an UpdateSet, with the keys of its configuration
+func ProveUpdateSet(input *UpdateSet, opts ...trace.Option) (*groth16.Proof, error) {
+	tracer := trace.NewOptions(opts...).Tracer
+	if !input.IsValid() {
+		return nil, ErrInvalidStatement
+	}
+	fileName := input.Config.KeyPath(uint32(len(input.UserID)))
+	tracer.StartPhase(trace.PhaseSNARKLoad)
 	pk, err := groth16.ReadSegmentProveKey(fileName)
 	if err != nil {
-		fmt.Println("error while ReadSegmentProveKey")
-		return nil, err
+		tracer.EndPhase(trace.PhaseSNARKLoad)
+		return nil, fmt.Errorf("%w: read proving key: %v", ErrKeyNotFound, err)
 	}
 	r1cs, err := groth16.LoadR1CSFromFile(fileName)
+	tracer.EndPhase(trace.PhaseSNARKLoad)
 	if err != nil {
-		fmt.Println("error while LoadR1CSFromFile")
-		return nil, err
+		return nil, fmt.Errorf("%w: load r1cs: %v", ErrKeyNotFound, err)
 	}
-	duration := time.Now().UTC().Sub(startingTime)
-	fmt.Printf("Loading a SNARK circuit and proving key for set size = %d, takes [%.3f] Seconds \n", len(input.UserID), duration.Seconds())
 
-	assignment := AssignCircuit(input)
+	assignment := AssignUpdateSet(input)
 	witness, err := frontend.NewWitness(assignment, ecc.BN254)
 	if err != nil {
-		fmt.Println("error while AssignCircuit")
-		return nil, err
+		return nil, fmt.Errorf("assign circuit: %w", err)
 	}
 	runtime.GC()
-	startingTime = time.Now().UTC()
+	tracer.StartPhase(trace.PhaseSNARKProve)
 	proof, err := groth16.ProveRoll(r1cs, pk[0], pk[1], witness, fileName, backend.IgnoreSolverError()) // backend.IgnoreSolverError() can be used for testing
+	tracer.EndPhase(trace.PhaseSNARKProve)
 	if err != nil {
-		fmt.Println("error while ProveRoll")
-		return nil, err
+		return nil, fmt.Errorf("prove: %w", err)
 	}
-	duration = time.Now().UTC().Sub(startingTime)
-	fmt.Printf("Generating a SNARK proof for set size = %d, takes [%.3f] Seconds \n", len(input.UserID), duration.Seconds())
 	return &proof, nil
 }
 
 // VerifyPublicWitness returns true is the public witness is valid for zkMultiSwap
-func VerifyPublicWitness(publicWitness *witness.Witness, publicInfo *PublicInfo) bool {
-	startingTime := time.Now().UTC()
+func VerifyPublicWitness(publicWitness *witness.Witness, publicInfo *PublicInfo, opts ...trace.Option) bool {
+	tracer := trace.NewOptions(opts...).Tracer
+	tracer.StartPhase(trace.PhasePublicWitness)
+	defer tracer.EndPhase(trace.PhasePublicWitness)
 	assignment2 := AssignCircuitHelper(publicInfo)
 	publicWitness2, err := frontend.NewWitness(assignment2, ecc.BN254, frontend.PublicOnly())
 	if err != nil {
-		fmt.Println("Error generating NewWitness")
 		return false
 	}
-	if !reflect.DeepEqual(publicWitness.Vector, publicWitness2.Vector) {
-		fmt.Println("Verification failed for publicWitness")
-		duration := time.Now().UTC().Sub(startingTime)
-		fmt.Printf("Checking publicWitness using reflect takes [%.3f] Seconds \n", duration.Seconds())
-		return false
-	}
-	duration := time.Now().UTC().Sub(startingTime)
-	fmt.Printf("Checking publicWitness using reflect takes [%.3f] Seconds \n", duration.Seconds())
-	return true
+	return reflect.DeepEqual(publicWitness.Vector, publicWitness2.Vector)
 }
 
 // GenPublicWitness generates the publicWitness based on publicInfo
@@ -113,31 +129,49 @@ func GenPublicWitness(publicInfo *PublicInfo) *witness.Witness {
 	assignment := AssignCircuitHelper(publicInfo)
 	publicWitness, err := frontend.NewWitness(assignment, ecc.BN254, frontend.PublicOnly())
 	if err != nil {
-		fmt.Println("Error generating NewWitness in GenPublicWitness")
 		return nil
 	}
 	return publicWitness
 }
 
-// Verify is used to check a Groth16 proof and public witness for the zkMultiSwap
-func Verify(proof *groth16.Proof, setsize uint32, publicInfo *PublicInfo) bool {
-	fileName := KeyPathPrefix + "_" + strconv.FormatInt(int64(setsize), 10)
-	vk, err := LoadVerifyingKey(fileName)
+// Verify is used to check a Groth16 proof and public witness for the zkMultiSwap,
+// it panics if the verifying key cannot be loaded
+func Verify(proof *groth16.Proof, setsize uint32, publicInfo *PublicInfo, opts ...trace.Option) bool {
+	ok, err := VerifyWithError(proof, setsize, publicInfo, opts...)
 	if err != nil {
-		panic("r1cs init error")
+		panic(err)
+	}
+	return ok
+}
+
+// VerifyWithError is Verify returning ErrKeyNotFound instead of panicking if the verifying key cannot be loaded.
+// An invalid proof is not an error, it returns false.
+func VerifyWithError(proof *groth16.Proof, setsize uint32, publicInfo *PublicInfo, opts ...trace.Option) (bool, error) {
+	return VerifyWithConfig(proof, setsize, DefaultConfig, publicInfo, opts...)
+}
+
+// VerifyWithConfig is VerifyWithError with the verifying key of the configuration
+func VerifyWithConfig(proof *groth16.Proof, setsize uint32, config Config, publicInfo *PublicInfo, opts ...trace.Option) (bool, error) {
+	tracer := trace.NewOptions(opts...).Tracer
+	if !config.IsValid() {
+		return false, ErrInvalidConfig
+	}
+	tracer.StartPhase(trace.PhaseSNARKLoad)
+	vk, err := LoadVerifyingKey(config.KeyPath(setsize))
+	tracer.EndPhase(trace.PhaseSNARKLoad)
+	if err != nil {
+		return false, err
+	}
+	if proof == nil {
+		return false, nil
 	}
 	runtime.GC()
-	startingTime := time.Now().UTC()
+	tracer.StartPhase(trace.PhaseSNARKVerify)
+	defer tracer.EndPhase(trace.PhaseSNARKVerify)
 	publicWitness := GenPublicWitness(publicInfo)
 	if publicWitness == nil {
-		return false
+		return false, nil
 	}
 	err = groth16.Verify(*proof, vk, publicWitness)
-	duration := time.Now().UTC().Sub(startingTime)
-	fmt.Printf("Verifying a SNARK proof for set size = %d, takes [%.3f] Seconds \n", setsize, duration.Seconds())
-	if err != nil {
-		fmt.Println("verify error = ", err)
-		return false
-	}
-	return true
+	return err == nil, nil
 }
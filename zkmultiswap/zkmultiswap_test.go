@@ -1,7 +1,11 @@
 package zkmultiswap
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -10,6 +14,8 @@ import (
 	"github.com/consensys/gnark/test"
 
 	"github.com/jiajunxin/rsa_accumulator/accumulator"
+	fiatshamir "github.com/jiajunxin/rsa_accumulator/fiat-shamir"
+	"github.com/jiajunxin/rsa_accumulator/trace"
 )
 
 func TestPublicWitness(t *testing.T) {
@@ -105,3 +111,201 @@ func TestZkMultiSwapFailCases(t *testing.T) {
 	witness.RemainderR2 = testSet.ChallengeL2
 	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BN254))
 }
+
+func TestZkMultiSwapUpdatedSum(t *testing.T) {
+	assert := test.NewAssert(t)
+	testSetSize := uint32(10)
+	circuit := *InitCircuitWithSize(testSetSize)
+	testSet := GenTestSet(testSetSize, accumulator.TrustedSetup())
+	if testSet.UpdatedBalances[0] == testSet.OriginalBalances[0] {
+		t.Fatal("the balance of the first user is not updated")
+	}
+	witness := *AssignCircuit(testSet)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+
+	// the change of the balance of the first user counted twice
+	witness.UpdatedSum = testSet.UpdatedSum + testSet.UpdatedBalances[0] - testSet.OriginalBalances[0]
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BN254))
+}
+
+func TestSetupTranscript(t *testing.T) {
+	setup := accumulator.TrustedSetup()
+	accOld, accMid, accNew := big.NewInt(2), big.NewInt(3), big.NewInt(5)
+	challenge := SetupTranscript(setup, accOld, accMid, accNew, 7).GetChallengeAndAppendTranscript()
+	if SetupTranscript(setup, accOld, accMid, accNew, 7).GetChallengeAndAppendTranscript().Cmp(challenge) != 0 {
+		t.Fatal("the transcript is not deterministic")
+	}
+	other := big.NewInt(11)
+	for name, transcript := range map[string]*fiatshamir.Transcript{
+		"Acc_old": SetupTranscript(setup, other, accMid, accNew, 7),
+		"Acc_mid": SetupTranscript(setup, accOld, other, accNew, 7),
+		"Acc_new": SetupTranscript(setup, accOld, accMid, other, 7),
+		"epoch":   SetupTranscript(setup, accOld, accMid, accNew, 8),
+	} {
+		if transcript.GetChallengeAndAppendTranscript().Cmp(challenge) == 0 {
+			t.Errorf("the challenge does not change with %s", name)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	// the key files are named by the version of the circuit, the keys of older circuits are not found
+	if path := KeyPath(7); path != fmt.Sprintf("zkmultiswap_v%d_7", CircuitVersion) {
+		t.Errorf("KeyPath(7) = %s", path)
+	}
+	// no keys are generated for this size
+	if _, err := VerifyWithError(nil, 7, &PublicInfo{}); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	corrupted := filepath.Join(t.TempDir(), "corrupted")
+	if err := os.WriteFile(corrupted+".vk.save", []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadVerifyingKey(corrupted); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound for a corrupted key, got %v", err)
+	}
+	testSet := GenTestSet(2, accumulator.TrustedSetup())
+	testSet.UserID = testSet.UserID[:1]
+	if _, err := Prove(testSet); err != ErrInvalidStatement {
+		t.Errorf("expected ErrInvalidStatement, got %v", err)
+	}
+}
+
+// pairTracer counts the phases started and not ended
+type pairTracer struct {
+	open map[trace.Phase]int
+}
+
+func (p *pairTracer) StartPhase(phase trace.Phase) { p.open[phase]++ }
+func (p *pairTracer) EndPhase(phase trace.Phase)   { p.open[phase]-- }
+
+func TestErrorsEndPhases(t *testing.T) {
+	tracer := &pairTracer{open: make(map[trace.Phase]int)}
+	// no keys are generated for this size
+	if _, err := VerifyWithError(nil, 7, &PublicInfo{}, trace.WithTracer(tracer)); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	if _, err := Prove(GenTestSet(7, accumulator.TrustedSetup()), trace.WithTracer(tracer)); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	for phase, open := range tracer.open {
+		if open != 0 {
+			t.Errorf("%d unended %s phases", open, phase)
+		}
+	}
+	if len(tracer.open) == 0 {
+		t.Error("no phases traced")
+	}
+}
+
+func TestZkMultiSwapConfig(t *testing.T) {
+	testSetSize := uint32(10)
+	// test.Assert caches the compiled circuits by type and address, every circuit gets its own
+	for _, config := range []Config{Config32, Config64, Config128} {
+		config := config
+		t.Run(config.String(), func(t *testing.T) {
+			assert := test.NewAssert(t)
+			circuit := InitCircuitWithConfig(testSetSize, config)
+			testSet := GenTestSetWithConfig(testSetSize, config, accumulator.TrustedSetup())
+			assert.SolvingSucceeded(circuit, AssignUpdateSet(testSet), test.WithCurves(ecc.BN254))
+
+			// case for a balance out of the range of the configuration
+			witness := AssignUpdateSet(testSet)
+			tooLarge := new(big.Int).Lsh(big.NewInt(1), uint(config.BalanceBits))
+			witness.OriginalBalances[0] = tooLarge
+			witness.UpdatedBalances[0] = tooLarge
+			assert.SolvingFailed(circuit, witness, test.WithCurves(ecc.BN254))
+			// case for a user ID out of the range of the configuration
+			witness = AssignUpdateSet(testSet)
+			witness.UserID[testSetSize-1] = new(big.Int).Lsh(big.NewInt(1), uint(config.IDBits))
+			assert.SolvingFailed(circuit, witness, test.WithCurves(ecc.BN254))
+		})
+	}
+
+	// 64-bits values do not fit into the circuit of the default configuration
+	t.Run("default circuit", func(t *testing.T) {
+		assert := test.NewAssert(t)
+		testSet := GenTestSetWithConfig(testSetSize, Config64, accumulator.TrustedSetup())
+		assert.SolvingFailed(InitCircuitWithSize(testSetSize), AssignUpdateSet(testSet), test.WithCurves(ecc.BN254))
+	})
+
+	// the set of GenTestSet is the one of GenTestSetWithConfig with Config32
+	testSet32 := GenTestSet(testSetSize, accumulator.TrustedSetup()).UpdateSet()
+	testSet := GenTestSetWithConfig(testSetSize, Config32, accumulator.TrustedSetup())
+	for i := range testSet.UserID {
+		if testSet.UserID[i].Cmp(&testSet32.UserID[i]) != 0 || testSet.OriginalBalances[i].Cmp(&testSet32.OriginalBalances[i]) != 0 ||
+			testSet.UpdatedBalances[i].Cmp(&testSet32.UpdatedBalances[i]) != 0 {
+			t.Fatalf("value %d differs from GenTestSet", i)
+		}
+	}
+	if testSet.OriginalSum.Cmp(&testSet32.OriginalSum) != 0 {
+		t.Error("sum differs from GenTestSet")
+	}
+	testSet.Config = Config64
+	testSet.UpdatedBalances[0].Lsh(big.NewInt(1), 64)
+	if testSet.IsValid() {
+		t.Error("set with a 65-bits balance is valid for Config64")
+	}
+}
+
+func TestConfigKeyPath(t *testing.T) {
+	for _, tc := range []struct {
+		config Config
+		want   string
+	}{
+		{Config{}, "4"},
+		{Config32, "4"},
+		{Config64, "id64_balance64_4"},
+		{Config{IDBits: 32, BalanceBits: 128}, "id32_balance128_4"},
+	} {
+		if got, want := tc.config.KeyPath(4), fmt.Sprintf("zkmultiswap_v%d_%s", CircuitVersion, tc.want); got != want {
+			t.Errorf("KeyPath() of %v = %s, want %s", tc.config, got, want)
+		}
+	}
+	for _, config := range []Config{{IDBits: 32}, {IDBits: 32, BalanceBits: MaxBitLength + 1}, {IDBits: -1, BalanceBits: 32}} {
+		if config.IsValid() {
+			t.Errorf("%v is valid", config)
+		}
+		if _, err := VerifyWithConfig(nil, 2, config, &PublicInfo{}); err != ErrInvalidConfig {
+			t.Errorf("VerifyWithConfig() with %v = %v, want %v", config, err, ErrInvalidConfig)
+		}
+	}
+}
+
+func TestProveWithConfig(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	const size = 2
+	for _, config := range []Config{Config32, Config64} {
+		if err = SetupZkMultiswapWithConfig(size, config); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testSet := GenTestSetWithConfig(size, Config64, accumulator.TrustedSetup())
+	proof, err := ProveUpdateSet(testSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyWithConfig(proof, size, Config64, testSet.PublicPart()); err != nil || !ok {
+		t.Errorf("VerifyWithConfig() = %v, %v", ok, err)
+	}
+	// the keys of both configurations coexist, the proof only verifies with its own
+	if ok, err := VerifyWithError(proof, size, testSet.PublicPart()); err != nil || ok {
+		t.Errorf("VerifyWithError() with the default keys = %v, %v", ok, err)
+	}
+	testSet32 := GenTestSet(size, accumulator.TrustedSetup())
+	proof, err = Prove(testSet32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyWithError(proof, size, testSet32.PublicPart()); err != nil || !ok {
+		t.Errorf("VerifyWithError() = %v, %v", ok, err)
+	}
+}